@@ -0,0 +1,117 @@
+package revel
+
+import (
+	"encoding/xml"
+	"errors"
+	"net/http"
+)
+
+// AppError is an application error with the HTTP status it maps to.  Code
+// and Message are shown to the client; Err is the internal cause, which is
+// logged but never rendered.
+//
+// An AppError is a Result, so actions may return one directly:
+//
+//	if hotel == nil {
+//	  return &revel.AppError{Status: 404, Code: "hotel_not_found", Message: "No such hotel"}
+//	}
+//
+// The AppErrorFilter also renders AppErrors that are wrapped in another
+// error, passed to RenderError or raised with panic.
+type AppError struct {
+	Status  int    // The HTTP status, 500 if not set.
+	Code    string // A machine readable code, e.g. "hotel_not_found".
+	Message string // A message for the client.
+	Err     error  // The internal cause, if any.
+}
+
+// NewAppError returns an AppError wrapping the given internal error.
+func NewAppError(status int, code, message string, err error) *AppError {
+	return &AppError{Status: status, Code: code, Message: message, Err: err}
+}
+
+func (e *AppError) Error() string {
+	msg := e.Code + ": " + e.Message
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *AppError) Unwrap() error {
+	return e.Err
+}
+
+func (e *AppError) status() int {
+	if e.Status == 0 {
+		return http.StatusInternalServerError
+	}
+	return e.Status
+}
+
+// Apply renders the error in the requested format: JSON and XML as an
+// object holding the status, code and message, other formats through the
+// templates/errors template for the status (when there is one).
+func (e *AppError) Apply(req *Request, resp *Response) {
+	if e.Err != nil {
+		ERROR.Printf("%s %s: %s", req.Method, req.URL.Path, e)
+	}
+
+	status := e.status()
+	resp.Status = status
+	body := appErrorBody{Status: status, Code: e.Code, Message: e.Message}
+	switch req.Format {
+	case "json":
+		RenderJsonResult{obj: body}.Apply(req, resp)
+		return
+	case "xml":
+		RenderXmlResult{body}.Apply(req, resp)
+		return
+	}
+
+	if tmpl, _, _ := errorTemplate(req, status); tmpl != nil {
+		ErrorResult{Error: &Error{Title: http.StatusText(status), Description: e.Message}}.Apply(req, resp)
+		return
+	}
+	resp.WriteHeader(status, "text/plain; charset=utf-8")
+	resp.Out.Write([]byte(http.StatusText(status) + "\n\n" + e.Message))
+}
+
+type appErrorBody struct {
+	XMLName xml.Name `json:"-" xml:"error"`
+	Status  int      `json:"status" xml:"status"`
+	Code    string   `json:"code" xml:"code"`
+	Message string   `json:"message" xml:"message"`
+}
+
+// AppErrorFilter renders the AppErrors found in the result of the remaining
+// filters and action: either wrapped in an ErrorResult (from RenderError) or
+// raised with panic.  Other panics are passed on to the PanicFilter.  The
+// ErrorResult is found under the results wrapped by later filters (see
+// UnwrapResult), which are replaced along with it.
+func AppErrorFilter(c *Controller, fc []Filter) {
+	defer func() {
+		if err := recover(); err != nil {
+			appErr, ok := asAppError(err)
+			if !ok {
+				panic(err)
+			}
+			c.Result = appErr
+		}
+	}()
+	fc[0](c, fc[1:])
+
+	if result, ok := UnwrapResult(c.Result).(ErrorResult); ok {
+		if appErr, ok := asAppError(result.Error); ok {
+			c.Result = appErr
+		}
+	}
+}
+
+func asAppError(err interface{}) (*AppError, bool) {
+	var appErr *AppError
+	if e, ok := err.(error); ok && errors.As(e, &appErr) {
+		return appErr, true
+	}
+	return nil, false
+}
@@ -0,0 +1,81 @@
+package revel
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAppErrorFilter(t *testing.T) {
+	startFakeBookingApp()
+
+	notFound := &AppError{Status: 404, Code: "hotel_not_found", Message: "No such hotel", Err: errors.New("sql: no rows")}
+	tests := []struct {
+		name   string
+		accept string
+		action Filter
+		body   string
+	}{
+		{"returned", "application/json", func(c *Controller, fc []Filter) {
+			c.Result = notFound
+		}, `{"status":404,"code":"hotel_not_found","message":"No such hotel"}`},
+		{"wrapped", "application/xml", func(c *Controller, fc []Filter) {
+			c.Result = c.RenderError(fmt.Errorf("loading hotel: %w", notFound))
+		}, `<error><status>404</status><code>hotel_not_found</code><message>No such hotel</message></error>`},
+		{"panic", "text/html", func(c *Controller, fc []Filter) {
+			panic(notFound)
+		}, "No such hotel"},
+	}
+
+	for _, test := range tests {
+		req, _ := http.NewRequest("GET", "/hotels/3", nil)
+		req.Header.Set("Accept", test.accept)
+		resp := httptest.NewRecorder()
+		c := NewController(NewRequest(req), NewResponse(resp))
+		AppErrorFilter(c, []Filter{test.action})
+		c.Result.Apply(c.Request, c.Response)
+
+		eq(t, test.name+" status", resp.Code, 404)
+		if !strings.Contains(resp.Body.String(), test.body) {
+			t.Errorf("%s: expected body to contain %q, got %q", test.name, test.body, resp.Body)
+		}
+		if strings.Contains(resp.Body.String(), "sql") {
+			t.Errorf("%s: internal error leaked to the client: %s", test.name, resp.Body)
+		}
+	}
+
+	// Other panics are left to the PanicFilter.
+	defer func() {
+		if err := recover(); err != "boom" {
+			t.Errorf("Expected the panic to be passed on, got %v", err)
+		}
+	}()
+	c := NewController(NewRequest(showRequest), NewResponse(httptest.NewRecorder()))
+	AppErrorFilter(c, []Filter{func(c *Controller, fc []Filter) { panic("boom") }})
+}
+
+// The AppError is found under the results wrapped by the filters after the
+// AppErrorFilter in the default chain, e.g. the SetCookieFilter.
+func TestAppErrorDefaultFilters(t *testing.T) {
+	startFakeBookingApp()
+	oldFilters := Filters
+	defer func() { Filters = oldFilters }()
+
+	Filters = append(append([]Filter(nil), oldFilters[:len(oldFilters)-1]...), func(c *Controller, fc []Filter) {
+		c.SetCookie(&http.Cookie{Name: "seen", Value: "1"})
+		c.Result = c.RenderError(NewAppError(http.StatusNotFound, "hotel_not_found", "No such hotel", nil))
+	})
+
+	req, _ := http.NewRequest("GET", "/hotels/3", nil)
+	req.Header.Set("Accept", "application/json")
+	resp := httptest.NewRecorder()
+	handleInternal(resp, req, nil)
+
+	eq(t, "status", resp.Code, http.StatusNotFound)
+	if body := resp.Body.String(); !strings.Contains(body, `"code":"hotel_not_found"`) {
+		t.Errorf("Expected the AppError to be rendered, got %q", body)
+	}
+}
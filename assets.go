@@ -0,0 +1,164 @@
+package revel
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+var (
+	// assetsDir is the directory of the static assets.
+	// It may be specified in config as "assets.dir", relative to the
+	// application's BasePath (default "public").
+	assetsDir string
+
+	// assetsURL is the URL path the assets are served under.
+	// It may be specified in config as "assets.url" (default "/public/").
+	assetsURL = "/public/"
+
+	// assetsHashedNames is true if asset URLs carry their hash in the file
+	// name (e.g. "css/app.1a2b3c4d5e6f.css") rather than as a query param
+	// (e.g. "css/app.css?v=1a2b3c4d5e6f").
+	// It may be specified in config as "assets.hashednames".
+	assetsHashedNames bool
+
+	// assetHashes caches the content hash of each asset, by its path
+	// relative to assetsDir.  It is not used in dev mode, so that edits show
+	// up immediately.
+	assetHashes sync.Map
+)
+
+// assetHashLength is the number of hex digits of the content hash in the
+// asset URLs.
+const assetHashLength = 12
+
+func init() {
+	TemplateFuncs["asset"] = AssetURL
+	OnAppStart(func() {
+		assetsDir = CurrentConfig().StringDefault("assets.dir", "public")
+		if !filepath.IsAbs(assetsDir) {
+			assetsDir = filepath.Join(BasePath, assetsDir)
+		}
+		assetsURL = strings.TrimSuffix(CurrentConfig().StringDefault("assets.url", "/public/"), "/") + "/"
+		assetsHashedNames = CurrentConfig().BoolDefault("assets.hashednames", false)
+		hashAssets()
+	})
+}
+
+// hashAssets computes the hash of all the assets in assetsDir.
+func hashAssets() {
+	assetHashes.Range(func(name, _ interface{}) bool {
+		assetHashes.Delete(name)
+		return true
+	})
+	filepath.Walk(assetsDir, func(file string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return nil
+		}
+		if rel, err := filepath.Rel(assetsDir, file); err == nil {
+			assetHash(filepath.ToSlash(rel))
+		}
+		return nil
+	})
+}
+
+// AssetURL returns the URL of the given static asset (relative to the assets
+// directory) with its content hash, so that browsers fetch it again whenever it
+// changes.  It is available in templates as "asset", e.g.
+//
+//	<link rel="stylesheet" href="{{asset "css/app.css"}}">
+//
+// renders "/public/css/app.css?v=1a2b3c4d5e6f", or
+// "/public/css/app.1a2b3c4d5e6f.css" if "assets.hashednames" is set.  The
+// AssetsFilter serves these URLs with long cache headers.  Assets that cannot
+// be read are linked to without a hash.
+func AssetURL(name string) string {
+	name = strings.TrimPrefix(path.Clean("/"+name), "/")
+	hash := assetHash(name)
+	switch {
+	case hash == "":
+		return assetsURL + name
+	case assetsHashedNames:
+		ext := path.Ext(name)
+		return assetsURL + strings.TrimSuffix(name, ext) + "." + hash + ext
+	}
+	return assetsURL + name + "?v=" + hash
+}
+
+// assetHash returns the content hash of the given asset, or "" if it cannot
+// be read.
+func assetHash(name string) string {
+	if !DevMode {
+		if hash, ok := assetHashes.Load(name); ok {
+			return hash.(string)
+		}
+	}
+	file, err := os.Open(filepath.Join(assetsDir, filepath.FromSlash(name)))
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+	h := sha256.New()
+	if _, err = io.Copy(h, file); err != nil {
+		return ""
+	}
+	hash := hex.EncodeToString(h.Sum(nil))[:assetHashLength]
+	assetHashes.Store(name, hash)
+	return hash
+}
+
+// AssetsFilter serves the asset URLs returned by AssetURL with headers
+// letting clients cache them for a year, since their content never changes.
+// Other requests (e.g. for assets without a hash, or an outdated one in the
+// query) are passed on to the routes, e.g. to Static.Serve.  Outdated hashed
+// file names are served the current asset, without the cache headers.
+//
+// It must run before the RouterFilter.
+func AssetsFilter(c *Controller, fc []Filter) {
+	if (c.Request.Method != "GET" && c.Request.Method != "HEAD") || !strings.HasPrefix(c.Request.URL.Path, assetsURL) {
+		fc[0](c, fc[1:])
+		return
+	}
+
+	name := strings.TrimPrefix(path.Clean("/"+c.Request.URL.Path[len(assetsURL):]), "/")
+	hash := c.Request.URL.Query().Get("v")
+	if assetsHashedNames {
+		name, hash = splitHashedAssetName(name)
+	}
+	if hash == "" || (!assetsHashedNames && hash != assetHash(name)) {
+		fc[0](c, fc[1:])
+		return
+	}
+
+	file, err := os.Open(filepath.Join(assetsDir, filepath.FromSlash(name)))
+	if err != nil {
+		fc[0](c, fc[1:])
+		return
+	}
+	if hash == assetHash(name) {
+		c.Response.Out.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	}
+	c.Result = c.RenderFile(file, Inline)
+}
+
+// splitHashedAssetName returns the name of the asset and its hash, given its
+// hashed name, e.g. "css/app.css" and "1a2b3c4d5e6f" for
+// "css/app.1a2b3c4d5e6f.css".  The hash is "" if the name has none.
+func splitHashedAssetName(name string) (string, string) {
+	ext := path.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	hashExt := path.Ext(base)
+	hash := strings.TrimPrefix(hashExt, ".")
+	if len(hash) != assetHashLength {
+		return name, ""
+	}
+	if _, err := hex.DecodeString(hash); err != nil {
+		return name, ""
+	}
+	return strings.TrimSuffix(base, hashExt) + ext, hash
+}
@@ -0,0 +1,81 @@
+package revel
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAssets(t *testing.T) {
+	startFakeBookingApp()
+	defer func(dir string, devMode bool) {
+		assetsDir, assetsHashedNames, DevMode = dir, false, devMode
+		hashAssets()
+	}(assetsDir, DevMode)
+
+	assetsDir = t.TempDir()
+	os.MkdirAll(filepath.Join(assetsDir, "css"), 0755)
+	writeAsset := func(content string) string {
+		if err := ioutil.WriteFile(filepath.Join(assetsDir, "css", "app.css"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		sum := sha256.Sum256([]byte(content))
+		return hex.EncodeToString(sum[:])[:assetHashLength]
+	}
+	hash := writeAsset("body {}")
+	hashAssets()
+
+	eq(t, "query", AssetURL("css/app.css"), "/public/css/app.css?v="+hash)
+	eq(t, "missing", AssetURL("/css/missing.css"), "/public/css/missing.css")
+	assetsHashedNames = true
+	eq(t, "hashed name", AssetURL("css/app.css"), "/public/css/app."+hash+".css")
+
+	// Computed once, except in dev mode.
+	newHash := writeAsset("body { margin: 0 }")
+	eq(t, "cached", AssetURL("css/app.css"), "/public/css/app."+hash+".css")
+	DevMode = true
+	eq(t, "dev mode", AssetURL("css/app.css"), "/public/css/app."+newHash+".css")
+	DevMode = false
+
+	run := func(url string) (*httptest.ResponseRecorder, bool) {
+		req, _ := http.NewRequest("GET", url, nil)
+		resp := httptest.NewRecorder()
+		c := NewController(NewRequest(req), NewResponse(resp))
+		called := false
+		AssetsFilter(c, []Filter{func(c *Controller, fc []Filter) { called = true }})
+		if c.Result != nil {
+			c.Result.Apply(c.Request, c.Response)
+		}
+		return resp, called
+	}
+
+	assetsHashedNames = false
+	hashAssets()
+	resp, called := run("/public/css/app.css?v=" + newHash)
+	if called || resp.Body.String() != "body { margin: 0 }" || resp.Header().Get("Cache-Control") != "public, max-age=31536000, immutable" {
+		t.Errorf("Expected the asset with cache headers, got %q (Cache-Control %q)", resp.Body.String(), resp.Header().Get("Cache-Control"))
+	}
+	for _, url := range []string{"/public/css/app.css", "/public/css/app.css?v=" + hash, "/hotels?v=" + newHash} {
+		if _, called := run(url); !called {
+			t.Errorf("Expected %s to be passed on", url)
+		}
+	}
+
+	assetsHashedNames = true
+	resp, called = run("/public/css/app." + newHash + ".css")
+	if called || resp.Body.String() != "body { margin: 0 }" || resp.Header().Get("Cache-Control") == "" {
+		t.Errorf("Expected the hashed asset with cache headers, got %q (Cache-Control %q)", resp.Body.String(), resp.Header().Get("Cache-Control"))
+	}
+	resp, called = run("/public/css/app." + hash + ".css")
+	if called || resp.Body.String() != "body { margin: 0 }" || resp.Header().Get("Cache-Control") != "" {
+		t.Errorf("Expected the current asset without cache headers, got %q (Cache-Control %q)", resp.Body.String(), resp.Header().Get("Cache-Control"))
+	}
+	if _, called = run("/public/css/app.css"); !called {
+		t.Error("Expected an unhashed name to be passed on")
+	}
+}
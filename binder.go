@@ -1,12 +1,20 @@
 package revel
 
 import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"mime/multipart"
+	"net"
+	"net/netip"
+	"net/url"
 	"os"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -68,6 +76,24 @@ var (
 	DateFormat     string
 	DateTimeFormat string
 
+	// CSVDelimiter splits the value of params bound to slice fields tagged
+	// with the "csv" option, e.g. `param:"tags,csv"`.
+	// It may be specified in config as "binder.csv.delimiter".
+	CSVDelimiter = ","
+
+	// bindEmptyAsNil is true if empty params are bound to nil pointers, as
+	// if they were missing, rather than to pointers to zero values.
+	// It may be specified in config as "binder.emptyasnil".  Otherwise it may
+	// be enabled per struct field with the "emptynil" option of the param tag,
+	// e.g. `param:"age,emptynil"`.
+	bindEmptyAsNil bool
+
+	// bindJSONLenient is true if JSON scalars are converted across numbers,
+	// strings and bools to suit the fields they are bound to, e.g. so an ID
+	// may be sent either as 123 or as "123".
+	// It may be specified in config as "binder.json.lenient".
+	bindJSONLenient bool
+
 	IntBinder = Binder{
 		Bind: ValueBinder(func(val string, typ reflect.Type) reflect.Value {
 			if len(val) == 0 {
@@ -155,7 +181,17 @@ var (
 
 	PointerBinder = Binder{
 		Bind: func(params *Params, name string, typ reflect.Type) reflect.Value {
-			return Bind(params, name, typ.Elem()).Addr()
+			if bindEmptyAsNil && params.isEmptyParam(name) {
+				return reflect.Zero(typ)
+			}
+			value := Bind(params, name, typ.Elem())
+			if !value.CanAddr() {
+				// e.g. the reflect.Zero returned for an empty value.
+				ptr := reflect.New(typ.Elem())
+				ptr.Elem().Set(value)
+				return ptr
+			}
+			return value.Addr()
 		},
 		Unbind: func(output map[string]string, name string, val interface{}) {
 			Unbind(output, name, reflect.ValueOf(val).Elem().Interface())
@@ -184,12 +220,97 @@ var (
 		},
 	}
 
+	// Durations are given in the time.ParseDuration format, e.g. "5m" or
+	// "1h30m", or as plain integers: a number of nanoseconds, or of seconds if
+	// "binder.duration.seconds" is set.  They are unbound as nanoseconds,
+	// unless "binder.duration.unbind" is set to "string" (e.g. "5m0s").
+	DurationBinder = Binder{
+		Bind: ValueBinder(func(val string, typ reflect.Type) reflect.Value {
+			if len(val) == 0 {
+				return reflect.Zero(typ)
+			}
+			d, err := parseDuration(val)
+			if err != nil {
+				WARN.Println(err)
+				return reflect.Zero(typ)
+			}
+			return reflect.ValueOf(d).Convert(typ)
+		}),
+		Unbind: func(output map[string]string, name string, val interface{}) {
+			d := val.(time.Duration)
+			if CurrentConfig().StringDefault("binder.duration.unbind", "nanoseconds") == "string" {
+				output[name] = d.String()
+				return
+			}
+			output[name] = strconv.FormatInt(int64(d), 10)
+		},
+	}
+
+	// IP addresses are given in IPv4 dotted decimal ("192.0.2.1") or IPv6
+	// ("2001:db8::1") form.  An invalid address binds to the zero value and is
+	// reported as a validation error on the param.
+	IPBinder = Binder{
+		Bind: bindIP,
+		Unbind: func(output map[string]string, name string, val interface{}) {
+			if ip := val.(net.IP); len(ip) > 0 {
+				output[name] = ip.String()
+			}
+		},
+	}
+
+	AddrBinder = Binder{
+		Bind: bindAddr,
+		Unbind: func(output map[string]string, name string, val interface{}) {
+			if addr := val.(netip.Addr); addr.IsValid() {
+				output[name] = addr.String()
+			}
+		},
+	}
+
 	MapBinder = Binder{
 		Bind:   bindMap,
 		Unbind: unbindMap,
 	}
 )
 
+// parseDuration parses a duration param, accepting plain integers as
+// nanoseconds, or as seconds if so configured.
+func parseDuration(val string) (time.Duration, error) {
+	if n, err := strconv.ParseInt(val, 10, 64); err == nil {
+		if CurrentConfig().BoolDefault("binder.duration.seconds", false) {
+			return time.Duration(n) * time.Second, nil
+		}
+		return time.Duration(n), nil
+	}
+	return time.ParseDuration(val)
+}
+
+func bindIP(params *Params, name string, typ reflect.Type) reflect.Value {
+	val := strings.TrimSpace(params.Get(name))
+	if val == "" {
+		return reflect.Zero(typ)
+	}
+	ip := net.ParseIP(val)
+	if ip == nil {
+		params.bindErrors = append(params.bindErrors, &BindError{Param: name, Value: val, Err: ErrInvalidIP, Type: typ})
+		return reflect.Zero(typ)
+	}
+	return reflect.ValueOf(ip).Convert(typ)
+}
+
+func bindAddr(params *Params, name string, typ reflect.Type) reflect.Value {
+	val := strings.TrimSpace(params.Get(name))
+	if val == "" {
+		return reflect.Zero(typ)
+	}
+	addr, err := netip.ParseAddr(val)
+	if err != nil {
+		params.bindErrors = append(params.bindErrors, &BindError{Param: name, Value: val, Err: ErrInvalidIP, Type: typ})
+		return reflect.Zero(typ)
+	}
+	return reflect.ValueOf(addr)
+}
+
 // Sadly, the binder lookups can not be declared initialized -- that results in
 // an "initialization loop" compile error.
 func init() {
@@ -216,77 +337,73 @@ func init() {
 	KindBinders[reflect.Map] = MapBinder
 
 	TypeBinders[reflect.TypeOf(time.Time{})] = TimeBinder
+	TypeBinders[reflect.TypeOf(time.Duration(0))] = DurationBinder
+	TypeBinders[reflect.TypeOf(net.IP{})] = IPBinder
+	TypeBinders[reflect.TypeOf(netip.Addr{})] = AddrBinder
+	TypeBinders[reflect.TypeOf(url.Values{})] = Binder{bindValues, unbindValues}
 
 	// Uploads
 	TypeBinders[reflect.TypeOf(&os.File{})] = Binder{bindFile, nil}
-	TypeBinders[reflect.TypeOf([]byte{})] = Binder{bindByteArray, nil}
+	TypeBinders[reflect.TypeOf([]byte{})] = Binder{bindByteArray, unbindByteArray}
 	TypeBinders[reflect.TypeOf((*io.Reader)(nil)).Elem()] = Binder{bindReadSeeker, nil}
 	TypeBinders[reflect.TypeOf((*io.ReadSeeker)(nil)).Elem()] = Binder{bindReadSeeker, nil}
 
 	OnAppStart(func() {
-		DateTimeFormat = Config.StringDefault("format.datetime", DEFAULT_DATETIME_FORMAT)
-		DateFormat = Config.StringDefault("format.date", DEFAULT_DATE_FORMAT)
+		DateTimeFormat = CurrentConfig().StringDefault("format.datetime", DEFAULT_DATETIME_FORMAT)
+		DateFormat = CurrentConfig().StringDefault("format.date", DEFAULT_DATE_FORMAT)
 		TimeFormats = append(TimeFormats, DateTimeFormat, DateFormat)
+		CSVDelimiter = CurrentConfig().StringDefault("binder.csv.delimiter", ",")
+		bindEmptyAsNil = CurrentConfig().BoolDefault("binder.emptyasnil", false)
+		bindJSONLenient = CurrentConfig().BoolDefault("binder.json.lenient", false)
 	})
 }
 
-// Used to keep track of the index for individual keyvalues.
-type sliceValue struct {
-	index int           // Index extracted from brackets.  If -1, no index was provided.
-	value reflect.Value // the bound value for this slice element.
-}
-
 // This function creates a slice of the given type, Binds each of the individual
 // elements, and then sets them to their appropriate location in the slice.
-// If elements are provided without an explicit index, they are added (in
-// unspecified order) to the end of the slice.
+// Indexed elements (e.g. items[2].Name) are bound once each, in index order,
+// and the slice is grown to the highest index, leaving the elements at missing
+// indexes zero.  If elements are provided without an explicit index, they are
+// added (in unspecified order) to the end of the slice.
 func bindSlice(params *Params, name string, typ reflect.Type) reflect.Value {
-	// Collect an array of slice elements with their indexes (and the max index).
+	// Collect the indexes of the elements, with the key prefix of each, and
+	// the unindexed elements.
 	maxIndex := -1
-	numNoIndex := 0
-	sliceValues := []sliceValue{}
+	prefixes := make(map[int]string)
+	var unindexed []reflect.Value
 
 	// Factor out the common slice logic (between form values and files).
 	processElement := func(key string, vals []string, files []*multipart.FileHeader) {
 		if !strings.HasPrefix(key, name+"[") {
 			return
 		}
-
-		// Extract the index, and the index where a sub-key starts. (e.g. field[0].subkey)
-		index := -1
-		leftBracket, rightBracket := len(name), strings.Index(key[len(name):], "]")+len(name)
-		if rightBracket > leftBracket+1 {
-			index, _ = strconv.Atoi(key[leftBracket+1 : rightBracket])
+		rightBracket := strings.Index(key[len(name):], "]") + len(name)
+		if rightBracket < len(name) {
+			return
 		}
-		subKeyIndex := rightBracket + 1
 
-		// Handle the indexed case.
-		if index > -1 {
-			if index > maxIndex {
-				maxIndex = index
+		// It's an un-indexed element.  (e.g. element[])
+		if rightBracket == len(name)+1 {
+			for _, val := range vals {
+				// Unindexed values can only be direct-bound.
+				unindexed = append(unindexed, BindValue(val, typ.Elem()))
+			}
+			for _, fileHeader := range files {
+				unindexed = append(unindexed, BindFile(fileHeader, typ.Elem()))
 			}
-			sliceValues = append(sliceValues, sliceValue{
-				index: index,
-				value: Bind(params, key[:subKeyIndex], typ.Elem()),
-			})
 			return
 		}
 
-		// It's an un-indexed element.  (e.g. element[])
-		numNoIndex += len(vals) + len(files)
-		for _, val := range vals {
-			// Unindexed values can only be direct-bound.
-			sliceValues = append(sliceValues, sliceValue{
-				index: -1,
-				value: BindValue(val, typ.Elem()),
-			})
+		// Handle the indexed case, keeping the prefix of sub-keys.
+		// (e.g. field[0] of field[0].subkey)
+		index, err := strconv.Atoi(key[len(name)+1 : rightBracket])
+		if err != nil || index < 0 {
+			return
 		}
-
-		for _, fileHeader := range files {
-			sliceValues = append(sliceValues, sliceValue{
-				index: -1,
-				value: BindFile(fileHeader, typ.Elem()),
-			})
+		if _, ok := prefixes[index]; !ok {
+			prefixes[index] = key[:rightBracket+1]
+		}
+		if index > maxIndex {
+			maxIndex = index
 		}
 	}
 
@@ -297,16 +414,17 @@ func bindSlice(params *Params, name string, typ reflect.Type) reflect.Value {
 		processElement(key, nil, fileHeaders)
 	}
 
-	resultArray := reflect.MakeSlice(typ, maxIndex+1, maxIndex+1+numNoIndex)
-	for _, sv := range sliceValues {
-		if sv.index != -1 {
-			resultArray.Index(sv.index).Set(sv.value)
-		} else {
-			resultArray = reflect.Append(resultArray, sv.value)
-		}
+	indexes := make([]int, 0, len(prefixes))
+	for index := range prefixes {
+		indexes = append(indexes, index)
 	}
+	sort.Ints(indexes)
 
-	return resultArray
+	resultArray := reflect.MakeSlice(typ, maxIndex+1, maxIndex+1+len(unindexed))
+	for _, index := range indexes {
+		resultArray.Index(index).Set(Bind(params, prefixes[index], typ.Elem()))
+	}
+	return reflect.Append(resultArray, unindexed...)
 }
 
 // Break on dots and brackets.
@@ -326,9 +444,23 @@ func unbindSlice(output map[string]string, name string, val interface{}) {
 	}
 }
 
+// bindStruct binds the exported fields of a struct from the params prefixed
+// with its name, e.g. "user.Name" (or "user.name" with the SnakeCaseFieldName
+// namer, see FieldNamer).  A "param" tag overrides the name of the field's
+// param (e.g. `param:"user_id"` binds "user.user_id", `param:"-"`
+// excludes the field), and may mark it as required
+// (`param:"user_id,required"`) or a slice as comma separated
+// (`param:"tags,csv"`, see bindField).  Fields whose param can not be parsed
+// and missing required params are recorded as BindErrors; the ActionInvoker
+// also adds the missing ones to c.Validation.
+//
+// A "default" tag gives the value of a field whose param is absent, e.g.
+// `default:"20"` for a limit.  It is not applied to a param that is present,
+// even if empty or zero.
 func bindStruct(params *Params, name string, typ reflect.Type) reflect.Value {
 	result := reflect.New(typ).Elem()
 	fieldValues := make(map[string]reflect.Value)
+	namer := params.fieldNamer()
 	for key, _ := range params.Values {
 		if !strings.HasPrefix(key, name+".") {
 			continue
@@ -342,34 +474,115 @@ func bindStruct(params *Params, name string, typ reflect.Type) reflect.Value {
 
 		if _, ok := fieldValues[fieldName]; !ok {
 			// Time to bind this field.  Get it and make sure we can set it.
-			fieldValue := result.FieldByName(fieldName)
-			if !fieldValue.IsValid() {
+			field, ok := structFieldByParam(typ, fieldName, namer)
+			if !ok {
 				WARN.Println("W: bindStruct: Field not found:", fieldName)
 				continue
 			}
+			fieldValue := result.FieldByIndex(field.Index)
 			if !fieldValue.CanSet() {
 				WARN.Println("W: bindStruct: Field not settable:", fieldName)
 				continue
 			}
-			boundVal := Bind(params, key[:len(name)+1+fieldLen], fieldValue.Type())
+			paramKey := key[:len(name)+1+fieldLen]
+			recorded := len(params.bindErrors)
+			boundVal := bindField(params, paramKey, field)
 			fieldValue.Set(boundVal)
 			fieldValues[fieldName] = boundVal
+			if raw := params.Get(paramKey); raw != "" && boundVal.IsZero() && len(params.bindErrors) == recorded {
+				if err := valueParseError(raw, field.Type); err != nil {
+					params.bindErrors = append(params.bindErrors, &BindError{field.Name, paramKey, raw, err, field.Type})
+				}
+			}
+		}
+	}
+
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		fieldName, required := paramTag(field, namer)
+		if field.PkgPath != "" || fieldName == "" || params.hasParam(name+"."+fieldName) {
+			continue
+		}
+		if def, ok := bindDefault(field); ok {
+			result.Field(i).Set(def)
+		}
+		if required {
+			params.bindErrors = append(params.bindErrors, &BindError{field.Name, name + "." + fieldName, "", ErrParamRequired, field.Type})
 		}
 	}
 
 	return result
 }
 
+// bindDefault returns the value of the field's "default" tag, bound to the
+// field type, or false if the field has no default.
+func bindDefault(field reflect.StructField) (reflect.Value, bool) {
+	def, ok := field.Tag.Lookup("default")
+	if !ok {
+		return reflect.Value{}, false
+	}
+	value := BindValue(def, field.Type)
+	if def != "" && value.IsZero() {
+		if err := valueParseError(def, field.Type); err != nil {
+			WARN.Printf("revel/binder: invalid default for field %s: %s", field.Name, err)
+		}
+	}
+	return value, true
+}
+
+// structFieldByParam returns the field of the struct bound to the given param
+// name: the field tagged or named (by namer) with that name, or else the
+// field of that name, unless it is bound to a different param.
+func structFieldByParam(typ reflect.Type, paramName string, namer FieldNamer) (reflect.StructField, bool) {
+	for i := 0; i < typ.NumField(); i++ {
+		if name, _ := paramTag(typ.Field(i), namer); name == paramName {
+			return typ.Field(i), true
+		}
+	}
+	field, ok := typ.FieldByName(paramName)
+	if ok {
+		if name, _ := paramTag(field, namer); name != paramName {
+			return reflect.StructField{}, false
+		}
+	}
+	return field, ok
+}
+
+// bindField binds the given struct field from the param of the given name.
+// A slice field tagged with the "csv" option (e.g. `param:"tags,csv"`) is
+// bound from a single value split on the CSVDelimiter, e.g. "tags=a,b,c";
+// repeated or indexed params (e.g. "tags[]=a&tags[]=b") are bound as usual.
+//
+// A pointer field tagged with the "emptynil" option (e.g.
+// `param:"age,emptynil"`) is bound to nil from an empty param, e.g. "age=".
+func bindField(params *Params, name string, field reflect.StructField) reflect.Value {
+	if field.Type.Kind() == reflect.Ptr && hasParamOption(field, "emptynil") && params.isEmptyParam(name) {
+		return reflect.Zero(field.Type)
+	}
+	vals := params.Values[name]
+	if field.Type.Kind() != reflect.Slice || len(vals) != 1 || !hasParamOption(field, "csv") {
+		return Bind(params, name, field.Type)
+	}
+	result := reflect.MakeSlice(field.Type, 0, strings.Count(vals[0], CSVDelimiter)+1)
+	for _, val := range strings.Split(vals[0], CSVDelimiter) {
+		if val = strings.TrimSpace(val); val != "" {
+			result = reflect.Append(result, BindValue(val, field.Type.Elem()))
+		}
+	}
+	return result
+}
+
 func unbindStruct(output map[string]string, name string, iface interface{}) {
 	val := reflect.ValueOf(iface)
 	typ := val.Type()
+	namer := DefaultFieldNamer
 	for i := 0; i < val.NumField(); i++ {
 		structField := typ.Field(i)
 		fieldValue := val.Field(i)
 
 		// PkgPath is specified to be empty exactly for exported fields.
-		if structField.PkgPath == "" {
-			Unbind(output, fmt.Sprintf("%s.%s", name, structField.Name), fieldValue.Interface())
+		if paramName, _ := paramTag(structField, namer); structField.PkgPath == "" && paramName != "" {
+			Unbind(output, fmt.Sprintf("%s.%s", name, paramName), fieldValue.Interface())
 		}
 	}
 }
@@ -422,6 +635,13 @@ func bindFile(params *Params, name string, typ reflect.Type) reflect.Value {
 	return reflect.ValueOf(tmpFile)
 }
 
+// bindByteArray binds the contents of an uploaded file or, failing that, an
+// encoded param value.  Values are decoded according to
+// "binder.bytes.encoding" (which may be overridden per param name, e.g.
+// "binder.bytes.encoding.digest = hex"):
+//
+//	base64, base64url - either base64 alphabet, padded or not (the default)
+//	hex               - hexadecimal
 func bindByteArray(params *Params, name string, typ reflect.Type) reflect.Value {
 	if reader := getMultipartFile(params, name); reader != nil {
 		b, err := ioutil.ReadAll(reader)
@@ -429,8 +649,52 @@ func bindByteArray(params *Params, name string, typ reflect.Type) reflect.Value
 			return reflect.ValueOf(b)
 		}
 		WARN.Println("Error reading uploaded file contents:", err)
+		return reflect.Zero(typ)
 	}
-	return reflect.Zero(typ)
+
+	vals, ok := params.Values[name]
+	if !ok || len(vals) == 0 || vals[0] == "" {
+		return reflect.Zero(typ)
+	}
+	b, err := decodeBytes(byteEncoding(name), vals[0])
+	if err != nil {
+		WARN.Printf("revel/binder: malformed %s value for %s: %s", byteEncoding(name), name, err)
+		return reflect.Zero(typ)
+	}
+	return reflect.ValueOf(b)
+}
+
+func unbindByteArray(output map[string]string, name string, val interface{}) {
+	b := val.([]byte)
+	switch byteEncoding(name) {
+	case "hex":
+		output[name] = hex.EncodeToString(b)
+	case "base64url":
+		output[name] = base64.URLEncoding.EncodeToString(b)
+	default:
+		output[name] = base64.StdEncoding.EncodeToString(b)
+	}
+}
+
+func byteEncoding(name string) string {
+	return CurrentConfig().StringDefault("binder.bytes.encoding."+name, CurrentConfig().StringDefault("binder.bytes.encoding", "base64"))
+}
+
+func decodeBytes(encoding, val string) ([]byte, error) {
+	if encoding == "hex" {
+		return hex.DecodeString(val)
+	}
+
+	// A "+" in an unescaped query string arrives as a space.
+	val = strings.Replace(val, " ", "+", -1)
+	var err error
+	for _, enc := range []*base64.Encoding{base64.StdEncoding, base64.URLEncoding, base64.RawStdEncoding, base64.RawURLEncoding} {
+		var b []byte
+		if b, err = enc.DecodeString(val); err == nil {
+			return b, nil
+		}
+	}
+	return nil, err
 }
 
 func bindReadSeeker(params *Params, name string, typ reflect.Type) reflect.Value {
@@ -459,6 +723,57 @@ func bindMap(params *Params, name string, typ reflect.Type) reflect.Value {
 	return result
 }
 
+// bindValues binds a url.Values argument, for endpoints taking arbitrary
+// params.  (Other map[string][]string types are bound by the MapBinder.)  It holds the params grouped under its
+// name, with the prefix removed:
+//
+//	?filter[status]=open&filter.tag=a&filter.tag=b => filter: {"status": {"open"}, "tag": {"a", "b"}}
+//
+// If there is no such group, it holds all the params except for those bound
+// to the other arguments of the action.
+func bindValues(params *Params, name string, typ reflect.Type) reflect.Value {
+	result := make(url.Values)
+	for key, values := range params.Values {
+		var subKey string
+		if strings.HasPrefix(key, name+"[") && strings.HasSuffix(key, "]") {
+			subKey = key[len(name)+1 : len(key)-1]
+		} else if strings.HasPrefix(key, name+".") {
+			subKey = key[len(name)+1:]
+		} else {
+			continue
+		}
+		result[subKey] = append(result[subKey], values...)
+	}
+
+	if len(result) == 0 {
+		for key, values := range params.Values {
+			if !isActionArgParam(params.actionArgs, name, key) {
+				result[key] = append([]string(nil), values...)
+			}
+		}
+	}
+	return reflect.ValueOf(result)
+}
+
+// isActionArgParam returns true if the param key is bound to one of the
+// given action arguments, other than the one named except.
+func isActionArgParam(args []string, except, key string) bool {
+	for _, arg := range args {
+		if arg != except && (key == arg || strings.HasPrefix(key, arg+".") || strings.HasPrefix(key, arg+"[")) {
+			return true
+		}
+	}
+	return false
+}
+
+func unbindValues(output map[string]string, name string, iface interface{}) {
+	for key, values := range iface.(url.Values) {
+		if len(values) > 0 {
+			output[name+"["+key+"]"] = values[0]
+		}
+	}
+}
+
 func unbindMap(output map[string]string, name string, iface interface{}) {
 	mapValue := reflect.ValueOf(iface)
 	for _, key := range mapValue.MapKeys() {
@@ -467,16 +782,238 @@ func unbindMap(output map[string]string, name string, iface interface{}) {
 	}
 }
 
+// NamedBinder binds the param of the name it is registered for, to a value of
+// the given type.
+type NamedBinder func(params *Params, typ reflect.Type) reflect.Value
+
+var namedBinders = make(map[string]NamedBinder)
+
+// RegisterNamedBinder makes Bind use the given binder for the param of the
+// given name, whatever its type, e.g. to always decrypt a "token" param:
+//
+//	revel.RegisterNamedBinder("token", func(params *revel.Params, typ reflect.Type) reflect.Value {
+//	  return reflect.ValueOf(decrypt(params.Get("token"))).Convert(typ)
+//	})
+//
+// The binder is used for action arguments, Params.Bind and struct fields
+// whose full param name is the given name (e.g. "user.token" for the Token
+// field of a "user" argument), and takes precedence over the route params,
+// the JSON body and the type binders.  It must return a value of the given
+// type, or the zero value if it can not bind it.  Registering another binder
+// for the same name replaces the previous one.
+func RegisterNamedBinder(name string, binder NamedBinder) {
+	namedBinders[name] = binder
+}
+
+// CookieBinder returns a NamedBinder that binds a param from the given cookie,
+// converted to the param type by its type binder.  It lets an action take a
+// cookie value as an argument:
+//
+//	revel.RegisterNamedBinder("theme", revel.CookieBinder("theme"))
+//
+//	func (c App) Index(theme string) revel.Result
+//
+// Cookies are only bound for the names registered this way.  A missing cookie
+// binds to the zero value.
+func CookieBinder(cookie string) NamedBinder {
+	return func(params *Params, typ reflect.Type) reflect.Value {
+		return bindCookie(params, cookie, typ)
+	}
+}
+
+func bindCookie(params *Params, cookie string, typ reflect.Type) reflect.Value {
+	values, ok := params.Cookies[cookie]
+	if !ok {
+		return reflect.Zero(typ)
+	}
+	binder, found := binderForType(typ)
+	if !found {
+		return reflect.Zero(typ)
+	}
+	cookieParams := &Params{Values: url.Values{cookie: values}}
+	value := binder.Bind(cookieParams, cookie, typ)
+	params.bindErrors = append(params.bindErrors, cookieParams.bindErrors...)
+	return value
+}
+
 // Bind takes the name and type of the desired parameter and constructs it
 // from one or more values from Params.
 // Returns the zero value of the type upon any sort of failure.
+//
+// If the request carried a JSON body, the name is looked up in the JSON
+// document, using the same dotted / bracketed syntax as form keys (e.g.
+// "filter.status", "items[0].id").  So the same action can accept either
+// a form or a JSON body.  The precedence is:
+//  1. Fixed and route params (e.g. :id in the routes file)
+//  2. The JSON body
+//  3. The query string and form (as for any other request)
+//
+// Params with a NamedBinder registered for their name are bound by it instead,
+// e.g. from the request cookies with CookieBinder.
 func Bind(params *Params, name string, typ reflect.Type) reflect.Value {
+	if binder, ok := namedBinders[name]; ok {
+		if value := binder(params, typ); value.IsValid() {
+			return value
+		}
+		return reflect.Zero(typ)
+	}
+	if !hasParamIn(params.Fixed, name) && !hasParamIn(params.Route, name) {
+		if value, found := bindJSONPath(params, name, typ); found {
+			return value
+		}
+	}
 	if binder, found := binderForType(typ); found {
 		return binder.Bind(params, name, typ)
 	}
 	return reflect.Zero(typ)
 }
 
+// hasParam returns true if any value or file was submitted for the given name,
+// either directly or as a sub-key (e.g. name.field or name[0]).
+func (p *Params) hasParam(name string) bool {
+	if _, ok := p.Files[name]; ok {
+		return true
+	}
+	return hasParamIn(p.Values, name)
+}
+
+// isEmptyParam returns true if the given param was submitted with an empty
+// value only, e.g. "age=", and without files or sub-keys.
+func (p *Params) isEmptyParam(name string) bool {
+	if vals := p.Values[name]; len(vals) != 1 || vals[0] != "" {
+		return false
+	}
+	if _, ok := p.Files[name]; ok {
+		return false
+	}
+	for key := range p.Values {
+		if strings.HasPrefix(key, name+".") || strings.HasPrefix(key, name+"[") {
+			return false
+		}
+	}
+	return true
+}
+
+// hasParamIn returns true if values holds the given name, either directly or
+// as a sub-key.
+func hasParamIn(values url.Values, name string) bool {
+	if _, ok := values[name]; ok {
+		return true
+	}
+	for key := range values {
+		if strings.HasPrefix(key, name+".") || strings.HasPrefix(key, name+"[") {
+			return true
+		}
+	}
+	return false
+}
+
+// bindJSONPath finds the element at the given path within the JSON body and
+// decodes it into a value of the given type, with its struct fields named by
+// the Params' FieldNamer.
+// Scalars that can not be decoded directly (e.g. "5" for an int) are passed
+// through the regular value binders, as if they had been submitted in a form.
+func bindJSONPath(params *Params, name string, typ reflect.Type) (reflect.Value, bool) {
+	raw, found := params.jsonPath(name)
+	if !found {
+		return reflect.Value{}, false
+	}
+
+	value, err := decodeJSONPath(raw, typ, params.fieldNamer())
+	if err != nil {
+		WARN.Printf("revel/binder: failed to bind JSON path %s to %s", name, typ)
+	}
+	return value, true
+}
+
+// decodeJSONPath decodes the JSON value found at a path into a value of the
+// given type, falling back to the string binders for scalars.  It returns the
+// zero value and why if the value could not be decoded.
+func decodeJSONPath(raw []byte, typ reflect.Type, namer FieldNamer) (reflect.Value, error) {
+	raw = renameJSONFields(raw, typ, namer)
+	value := reflect.New(typ)
+	err := json.Unmarshal(raw, value.Interface())
+	if err == nil {
+		return value.Elem(), nil
+	}
+	if bindJSONLenient {
+		value = reflect.New(typ)
+		if err := unmarshalJSONLenient(raw, value.Interface(), false); err == nil {
+			return value.Elem(), nil
+		}
+	}
+
+	var scalar interface{}
+	if json.Unmarshal(raw, &scalar) == nil {
+		var str string
+		switch v := scalar.(type) {
+		case string:
+			str = v
+		case float64, bool:
+			str = string(raw)
+		default:
+			return reflect.Zero(typ), err
+		}
+		bound := BindValue(str, typ)
+		if bound.IsZero() && str != "" {
+			if parseErr := valueParseError(str, typ); parseErr != nil {
+				return bound, parseErr
+			}
+		}
+		return bound, nil
+	}
+	return reflect.Zero(typ), err
+}
+
+// jsonPath walks the JSON body following the given param name, and returns
+// the element found there.
+// e.g. "filter.status" => {"filter":{"status":...}}, "items[0]" => {"items":[...]}
+// The body is decoded once, on the first lookup, and kept on the Params.
+func (p *Params) jsonPath(name string) (json.RawMessage, bool) {
+	path := strings.FieldsFunc(name, func(r rune) bool {
+		return r == '.' || r == '[' || r == ']'
+	})
+	body := p.bindableJSON()
+	if len(path) == 0 || len(body) == 0 {
+		return nil, false
+	}
+
+	// The body is compared by identity, in case the JSON was replaced.
+	if len(p.jsonTreeOf) != len(body) || &p.jsonTreeOf[0] != &body[0] {
+		p.jsonTree, p.jsonTreeOf = nil, body
+		decoder := json.NewDecoder(bytes.NewReader(body))
+		decoder.UseNumber()
+		if err := decoder.Decode(&p.jsonTree); err != nil {
+			p.jsonTree = nil
+		}
+	}
+
+	node := p.jsonTree
+	for _, key := range path {
+		switch v := node.(type) {
+		case map[string]interface{}:
+			next, ok := v[key]
+			if !ok {
+				return nil, false
+			}
+			node = next
+		case []interface{}:
+			index, err := strconv.Atoi(key)
+			if err != nil || index < 0 || index >= len(v) {
+				return nil, false
+			}
+			node = v[index]
+		default:
+			return nil, false
+		}
+	}
+	raw, err := json.Marshal(node)
+	if err != nil {
+		return nil, false
+	}
+	return raw, true
+}
+
 func BindValue(val string, typ reflect.Type) reflect.Value {
 	return Bind(&Params{Values: map[string][]string{"": {val}}}, "", typ)
 }
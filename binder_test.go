@@ -4,6 +4,10 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
 	"os"
 	"reflect"
 	"sort"
@@ -364,3 +368,503 @@ func valEq(t *testing.T, name string, actual, expected reflect.Value) {
 		eq(t, name, actual.Interface(), expected.Interface())
 	}
 }
+
+func TestBindJSONPath(t *testing.T) {
+	params := &Params{
+		Route:  map[string][]string{"id": {"7"}},
+		Values: map[string][]string{"id": {"7"}},
+		JSON: []byte(`{
+			"id": 99,
+			"filter": {"status": "active", "limit": "25"},
+			"items": [{"Id": 1, "Name": "rob"}, {"Id": 2, "Name": "bill"}],
+			"tags": ["a", "b"]
+		}`),
+	}
+
+	var status string
+	params.Bind(&status, "filter.status")
+	eq(t, "filter.status", status, "active")
+
+	var limit int
+	params.Bind(&limit, "filter.limit")
+	eq(t, "filter.limit", limit, 25)
+
+	var name string
+	params.Bind(&name, "items[1].Name")
+	eq(t, "items[1].Name", name, "bill")
+
+	var item A
+	params.Bind(&item, "items[0]")
+	eq(t, "items[0]", item, A{Id: 1, Name: "rob"})
+
+	var tags []string
+	params.Bind(&tags, "tags")
+	valEq(t, "tags", reflect.ValueOf(tags), reflect.ValueOf([]string{"a", "b"}))
+
+	// Route params take precedence over the body.
+	var id int
+	params.Bind(&id, "id")
+	eq(t, "id", id, 7)
+
+	var missing string
+	params.Bind(&missing, "filter.missing")
+	eq(t, "filter.missing", missing, "")
+
+	// The body is decoded once, and again only if it is replaced.
+	if params.jsonTree == nil {
+		t.Error("Expected the decoded body to be kept")
+	}
+	params.JSON = []byte(`{"filter": {"status": "closed"}}`)
+	params.Bind(&status, "filter.status")
+	eq(t, "replaced filter.status", status, "closed")
+}
+
+func TestBindByteArrayEncodings(t *testing.T) {
+	startFakeBookingApp()
+	Config.SetOption("binder.bytes.encoding.digest", "hex")
+	Config.SetOption("binder.bytes.encoding.token", "base64url")
+
+	blob := []byte{0xfb, 0xff, 0x00, 'r', 'e', 'v', 'e', 'l'}
+	for _, name := range []string{"blob", "digest", "token"} {
+		output := make(map[string]string)
+		Unbind(output, name, blob)
+		params := &Params{Values: url.Values{name: {output[name]}}}
+
+		var actual []byte
+		params.Bind(&actual, name)
+		eq(t, name+" round trip", string(actual), string(blob))
+	}
+
+	params := &Params{Values: url.Values{
+		"std":     {"+/8AcmV2ZWw="},
+		"url":     {"-_8AcmV2ZWw"},
+		"spaced":  {" /8AcmV2ZWw="}, // A "+" that was unescaped as a space.
+		"invalid": {"not base64!"},
+		"digest":  {"zz"},
+	}}
+	for name, expected := range map[string][]byte{"std": blob, "url": blob, "spaced": blob, "invalid": nil, "digest": nil} {
+		var actual []byte
+		params.Bind(&actual, name)
+		eq(t, name, string(actual), string(expected))
+	}
+}
+
+func TestBindDuration(t *testing.T) {
+	startFakeBookingApp()
+
+	params := &Params{Values: url.Values{"ttl": {"1h30m"}, "zero": {"0"}, "seconds": {"300"}, "invalid": {"5 minutes"}}}
+	for name, expected := range map[string]time.Duration{"ttl": 90 * time.Minute, "zero": 0, "seconds": 300, "invalid": 0} {
+		var actual time.Duration
+		params.Bind(&actual, name)
+		eq(t, name, actual, expected)
+	}
+
+	Config.SetOption("binder.duration.seconds", "true")
+	var seconds time.Duration
+	params.Bind(&seconds, "seconds")
+	eq(t, "seconds", seconds, 5*time.Minute)
+
+	var form struct{ TTL, Invalid time.Duration }
+	params = &Params{Values: url.Values{"TTL": {"5m"}, "Invalid": {"5 minutes"}}}
+	errs := params.BindForm(&form)
+	eq(t, "TTL", form.TTL, 5*time.Minute)
+	if len(errs) != 1 || errs[0].(*BindError).Field != "Invalid" {
+		t.Errorf("Expected a bind error for Invalid, got %v", errs)
+	}
+
+	output := make(map[string]string)
+	Unbind(output, "ttl", 90*time.Minute)
+	eq(t, "unbind", output["ttl"], "5400000000000")
+
+	Config.SetOption("binder.duration.unbind", "string")
+	Unbind(output, "ttl", 90*time.Minute)
+	eq(t, "unbind string", output["ttl"], "1h30m0s")
+}
+
+func TestBindIP(t *testing.T) {
+	params := &Params{Values: url.Values{"v4": {"192.0.2.1"}, "v6": {" 2001:db8::1 "}, "invalid": {"192.0.2"}, "empty": {""}}}
+	for name, expected := range map[string]string{"v4": "192.0.2.1", "v6": "2001:db8::1", "invalid": "<nil>", "empty": "<nil>"} {
+		var ip net.IP
+		params.Bind(&ip, name)
+		eq(t, "net.IP "+name, ip.String(), expected)
+
+		var addr netip.Addr
+		params.Bind(&addr, name)
+		if expected == "<nil>" {
+			expected = "invalid IP"
+		}
+		eq(t, "netip.Addr "+name, addr.String(), expected)
+	}
+	if errs := params.BindErrors(); len(errs) != 2 || errs[0].Param != "invalid" || errs[0].Err != ErrInvalidIP {
+		t.Errorf("Expected the invalid addresses to be reported, got %v", errs)
+	}
+
+	var form struct {
+		IP   net.IP
+		Addr netip.Addr
+	}
+	params = &Params{Values: url.Values{"IP": {"::1"}, "Addr": {"localhost"}}}
+	errs := params.BindForm(&form)
+	eq(t, "IP", form.IP.String(), "::1")
+	if len(errs) != 1 || errs[0].(*BindError).Field != "Addr" {
+		t.Errorf("Expected a bind error for Addr, got %v", errs)
+	}
+
+	output := make(map[string]string)
+	Unbind(output, "ip", net.ParseIP("192.0.2.1"))
+	Unbind(output, "addr", netip.MustParseAddr("2001:db8::1"))
+	Unbind(output, "none", netip.Addr{})
+	eq(t, "unbind net.IP", output["ip"], "192.0.2.1")
+	eq(t, "unbind netip.Addr", output["addr"], "2001:db8::1")
+	if _, ok := output["none"]; ok {
+		t.Error("Expected the zero netip.Addr to be left out")
+	}
+}
+
+func TestBindCSV(t *testing.T) {
+	type search struct {
+		Tags  []string `param:"tags,csv"`
+		IDs   []int    `param:"ids,csv"`
+		Names []string `param:"names"`
+	}
+	params := &Params{Values: url.Values{
+		"tags":       {"a, b,,c"},
+		"ids":        {"1,2,3"},
+		"names":      {"a,b"},
+		"s.tags[]":   {"x,y", "z"},
+		"s.ids":      {"4,5"},
+		"s.names[0]": {"a,b"},
+	}}
+
+	var form search
+	params.BindForm(&form)
+	valEq(t, "tags", reflect.ValueOf(form.Tags), reflect.ValueOf([]string{"a", "b", "c"}))
+	valEq(t, "ids", reflect.ValueOf(form.IDs), reflect.ValueOf([]int{1, 2, 3}))
+	eq(t, "names without csv", len(form.Names), 0)
+
+	// Repeated and indexed params are bound as usual.
+	var s search
+	params.Bind(&s, "s")
+	valEq(t, "repeated", reflect.ValueOf(s.Tags), reflect.ValueOf([]string{"x,y", "z"}))
+	valEq(t, "struct ids", reflect.ValueOf(s.IDs), reflect.ValueOf([]int{4, 5}))
+	valEq(t, "indexed", reflect.ValueOf(s.Names), reflect.ValueOf([]string{"a,b"}))
+
+	defer func(delimiter string) { CSVDelimiter = delimiter }(CSVDelimiter)
+	CSVDelimiter = ";"
+	form = search{}
+	(&Params{Values: url.Values{"tags": {"a;b,c"}}}).BindForm(&form)
+	valEq(t, "delimiter", reflect.ValueOf(form.Tags), reflect.ValueOf([]string{"a", "b,c"}))
+}
+
+func TestBindEmptyAsNil(t *testing.T) {
+	type profile struct {
+		Age   *int    `param:"age,emptynil"`
+		Name  *string `param:"name,emptynil"`
+		Stars *int    `param:"stars"`
+	}
+	params := &Params{Values: url.Values{"age": {""}, "name": {"rob"}, "stars": {""}, "p.age": {""}, "p.stars": {""}}}
+
+	var p profile
+	params.Bind(&p, "p")
+	if p.Age != nil || p.Stars == nil || *p.Stars != 0 {
+		t.Errorf("Expected only the emptynil field to be nil, got %+v", p)
+	}
+
+	// Blank fields are left untouched by BindForm.
+	age := 30
+	form := profile{Age: &age}
+	params.BindForm(&form)
+	if form.Age != &age || form.Name == nil || *form.Name != "rob" || form.Stars == nil {
+		t.Errorf("Expected the blank emptynil field to be left alone, got %+v", form)
+	}
+
+	defer func(enabled bool) { bindEmptyAsNil = enabled }(bindEmptyAsNil)
+	bindEmptyAsNil = true
+	var stars *int
+	params.Bind(&stars, "stars")
+	if stars != nil {
+		t.Errorf("Expected an empty param to bind to nil, got %v", *stars)
+	}
+	form = profile{}
+	params.BindForm(&form)
+	if form.Stars != nil {
+		t.Errorf("Expected the blank field to be left alone, got %v", *form.Stars)
+	}
+}
+
+func TestBindSparseSlice(t *testing.T) {
+	params := &Params{Values: url.Values{
+		"items[3].Name":   {"dan"},
+		"items[0].Name":   {"rob"},
+		"items[0].Id":     {"1"},
+		"items[1].Id":     {"2"},
+		"items[-1].Name":  {"neg"},
+		"items[abc].Name": {"bad"},
+	}}
+
+	var items []A
+	params.Bind(&items, "items")
+	expected := []A{{Id: 1, Name: "rob"}, {Id: 2}, {}, {Name: "dan"}}
+	if !reflect.DeepEqual(items, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, items)
+	}
+}
+
+func TestBindFormOrJSON(t *testing.T) {
+	startFakeBookingApp()
+	bindBody := func(contentType, body string) (name string, page, id int) {
+		req, _ := http.NewRequest("POST", "/hotels/3?page=2", strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		params := &Params{Route: url.Values{"id": {"3"}}}
+		if err := ParseParams(params, NewRequest(req)); err != nil {
+			t.Fatal(err)
+		}
+		params.Bind(&name, "name")
+		params.Bind(&page, "page")
+		params.Bind(&id, "id")
+		return
+	}
+
+	// The same names are found in either body, with the query string as the
+	// fallback and route params winning over the body.
+	name, page, id := bindBody("application/json", `{"name":"json","page":5,"id":9}`)
+	eq(t, "json name", name, "json")
+	eq(t, "json page", page, 5)
+	eq(t, "json id", id, 3)
+
+	name, page, id = bindBody("application/json", `{"name":"json"}`)
+	eq(t, "json fallback page", page, 2)
+
+	name, page, id = bindBody("application/x-www-form-urlencoded", "name=form&id=9")
+	eq(t, "form name", name, "form")
+	eq(t, "form page", page, 2)
+	eq(t, "form id", id, 3)
+}
+
+func TestBindStructTags(t *testing.T) {
+	type signup struct {
+		UserId  int    `param:"user_id,required"`
+		Email   string `param:"email,required"`
+		Name    string
+		Role    string `param:"-"`
+		Invited bool   `param:",required"`
+	}
+
+	params := &Params{Values: url.Values{
+		"user.user_id": {"12"},
+		"user.UserId":  {"34"},
+		"user.Name":    {"rob"},
+		"user.Role":    {"admin"},
+	}}
+	var user signup
+	params.Bind(&user, "user")
+	eq(t, "user", user, signup{UserId: 12, Name: "rob"})
+
+	// The missing required params are recorded for the controller.
+	missing := make(map[string]string)
+	for _, err := range params.BindErrors() {
+		missing[err.Param] = err.validationError().Message
+	}
+	valEq(t, "missing required", reflect.ValueOf(missing), reflect.ValueOf(map[string]string{"user.email": "Required", "user.Invited": "Required"}))
+
+	output := make(map[string]string)
+	Unbind(output, "user", signup{UserId: 1, Email: "a@b.c", Name: "n", Role: "r"})
+	valEq(t, "unbound", reflect.ValueOf(output), reflect.ValueOf(map[string]string{"user.user_id": "1", "user.email": "a@b.c", "user.Name": "n", "user.Invited": "false"}))
+}
+
+func TestFieldNamers(t *testing.T) {
+	startFakeBookingApp()
+
+	for name, expected := range map[string]string{
+		"UserName":   "user_name",
+		"ID":         "id",
+		"UserID":     "user_id",
+		"HTTPServer": "http_server",
+		"Address2":   "address2",
+		"name":       "name",
+	} {
+		eq(t, name, snakeCase(name), expected)
+	}
+
+	type profile struct {
+		UserName string
+		UserID   int
+		Email    string `param:"EMail"`
+	}
+	values := url.Values{"user.user_name": {"rob"}, "user.user_id": {"3"}, "user.EMail": {"rob@example.com"}, "user.UserName": {"bob"}}
+
+	// Exact names by default.
+	var user profile
+	(&Params{Values: values}).Bind(&user, "user")
+	eq(t, "exact", user, profile{UserName: "bob", Email: "rob@example.com"})
+
+	// Per bind.
+	user = profile{}
+	(&Params{Values: values, FieldNamer: SnakeCaseFieldName}).Bind(&user, "user")
+	eq(t, "per bind", user, profile{UserName: "rob", UserID: 3, Email: "rob@example.com"})
+
+	// Globally.
+	Config.SetOption("binder.fieldnames", "snake")
+	defer func(namer FieldNamer) { DefaultFieldNamer = namer }(DefaultFieldNamer)
+	DefaultFieldNamer = configFieldNamer()
+	user = profile{}
+	(&Params{Values: values}).Bind(&user, "user")
+	eq(t, "configured", user, profile{UserName: "rob", UserID: 3, Email: "rob@example.com"})
+
+	var form profile
+	(&Params{Values: url.Values{"user_name": {"rob"}}}).BindForm(&form)
+	eq(t, "form", form.UserName, "rob")
+
+	output := make(map[string]string)
+	Unbind(output, "user", profile{UserName: "rob", UserID: 3})
+	valEq(t, "unbound", reflect.ValueOf(output), reflect.ValueOf(map[string]string{"user.user_name": "rob", "user.user_id": "3", "user.EMail": ""}))
+
+	// JSON bodies, down to nested structs, slices and maps.  Names given in
+	// json tags are kept.
+	type account struct {
+		Owner    profile
+		Members  []profile
+		ByRole   map[string]profile
+		PlanName string `json:"plan"`
+	}
+	body := []byte(`{"owner": {"user_name": "rob", "user_id": 3}, "members": [{"user_name": "ann"}],
+		"by_role": {"admin": {"user_id": 4}}, "plan": "pro", "plan_name": "ignored"}`)
+	var acct account
+	if err := (&Params{JSON: body}).BindJSON(&acct); err != nil {
+		t.Fatal(err)
+	}
+	expected := account{
+		Owner:    profile{UserName: "rob", UserID: 3},
+		Members:  []profile{{UserName: "ann"}},
+		ByRole:   map[string]profile{"admin": {UserID: 4}},
+		PlanName: "pro",
+	}
+	if !reflect.DeepEqual(acct, expected) {
+		t.Errorf("Expected the JSON body fields to be named by the namer, got %+v", acct)
+	}
+
+	user = profile{}
+	(&Params{JSON: []byte(`{"user": {"user_name": "rob"}}`)}).Bind(&user, "user")
+	eq(t, "JSON path", user.UserName, "rob")
+}
+
+func TestBindValues(t *testing.T) {
+	params := &Params{Values: url.Values{
+		"q":              {"hotel"},
+		"page":           {"2"},
+		"user.Name":      {"rob"},
+		"filter[status]": {"open"},
+		"filter.tag":     {"a", "b"},
+	}}
+
+	// The params grouped under the argument name.
+	var filter url.Values
+	params.Bind(&filter, "filter")
+	valEq(t, "filter", reflect.ValueOf(filter), reflect.ValueOf(url.Values{"status": {"open"}, "tag": {"a", "b"}}))
+
+	// Otherwise all of them, except for the other action arguments.
+	params.actionArgs = []string{"page", "user", "rest"}
+	var rest url.Values
+	params.Bind(&rest, "rest")
+	valEq(t, "rest", reflect.ValueOf(rest), reflect.ValueOf(url.Values{
+		"q":              {"hotel"},
+		"filter[status]": {"open"},
+		"filter.tag":     {"a", "b"},
+	}))
+
+	// Other map[string][]string types are left to the MapBinder.
+	var other map[string][]string
+	params.Bind(&other, "filter")
+	if _, ok := other["tag"]; ok {
+		t.Errorf("Expected map[string][]string not to be bound as url.Values, got %v", other)
+	}
+
+	output := make(map[string]string)
+	Unbind(output, "filter", url.Values{"status": {"open"}})
+	eq(t, "unbind", output["filter[status]"], "open")
+}
+
+func TestRegisterNamedBinder(t *testing.T) {
+	defer func() { namedBinders = make(map[string]NamedBinder) }()
+	RegisterNamedBinder("token", func(params *Params, typ reflect.Type) reflect.Value {
+		return reflect.ValueOf(strings.ToUpper(params.Get("token"))).Convert(typ)
+	})
+	RegisterNamedBinder("a.Name", func(params *Params, typ reflect.Type) reflect.Value {
+		return reflect.Value{}
+	})
+	params := &Params{Values: url.Values{"token": {"secret"}, "a.Id": {"1"}, "a.Name": {"rob"}}}
+
+	var token string
+	params.Bind(&token, "token")
+	eq(t, "token", token, "SECRET")
+
+	var a A
+	params.Bind(&a, "a")
+	eq(t, "struct field", a, A{Id: 1})
+}
+
+func TestBindStructDefaults(t *testing.T) {
+	type listQuery struct {
+		Limit  int           `default:"20"`
+		Offset int           `default:"5"`
+		Sort   string        `param:"sort" default:"name"`
+		Since  time.Duration `default:"1h"`
+		Page   *int          `default:"1"`
+		Query  string
+	}
+	params := &Params{Values: url.Values{"q.Offset": {"0"}, "q.sort": {""}, "Offset": {"0"}}}
+
+	var q listQuery
+	params.Bind(&q, "q")
+	eq(t, "absent", q.Limit, 20)
+	eq(t, "explicit zero", q.Offset, 0)
+	eq(t, "explicit empty", q.Sort, "")
+	eq(t, "duration", q.Since, time.Hour)
+	if q.Page == nil || *q.Page != 1 {
+		t.Errorf("Expected the pointer default, got %v", q.Page)
+	}
+	eq(t, "no default", q.Query, "")
+
+	var form listQuery
+	if errs := params.BindForm(&form); len(errs) != 0 {
+		t.Errorf("Unexpected errors: %v", errs)
+	}
+	eq(t, "form absent", form.Limit, 20)
+	eq(t, "form explicit zero", form.Offset, 0)
+	eq(t, "form tagged", form.Sort, "name")
+}
+
+func TestBindCookie(t *testing.T) {
+	defer func() { namedBinders = make(map[string]NamedBinder) }()
+	RegisterNamedBinder("theme", CookieBinder("theme"))
+	RegisterNamedBinder("pagesize", CookieBinder("pagesize"))
+	RegisterNamedBinder("missing", CookieBinder("missing"))
+	RegisterNamedBinder("ip", CookieBinder("ip"))
+
+	httpReq, _ := http.NewRequest("GET", "/?theme=light&cookie.Theme=light", nil)
+	httpReq.AddCookie(&http.Cookie{Name: "theme", Value: "dark"})
+	httpReq.AddCookie(&http.Cookie{Name: "pagesize", Value: "50"})
+	httpReq.AddCookie(&http.Cookie{Name: "ip", Value: "nope"})
+	params := &Params{}
+	ParseParams(params, NewRequest(httpReq))
+
+	var theme, missing string
+	var pageSize int
+	params.Bind(&theme, "theme")
+	params.Bind(&pageSize, "pagesize")
+	params.Bind(&missing, "missing")
+	eq(t, "named binder", theme, "dark")
+	eq(t, "int cookie", pageSize, 50)
+	eq(t, "missing cookie", missing, "")
+
+	// Params are only bound from the cookies through a CookieBinder.
+	var cookie struct{ Theme string }
+	params.Bind(&cookie, "cookie")
+	eq(t, "form param", cookie.Theme, "light")
+
+	var ip net.IP
+	params.Bind(&ip, "ip")
+	if errs := params.BindErrors(); ip != nil || len(errs) != 1 || errs[0].Param != "ip" {
+		t.Errorf("Expected the invalid cookie to be reported, got %v %v", ip, errs)
+	}
+}
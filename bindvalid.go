@@ -0,0 +1,302 @@
+package revel
+
+import (
+	"encoding/xml"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ValidationTags make Validators from the rules of a "valid" struct tag, by
+// rule name.  The argument is the text after "=", e.g. "3" for "min=3".
+// Applications may register their own rules.
+var ValidationTags = map[string]func(arg string) (Validator, error){
+	"required": func(string) (Validator, error) { return Required{}, nil },
+	"email":    func(string) (Validator, error) { return ValidEmail(), nil },
+	"min":      intValidationTag(func(n int) Validator { return Min{n} }),
+	"max":      intValidationTag(func(n int) Validator { return Max{n} }),
+	"minsize":  intValidationTag(func(n int) Validator { return MinSize{n} }),
+	"maxsize":  intValidationTag(func(n int) Validator { return MaxSize{n} }),
+	"length":   intValidationTag(func(n int) Validator { return Length{n} }),
+	"range": func(arg string) (Validator, error) {
+		bounds := strings.SplitN(arg, ":", 2)
+		if len(bounds) != 2 {
+			return nil, fmt.Errorf("expected min:max, got %q", arg)
+		}
+		min, err := strconv.Atoi(bounds[0])
+		if err != nil {
+			return nil, err
+		}
+		max, err := strconv.Atoi(bounds[1])
+		if err != nil {
+			return nil, err
+		}
+		return Range{Min{min}, Max{max}}, nil
+	},
+	"match": func(arg string) (Validator, error) {
+		regex, err := regexp.Compile(arg)
+		if err != nil {
+			return nil, err
+		}
+		return Match{regex}, nil
+	},
+}
+
+func intValidationTag(f func(n int) Validator) func(arg string) (Validator, error) {
+	return func(arg string) (Validator, error) {
+		n, err := strconv.Atoi(arg)
+		if err != nil {
+			return nil, err
+		}
+		return f(n), nil
+	}
+}
+
+// BindValid binds the struct pointed to by "dest" from the params (see
+// Params.BindForm) and validates its fields against the rules of their
+// "valid" tags, e.g.
+//
+//	type Signup struct {
+//		Email    string `param:"email" valid:"required,email"`
+//		Age      int    `valid:"range=18:120"`
+//		Nickname string `valid:"minsize=3,maxsize=20,match=^[a-z]+$"`
+//	}
+//
+// The rules are "required", "email", "min=n", "max=n", "range=min:max",
+// "minsize=n", "maxsize=n", "length=n" and "match=regex" (which must come
+// last, as the regex may contain commas), plus those registered in
+// ValidationTags.  Fields that are not required are only validated when set.
+// The fields of nested structs are validated too.
+//
+// The rules are parsed once per type.  Invalid rules are reported on app
+// start for the struct arguments of the actions and the types passed to
+// RegisterValidation; others are logged when first used, and the fields
+// with them fail validation.
+//
+// Params that could not be bound and failed rules are added to c.Validation,
+// keyed by param name (e.g. "email" or "Address.City").  Returns true if
+// there were none:
+//
+//	if !c.BindValid(&form) {
+//		return c.RenderValidationErrors(422)
+//	}
+func (c *Controller) BindValid(dest interface{}) bool {
+	if c.Validation == nil {
+		c.Validation = &Validation{}
+	}
+	errorCount := len(c.Validation.Errors)
+
+	for _, err := range c.Params.BindForm(dest) {
+		c.Validation.Errors = append(c.Validation.Errors, err.(*BindError).validationError())
+	}
+	validateStruct(c.Validation, reflect.ValueOf(dest).Elem(), "", c.Params.fieldNamer())
+
+	return len(c.Validation.Errors) == errorCount
+}
+
+// RegisterValidation checks the "valid" tags of the given structs (or
+// pointers to them) on app start, and panics if any is invalid, so that
+// the types bound by BindValid outside of action arguments fail before
+// serving requests:
+//
+//	func init() {
+//		revel.RegisterValidation(Signup{})
+//	}
+func RegisterValidation(structs ...interface{}) {
+	OnAppStart(func() {
+		for _, s := range structs {
+			if err := checkValidationRules(reflect.TypeOf(s)); err != nil {
+				panic(err)
+			}
+		}
+	})
+}
+
+// fieldRules are the validators of a struct field, parsed from its "valid"
+// tag.
+type fieldRules struct {
+	validators []Validator
+	required   bool
+	err        error // If the tag is invalid.
+}
+
+// validationRules caches the rules of the struct types by field index, as
+// map[int]*fieldRules by reflect.Type.
+var validationRules sync.Map
+
+// validationRulesOf returns the rules of the fields of the struct type.
+func validationRulesOf(typ reflect.Type) map[int]*fieldRules {
+	if rules, ok := validationRules.Load(typ); ok {
+		return rules.(map[int]*fieldRules)
+	}
+	rules := make(map[int]*fieldRules)
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if tag := field.Tag.Get("valid"); tag != "" && field.PkgPath == "" {
+			rules[i] = parseValidationTag(tag)
+			if err := rules[i].err; err != nil {
+				ERROR.Printf("revel/validation: %s.%s: %s", typ, field.Name, err)
+			}
+		}
+	}
+	actual, _ := validationRules.LoadOrStore(typ, rules)
+	return actual.(map[int]*fieldRules)
+}
+
+// parseValidationTag parses the rules of a "valid" tag.
+func parseValidationTag(tag string) *fieldRules {
+	rules := strings.Split(tag, ",")
+	for i, rule := range rules {
+		if strings.HasPrefix(strings.TrimSpace(rule), "match=") {
+			rules = append(rules[:i], strings.Join(rules[i:], ","))
+			break
+		}
+	}
+
+	parsed := &fieldRules{}
+	for _, rule := range rules {
+		name, arg := strings.TrimSpace(rule), ""
+		if eq := strings.Index(name, "="); eq != -1 {
+			name, arg = name[:eq], name[eq+1:]
+		}
+		if name == "" {
+			continue
+		}
+		makeValidator, ok := ValidationTags[name]
+		if !ok {
+			return &fieldRules{err: fmt.Errorf("unknown rule %q", name)}
+		}
+		validator, err := makeValidator(arg)
+		if err != nil {
+			return &fieldRules{err: fmt.Errorf("invalid rule %q: %s", rule, err)}
+		}
+		parsed.required = parsed.required || name == "required"
+		parsed.validators = append(parsed.validators, validator)
+	}
+	return parsed
+}
+
+// checkValidationRules returns the first invalid "valid" tag of the struct
+// type (or pointer to it) and its nested structs.
+func checkValidationRules(typ reflect.Type) error {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct || typ == reflect.TypeOf(time.Time{}) {
+		return nil
+	}
+	rules := validationRulesOf(typ)
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if rule, ok := rules[i]; ok && rule.err != nil {
+			return fmt.Errorf("revel/validation: %s.%s: %s", typ, field.Name, rule.err)
+		}
+		if field.Type.Kind() == reflect.Struct {
+			if err := checkValidationRules(field.Type); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func init() {
+	OnAppStart(func() {
+		// Invalid tags on the struct arguments of the actions fail the start.
+		for _, controller := range controllers {
+			for _, method := range controller.Methods {
+				for _, arg := range method.Args {
+					if err := checkValidationRules(arg.Type); err != nil {
+						ERROR.Panicln(err)
+					}
+				}
+			}
+		}
+	})
+}
+
+// validateStruct checks the fields of the struct v against their "valid" tags,
+// with error keys prefixed by prefix.
+func validateStruct(v *Validation, value reflect.Value, prefix string, namer FieldNamer) {
+	typ := value.Type()
+	rules := validationRulesOf(typ)
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		name, _ := paramTag(field, namer)
+		if field.PkgPath != "" || name == "" {
+			continue
+		}
+		fieldValue := value.Field(i)
+		if alreadyInvalid(v, prefix+name) {
+			continue
+		}
+		if rule, ok := rules[i]; ok {
+			validateField(v, fieldValue, prefix+name, rule)
+		}
+		if fieldValue.Kind() == reflect.Struct && fieldValue.Type() != reflect.TypeOf(time.Time{}) {
+			validateStruct(v, fieldValue, prefix+name+".", namer)
+		}
+	}
+}
+
+// validateField applies the rules to the field, stopping at the first failed
+// one.  A field with invalid rules always fails.
+func validateField(v *Validation, value reflect.Value, key string, rules *fieldRules) {
+	if rules.err != nil {
+		v.Errors = append(v.Errors, &ValidationError{Key: key, Message: "Invalid validation rule"})
+		return
+	}
+	if !rules.required && value.IsZero() {
+		return
+	}
+
+	obj := value.Interface()
+	for _, validator := range rules.validators {
+		if !validator.IsSatisfied(obj) {
+			v.Errors = append(v.Errors, &ValidationError{Key: key, Message: validator.DefaultMessage()})
+			return
+		}
+	}
+}
+
+// alreadyInvalid returns true if the validation has an error for the key.
+func alreadyInvalid(v *Validation, key string) bool {
+	for _, err := range v.Errors {
+		if err.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// RenderValidationErrors renders the validation errors with the given status
+// (e.g. 422), as XML for XML requests and as JSON otherwise:
+//
+//	{"errors": [{"key": "email", "message": "Required"}]}
+func (c *Controller) RenderValidationErrors(status int) Result {
+	c.Response.Status = status
+	body := validationErrorsBody{}
+	if c.Validation != nil {
+		for _, err := range c.Validation.Errors {
+			body.Errors = append(body.Errors, validationErrorBody{err.Key, err.Message})
+		}
+	}
+	if c.Request.Format == "xml" {
+		return RenderXmlResult{body}
+	}
+	return RenderJsonResult{obj: body}
+}
+
+type validationErrorsBody struct {
+	XMLName xml.Name              `json:"-" xml:"errors"`
+	Errors  []validationErrorBody `json:"errors" xml:"error"`
+}
+
+type validationErrorBody struct {
+	Key     string `json:"key" xml:"key"`
+	Message string `json:"message" xml:"message"`
+}
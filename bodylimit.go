@@ -0,0 +1,89 @@
+package revel
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+// ErrBodyTooLarge is returned by Request.BodyString when the body is larger
+// than the given limit.
+var ErrBodyTooLarge = errors.New("revel: content larger than maximum limit")
+
+// BodyLimitFilter caps the size of the request body, so that every parsing
+// path (form, multipart and JSON) shares one ceiling.  Reads past the limit
+// fail, and the ParamsFilter responds with 413 Request Entity Too Large.
+//
+// The limit is read from "http.maxbodysize" (in bytes, 0 means no limit), and
+// may be overridden per action with "http.maxbodysize.<Controller.Action>",
+// e.g. "http.maxbodysize.App.Upload = 104857600".  Unless
+// "http.maxdecompressedsize" is set, the limit also applies to the
+// decompressed size of gzip or deflate encoded bodies.
+//
+// It must run after the RouterFilter and before the ParamsFilter.
+func BodyLimitFilter(c *Controller, fc []Filter) {
+	limit := int64(CurrentConfig().IntDefault("http.maxbodysize", 0))
+	if c.Action != "" {
+		limit = int64(CurrentConfig().IntDefault("http.maxbodysize."+c.Action, int(limit)))
+	}
+	if limit > 0 && c.Request.Body != nil {
+		c.Request.Body = &limitedBody{http.MaxBytesReader(c.Response.Out, c.Request.Body, limit), c.Request}
+		c.Request.bodyLimit = limit
+	}
+	fc[0](c, fc[1:])
+}
+
+// limitedBody records on the request when its size limit was hit.  Parsers
+// do not always pass the underlying read error on (e.g. while reading
+// multipart headers), so the error alone is not enough to detect it.
+type limitedBody struct {
+	io.ReadCloser
+	req *Request
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if isMaxBytesError(err) {
+		b.req.bodyLimitHit = true
+	}
+	return n, err
+}
+
+// bodyTooLarge returns true if the request body exceeded a size limit.
+func (req *Request) bodyTooLarge(err error) bool {
+	return req.bodyLimitHit || isMaxBytesError(err) ||
+		errors.Is(err, ErrDecompressedBodyTooLarge) || errors.Is(err, ErrBodyTooLarge)
+}
+
+// BodyString reads the raw request body, of at most limit bytes, e.g. to
+// verify the signature of a webhook.  It returns ErrBodyTooLarge if the body
+// is larger.  The bytes read are buffered and put back in front of the rest
+// of the body, so that it may still be parsed afterwards (it must be called
+// before the ParamsFilter for that).
+func (req *Request) BodyString(limit int64) (string, error) {
+	if req.Body == nil {
+		return "", nil
+	}
+	b, err := ioutil.ReadAll(io.LimitReader(req.Body, limit+1))
+	req.Body = bufferedBody{io.MultiReader(bytes.NewReader(b), req.Body), req.Body}
+	if err != nil {
+		return "", err
+	}
+	if int64(len(b)) > limit {
+		return "", ErrBodyTooLarge
+	}
+	return string(b), nil
+}
+
+// bufferedBody is a request body whose start has already been read.
+type bufferedBody struct {
+	io.Reader
+	io.Closer
+}
+
+func isMaxBytesError(err error) bool {
+	var maxBytesErr *http.MaxBytesError
+	return errors.As(err, &maxBytesErr)
+}
@@ -0,0 +1,107 @@
+package revel
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBodyLimitFilter(t *testing.T) {
+	startFakeBookingApp()
+	Config.SetOption("http.maxbodysize", "16")
+	Config.SetOption("http.maxbodysize.Hotels.Show", "1024")
+
+	testCases := []struct {
+		action, contentType, body string
+		expectedStatus            int
+	}{
+		{"", "application/x-www-form-urlencoded", "a=1", 0},
+		{"", "application/x-www-form-urlencoded", "a=" + strings.Repeat("x", 32), http.StatusRequestEntityTooLarge},
+		{"", "application/json", `{"a":"` + strings.Repeat("x", 32) + `"}`, http.StatusRequestEntityTooLarge},
+		{"", "multipart/form-data; boundary=" + MULTIPART_BOUNDARY, MULTIPART_FORM_DATA, http.StatusRequestEntityTooLarge},
+		{"Hotels.Show", "application/x-www-form-urlencoded", "a=" + strings.Repeat("x", 32), 0},
+	}
+	for _, tc := range testCases {
+		req, _ := http.NewRequest("POST", "/hotels/3", strings.NewReader(tc.body))
+		req.Header.Set("Content-Type", tc.contentType)
+		c := NewController(NewRequest(req), NewResponse(httptest.NewRecorder()))
+		c.Action = tc.action
+
+		BodyLimitFilter(c, []Filter{ParamsFilter, NilFilter})
+		if c.Response.Status != tc.expectedStatus {
+			t.Errorf("%s %q: (expected) status %d != %d (actual)",
+				tc.contentType, tc.action, tc.expectedStatus, c.Response.Status)
+		}
+	}
+}
+
+func TestBodyLimitFilterDecompressed(t *testing.T) {
+	startFakeBookingApp()
+	Config.SetOption("http.maxbodysize", "256")
+
+	var body bytes.Buffer
+	w := gzip.NewWriter(&body)
+	w.Write([]byte("a=" + strings.Repeat("x", 4096)))
+	w.Close()
+
+	run := func() int {
+		req, _ := http.NewRequest("POST", "/hotels/3", bytes.NewReader(body.Bytes()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("Content-Encoding", "gzip")
+		c := NewController(NewRequest(req), NewResponse(httptest.NewRecorder()))
+		BodyLimitFilter(c, []Filter{ParamsFilter, NilFilter})
+		return c.Response.Status
+	}
+
+	// The compressed body is within the limit, but not the decompressed one.
+	if status := run(); status != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected the body limit to apply to the decompressed body, got status %d", status)
+	}
+
+	Config.SetOption("http.maxdecompressedsize", "8192")
+	if status := run(); status != 0 {
+		t.Errorf("Expected http.maxdecompressedsize to override the body limit, got status %d", status)
+	}
+}
+
+func TestJSONBodyDefaultLimit(t *testing.T) {
+	startFakeBookingApp()
+	body := `{"a":"` + strings.Repeat("x", maxFormSize) + `"}`
+	req, _ := http.NewRequest("POST", "/hotels/3", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	params := &Params{}
+	if err := ParseParams(params, NewRequest(req)); err != ErrBodyTooLarge || len(params.JSON) != maxFormSize {
+		t.Errorf("Expected the JSON body to be cut at %d bytes, got %d (%v)", maxFormSize, len(params.JSON), err)
+	}
+}
+
+func TestRequestBodyString(t *testing.T) {
+	startFakeBookingApp()
+	newRequest := func(body string) *Request {
+		req, _ := http.NewRequest("POST", "/hotels/3", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		return NewRequest(req)
+	}
+
+	// The body may still be parsed after it was read.
+	req := newRequest("name=rob")
+	if body, err := req.BodyString(8); err != nil || body != "name=rob" {
+		t.Errorf("Expected the body, got %q (%v)", body, err)
+	}
+	params := &Params{}
+	if err := ParseParams(params, req); err != nil || params.Get("name") != "rob" {
+		t.Errorf("Expected the body to be parsed afterwards, got %v (%v)", params.Values, err)
+	}
+
+	req = newRequest("name=" + strings.Repeat("x", 32))
+	if _, err := req.BodyString(8); err != ErrBodyTooLarge {
+		t.Errorf("Expected ErrBodyTooLarge, got %v", err)
+	}
+	if !req.bodyTooLarge(ErrBodyTooLarge) {
+		t.Error("Expected ErrBodyTooLarge to be a body size error")
+	}
+}
@@ -0,0 +1,226 @@
+package revel
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync/atomic"
+)
+
+// BodyLogFilter logs the request and response bodies, for debugging API
+// integrations.  It is off unless "log.body" is set, or set for the action,
+// e.g. "log.body.Api.Callback = true", or the request was marked verbose by
+// the VerboseLogFilter.  Bodies are truncated to
+// "log.body.maxlength" bytes (default 1024), bodies that are not text (e.g.
+// images) are left out, and the values of the fields listed in
+// "log.body.redact" (comma separated, e.g. "password,token") are replaced by
+// [REDACTED] in JSON and form bodies, also in nested keys such as
+// "user.Password".
+//
+// The bodies are captured as they are read and written, so the action sees
+// the request body as sent and streamed responses are not buffered.  Only
+// the part of the request body that was read is logged.
+//
+// It must run after the RouterFilter and before the ParamsFilter.
+func BodyLogFilter(c *Controller, fc []Filter) {
+	if !CurrentConfig().BoolDefault("log.body."+c.Action, CurrentConfig().BoolDefault("log.body", false)) && !c.Verbose() {
+		fc[0](c, fc[1:])
+		return
+	}
+
+	limit := CurrentConfig().IntDefault("log.body.maxlength", 1024)
+	var request *bodyCapture
+	if c.Request.Body != nil {
+		request = &bodyCapture{limit: limit}
+		c.Request.Body = capturedBody{io.TeeReader(c.Request.Body, request), c.Request.Body}
+	}
+	fc[0](c, fc[1:])
+
+	log := &bodyLog{c: c, request: request, response: &bodyCapture{limit: limit}}
+	if c.Result == nil {
+		log.print()
+		return
+	}
+	c.Result = bodyLogResult{c.Result, log}
+}
+
+// bodyCapture keeps the first bytes written to it, up to the limit.
+type bodyCapture struct {
+	bytes.Buffer
+	limit int
+	total int
+}
+
+func (b *bodyCapture) Write(p []byte) (int, error) {
+	b.total += len(p)
+	if room := b.limit - b.Len(); room > 0 {
+		if len(p) > room {
+			b.Buffer.Write(p[:room])
+		} else {
+			b.Buffer.Write(p)
+		}
+	}
+	return len(p), nil
+}
+
+// capturedBody is a request body whose reads are captured.
+type capturedBody struct {
+	io.Reader
+	io.Closer
+}
+
+// bodyLogResult captures the response body written by the wrapped result.
+type bodyLogResult struct {
+	Result
+	log *bodyLog
+}
+
+func (r bodyLogResult) Apply(req *Request, resp *Response) {
+	out := resp.Out
+	resp.Out = &capturingResponseWriter{out, r.log.response}
+	defer func() {
+		resp.Out = out
+		r.log.print()
+	}()
+	r.Result.Apply(req, resp)
+}
+
+func (r bodyLogResult) Unwrap() Result {
+	return r.Result
+}
+
+// capturingResponseWriter copies the response body written to it into a
+// bodyCapture.
+type capturingResponseWriter struct {
+	http.ResponseWriter
+	capture *bodyCapture
+}
+
+func (w *capturingResponseWriter) Write(b []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(b)
+	w.capture.Write(b[:n])
+	return n, err
+}
+
+func (w *capturingResponseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *capturingResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *capturingResponseWriter) ReadFrom(r io.Reader) (int64, error) {
+	return readFromWriter(w.ResponseWriter, io.TeeReader(r, w.capture))
+}
+
+func (w *capturingResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return hijackWriter(w.ResponseWriter)
+}
+
+func (w *capturingResponseWriter) CloseNotify() <-chan bool {
+	return closeNotifyWriter(w.ResponseWriter)
+}
+
+type bodyLog struct {
+	c                 *Controller
+	request, response *bodyCapture
+}
+
+func (l *bodyLog) print() {
+	requestBody := "(none)"
+	if l.request != nil {
+		requestBody = formatBody(l.request, l.c.Request.Header.Get("Content-Type"))
+	}
+	INFO.Printf("%s %s (%s)\nRequest body: %s\nResponse body (%d): %s",
+		l.c.Request.Method, l.c.Request.URL.Path, l.c.Action, requestBody,
+		l.c.Response.Status, formatBody(l.response, l.c.Response.Out.Header().Get("Content-Type")))
+}
+
+// formatBody returns the captured body for the log: redacted and marked if
+// truncated, or a placeholder for binary content.
+func formatBody(capture *bodyCapture, contentType string) string {
+	if capture.total == 0 {
+		return "(empty)"
+	}
+	if !isTextContentType(contentType) {
+		return fmt.Sprintf("(%d bytes of %s)", capture.total, contentType)
+	}
+	body := redactBody(capture.String())
+	if capture.total > capture.Len() {
+		body += fmt.Sprintf("... (%d bytes)", capture.total)
+	}
+	return body
+}
+
+func isTextContentType(contentType string) bool {
+	mediaType := strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+	switch {
+	case mediaType == "", strings.HasPrefix(mediaType, "text/"),
+		strings.HasSuffix(mediaType, "json"), strings.HasSuffix(mediaType, "xml"),
+		strings.HasSuffix(mediaType, "javascript"), mediaType == "application/x-www-form-urlencoded":
+		return true
+	}
+	return false
+}
+
+// bodyRedaction holds the patterns matching the "log.body.redact" fields,
+// compiled when the app starts and when the config is reloaded.
+var bodyRedaction atomic.Pointer[bodyRedactor]
+
+func init() {
+	OnAppStart(loadBodyRedaction)
+	OnConfigReload(func(changed map[string]bool) error {
+		if changed["log.body.redact"] {
+			loadBodyRedaction()
+		}
+		return nil
+	})
+}
+
+// bodyRedactor replaces the values of the redacted fields, both as JSON
+// members and as form params.
+type bodyRedactor struct {
+	json, form *regexp.Regexp
+}
+
+// loadBodyRedaction compiles the patterns of the "log.body.redact" fields.
+// A field matches the keys equal to it regardless of case, and the keys
+// whose last dotted or bracketed segment is equal to it, e.g. "password"
+// matches "Password", "user.password" and "user[Password]".
+func loadBodyRedaction() {
+	var fields []string
+	for _, field := range strings.Split(CurrentConfig().StringDefault("log.body.redact", ""), ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			fields = append(fields, regexp.QuoteMeta(field))
+		}
+	}
+	if len(fields) == 0 {
+		bodyRedaction.Store(nil)
+		return
+	}
+	// An optional prefix ending in a separator (URL-escaped in form bodies).
+	key := `(?:[^"&=]*(?:\.|\[|%5b))?(?:` + strings.Join(fields, "|") + `)(?:\]|%5d)?`
+	bodyRedaction.Store(&bodyRedactor{
+		json: regexp.MustCompile(`(?i)("` + key + `"\s*:\s*)("(?:[^"\\]|\\.)*"?|[^,}\]\s]+)`),
+		form: regexp.MustCompile(`(?i)(^|&)(` + key + `=)[^&]*`),
+	})
+}
+
+// redactBody replaces the values of the "log.body.redact" fields, both as JSON
+// members and as form params.
+func redactBody(body string) string {
+	redactor := bodyRedaction.Load()
+	if redactor == nil {
+		return body
+	}
+	body = redactor.json.ReplaceAllString(body, `${1}"[REDACTED]"`)
+	return redactor.form.ReplaceAllString(body, `${1}${2}[REDACTED]`)
+}
@@ -0,0 +1,81 @@
+package revel
+
+import (
+	"bytes"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBodyLogFilter(t *testing.T) {
+	startFakeBookingApp()
+	defer func(logger *log.Logger) { INFO = logger }(INFO)
+	var buf bytes.Buffer
+	INFO = log.New(&buf, "", 0)
+
+	run := func(body, contentType string, result Result) (string, string) {
+		buf.Reset()
+		req, _ := http.NewRequest("POST", "/api/callback", strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		resp := httptest.NewRecorder()
+		c := NewController(NewRequest(req), NewResponse(resp))
+		c.Action = "Api.Callback"
+		var read []byte
+		BodyLogFilter(c, []Filter{func(c *Controller, fc []Filter) {
+			read, _ = ioutil.ReadAll(c.Request.Body)
+			c.Result = result
+		}})
+		if string(read) != body {
+			t.Errorf("Expected the action to read the whole body, got %q", read)
+		}
+		c.Result.Apply(c.Request, c.Response)
+		return buf.String(), resp.Body.String()
+	}
+	jsonResult := RenderJsonResult{obj: map[string]string{"token": "abc", "status": "ok"}}
+
+	if output, _ := run(`{"id":1}`, "application/json", jsonResult); output != "" {
+		t.Errorf("Expected nothing to be logged by default, got %q", output)
+	}
+
+	Config.SetOption("log.body.Api.Callback", "true")
+	Config.SetOption("log.body.maxlength", "40")
+	Config.SetOption("log.body.redact", "password, token")
+	loadBodyRedaction()
+	defer bodyRedaction.Store(nil)
+	output, body := run(`{"user":"rob","password":"s3cr\"et","pin":1234,"note":"`+strings.Repeat("x", 50)+`"}`, "application/json", jsonResult)
+	if body != `{"status":"ok","token":"abc"}` {
+		t.Errorf("Expected the response to be unchanged, got %q", body)
+	}
+	for _, expected := range []string{
+		`POST /api/callback (Api.Callback)`,
+		`Request body: {"user":"rob","password":"[REDACTED]","pin... (107 bytes)`,
+		`Response body (200): {"status":"ok","token":"[REDACTED]"}`,
+	} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected the log to contain %q, got:\n%s", expected, output)
+		}
+	}
+	if strings.Contains(output, "s3cr") || strings.Contains(output, "abc") {
+		t.Errorf("Expected the secrets to be redacted, got:\n%s", output)
+	}
+
+	image := &BinaryResult{Reader: strings.NewReader("\x89PNG"), Name: "logo.png", Delivery: Inline, Length: 4}
+	output, _ = run("user=rob&password=s3cret", "application/x-www-form-urlencoded", image)
+	if !strings.Contains(output, "Request body: user=rob&password=[REDACTED]") || !strings.Contains(output, "(4 bytes of image/png)") {
+		t.Errorf("Expected the binary response to be left out, got:\n%s", output)
+	}
+
+	// Raise the limit so that the redacted fields are not cut off.
+	Config.SetOption("log.body.maxlength", "1024")
+	output, _ = run(`{"Password":"s3cret","user.Token":"abc","passwords":2}`, "application/json", image)
+	if !strings.Contains(output, `Request body: {"Password":"[REDACTED]","user.Token":"[REDACTED]","passwords":2}`) {
+		t.Errorf("Expected the JSON fields to be redacted regardless of case and prefix, got:\n%s", output)
+	}
+	output, _ = run("user.Password=s3cret&user%5Btoken%5D=abc&name=rob", "application/x-www-form-urlencoded", image)
+	if !strings.Contains(output, "Request body: user.Password=[REDACTED]&user%5Btoken%5D=[REDACTED]&name=rob") {
+		t.Errorf("Expected the nested form fields to be redacted, got:\n%s", output)
+	}
+}
@@ -0,0 +1,70 @@
+package revel
+
+import (
+	"net/http"
+	"strings"
+)
+
+// CleanPathFilter replaces the request path with its canonical form before it
+// is routed: repeated slashes are collapsed and "." and ".." segments are
+// resolved, so that /api//users/../admin is seen as /api/admin by the router
+// and by filters matching on path prefixes.  A trailing slash is kept.
+// Paths with ".." segments climbing above the root are rejected with 400 Bad
+// Request.
+//
+// It is enabled by setting "http.cleanpath" to true (default false).  If
+// "http.cleanpath.redirect" is set, GET and HEAD requests are instead
+// redirected (301) to the canonical path.
+func CleanPathFilter(c *Controller, fc []Filter) {
+	if !CurrentConfig().BoolDefault("http.cleanpath", false) {
+		fc[0](c, fc[1:])
+		return
+	}
+
+	cleaned, ok := cleanPath(c.Request.URL.Path)
+	if !ok {
+		c.Response.Status = http.StatusBadRequest
+		c.Result = c.RenderError(&Error{
+			Title:       "Bad Request",
+			Description: "The request path is outside of the application root",
+		})
+		return
+	}
+
+	if cleaned != c.Request.URL.Path {
+		if (c.Request.Method == "GET" || c.Request.Method == "HEAD") && CurrentConfig().BoolDefault("http.cleanpath.redirect", false) {
+			url := *c.Request.URL
+			url.Path, url.RawPath = cleaned, ""
+			c.Response.Status = http.StatusMovedPermanently
+			c.Result = c.Redirect(url.RequestURI())
+			return
+		}
+		TRACE.Printf("Cleaned request path %s to %s", c.Request.URL.Path, cleaned)
+		c.Request.URL.Path, c.Request.URL.RawPath = cleaned, ""
+	}
+	fc[0](c, fc[1:])
+}
+
+// cleanPath returns the canonical form of the given path, or false if it has
+// ".." segments climbing above the root.
+func cleanPath(p string) (string, bool) {
+	var segments []string
+	for _, segment := range strings.Split(p, "/") {
+		switch segment {
+		case "", ".":
+		case "..":
+			if len(segments) == 0 {
+				return "", false
+			}
+			segments = segments[:len(segments)-1]
+		default:
+			segments = append(segments, segment)
+		}
+	}
+
+	cleaned := "/" + strings.Join(segments, "/")
+	if len(segments) > 0 && (strings.HasSuffix(p, "/") || strings.HasSuffix(p, "/.") || strings.HasSuffix(p, "/..")) {
+		cleaned += "/"
+	}
+	return cleaned, true
+}
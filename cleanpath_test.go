@@ -0,0 +1,79 @@
+package revel
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCleanPath(t *testing.T) {
+	for path, expected := range map[string]string{
+		"/":                    "/",
+		"/hotels":              "/hotels",
+		"/hotels/":             "/hotels/",
+		"//hotels///3":         "/hotels/3",
+		"/api//users/../admin": "/api/admin",
+		"/hotels/./3/.":        "/hotels/3/",
+		"/hotels/3/..":         "/hotels/",
+		"/a/b/../../":          "/",
+		"/..hidden/...":        "/..hidden/...",
+	} {
+		if cleaned, ok := cleanPath(path); !ok || cleaned != expected {
+			t.Errorf("Expected %s to be cleaned to %s, got %s (%v)", path, expected, cleaned, ok)
+		}
+	}
+	for _, path := range []string{"/..", "/../etc/passwd", "/hotels/../../admin"} {
+		if _, ok := cleanPath(path); ok {
+			t.Errorf("Expected %s to be rejected", path)
+		}
+	}
+}
+
+func TestCleanPathFilter(t *testing.T) {
+	startFakeBookingApp()
+
+	run := func(method, target string) (*Controller, *httptest.ResponseRecorder, bool) {
+		req, _ := http.NewRequest(method, target, nil)
+		resp := httptest.NewRecorder()
+		c := NewController(NewRequest(req), NewResponse(resp))
+		called := false
+		CleanPathFilter(c, []Filter{func(c *Controller, fc []Filter) { called = true }})
+		if c.Result != nil {
+			c.Result.Apply(c.Request, c.Response)
+		}
+		return c, resp, called
+	}
+
+	// Disabled by default.
+	c, _, called := run("GET", "/hotels//4")
+	if !called || c.Request.URL.Path != "/hotels//4" {
+		t.Errorf("Expected the filter to be disabled by default, got %s", c.Request.URL.Path)
+	}
+
+	Config.SetOption("http.cleanpath", "true")
+	c, _, called = run("GET", "/hotels//3/../4?x=1")
+	if !called || c.Request.URL.Path != "/hotels/4" || c.Request.URL.RawQuery != "x=1" {
+		t.Errorf("Expected the path to be cleaned to /hotels/4, got %s (called: %v)", c.Request.URL, called)
+	}
+
+	_, resp, called := run("GET", "/hotels/%2e%2e/%2e%2e/admin")
+	if called || resp.Code != http.StatusBadRequest {
+		t.Errorf("Expected a path escaping the root to be rejected, got %d (called: %v)", resp.Code, called)
+	}
+
+	Config.SetOption("http.cleanpath.redirect", "true")
+	_, resp, called = run("GET", "/hotels//4?x=1")
+	if called || resp.Code != http.StatusMovedPermanently || resp.Header().Get("Location") != "/hotels/4?x=1" {
+		t.Errorf("Expected a redirect to /hotels/4?x=1, got %d to %s", resp.Code, resp.Header().Get("Location"))
+	}
+	c, _, called = run("POST", "/hotels//4")
+	if !called || c.Request.URL.Path != "/hotels/4" {
+		t.Errorf("Expected POSTs to be cleaned without a redirect, got %s", c.Request.URL.Path)
+	}
+
+	Config.SetOption("http.cleanpath", "false")
+	c, _, called = run("GET", "/hotels//4")
+	if !called || c.Request.URL.Path != "/hotels//4" {
+		t.Errorf("Expected the disabled filter to leave the path alone, got %s", c.Request.URL.Path)
+	}
+}
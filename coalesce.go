@@ -0,0 +1,138 @@
+package revel
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// coalesceWindow is how long a coalesced response is kept for identical
+	// requests arriving after it was rendered.
+	// It may be specified in config as "coalesce.window" (e.g. "100ms").
+	coalesceWindow time.Duration
+
+	// coalesceVary lists the request headers that distinguish otherwise
+	// identical requests.
+	// It may be specified in config as "coalesce.vary".
+	coalesceVary []string
+
+	coalescing = struct {
+		sync.Mutex
+		calls map[string]*coalescedCall
+	}{calls: make(map[string]*coalescedCall)}
+)
+
+func init() {
+	OnAppStart(func() {
+		var err error
+		if coalesceWindow, err = time.ParseDuration(CurrentConfig().StringDefault("coalesce.window", "0s")); err != nil {
+			panic(fmt.Errorf("coalesce.window invalid: %s", err))
+		}
+		coalesceVary = nil
+		for _, header := range strings.Split(CurrentConfig().StringDefault("coalesce.vary", "Accept,Accept-Encoding,Accept-Language"), ",") {
+			if header = strings.TrimSpace(header); header != "" {
+				coalesceVary = append(coalesceVary, header)
+			}
+		}
+	})
+}
+
+// coalescedCall is a request being (or having been) rendered on behalf of
+// all the identical requests that arrived meanwhile.
+type coalescedCall struct {
+	done    chan struct{}
+	resp    *RecordedResponse // nil if the request failed to render.
+	waiters int
+}
+
+// CoalesceFilter makes identical concurrent GET and HEAD requests share a
+// single execution: the first request runs the rest of the chain and the
+// others wait for, and reply with, its rendered response.  Requests are
+// identical if their method, path, query and "coalesce.vary" headers match.
+//
+// It is meant for expensive public endpoints, and should be enabled per
+// action.  For example:
+//
+//	revel.FilterAction(App.Popular).Add(revel.CoalesceFilter)
+//
+// Cookies set while rendering are not shared.
+func CoalesceFilter(c *Controller, fc []Filter) {
+	if c.Request.Method != "GET" && c.Request.Method != "HEAD" {
+		fc[0](c, fc[1:])
+		return
+	}
+
+	key := coalesceKey(c.Request)
+	coalescing.Lock()
+	if call, ok := coalescing.calls[key]; ok {
+		call.waiters++
+		coalescing.Unlock()
+
+		select {
+		case <-call.done:
+		case <-c.Request.Context().Done():
+			return
+		}
+		if call.resp == nil {
+			fc[0](c, fc[1:])
+			return
+		}
+		c.Result = coalescedResult{call.resp, false}
+		return
+	}
+	call := &coalescedCall{done: make(chan struct{})}
+	coalescing.calls[key] = call
+	coalescing.Unlock()
+
+	// Render the response once, so that it may be replayed for everyone.
+	// If that fails (e.g. panics), the waiting requests run on their own.
+	out := c.Response.Out
+	recorder := &RecordedResponse{}
+	c.Response.Out = recorder
+	defer func() {
+		c.Response.Out = out
+		close(call.done)
+		if coalesceWindow > 0 && call.resp != nil {
+			time.AfterFunc(coalesceWindow, func() { forgetCoalescedCall(key, call) })
+		} else {
+			forgetCoalescedCall(key, call)
+		}
+	}()
+
+	fc[0](c, fc[1:])
+	if c.Result != nil {
+		c.Result.Apply(c.Request, c.Response)
+	}
+	call.resp = recorder
+	c.Result = coalescedResult{recorder, true}
+}
+
+func forgetCoalescedCall(key string, call *coalescedCall) {
+	coalescing.Lock()
+	if coalescing.calls[key] == call {
+		delete(coalescing.calls, key)
+	}
+	coalescing.Unlock()
+}
+
+func coalesceKey(req *Request) string {
+	key := req.Method + " " + req.URL.Path + "?" + req.URL.RawQuery
+	for _, header := range coalesceVary {
+		key += "\n" + header + ": " + strings.Join(req.Header[http.CanonicalHeaderKey(header)], ",")
+	}
+	return key
+}
+
+// coalescedResult replays a recorded response.  Cookies are only replayed
+// for the request that rendered it.
+type coalescedResult struct {
+	resp    *RecordedResponse
+	cookies bool
+}
+
+func (r coalescedResult) Apply(req *Request, resp *Response) {
+	r.resp.replay(resp, r.cookies)
+}
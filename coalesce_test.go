@@ -0,0 +1,67 @@
+package revel
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCoalesceFilter(t *testing.T) {
+	startFakeBookingApp()
+
+	var (
+		executions int
+		release    = make(chan struct{})
+		wg         sync.WaitGroup
+		responses  = make([]*httptest.ResponseRecorder, 2)
+	)
+	chain := []Filter{func(c *Controller, fc []Filter) {
+		executions++
+		<-release
+		c.SetCookie(&http.Cookie{Name: "private", Value: "1"})
+		c.Result = c.RenderText("popular")
+	}}
+	serve := func(i int) {
+		defer wg.Done()
+		responses[i] = httptest.NewRecorder()
+		c := NewController(NewRequest(showRequest), NewResponse(responses[i]))
+		CoalesceFilter(c, chain)
+		c.Result.Apply(c.Request, c.Response)
+	}
+	waitFor := func(cond func(*coalescedCall) bool) {
+		for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+			coalescing.Lock()
+			call, ok := coalescing.calls[coalesceKey(NewRequest(showRequest))]
+			done := ok && cond(call)
+			coalescing.Unlock()
+			if done {
+				return
+			}
+		}
+		t.Fatal("Timed out waiting for the coalesced request")
+	}
+
+	wg.Add(2)
+	go serve(0)
+	waitFor(func(*coalescedCall) bool { return true })
+	go serve(1)
+	waitFor(func(call *coalescedCall) bool { return call.waiters == 1 })
+	close(release)
+	wg.Wait()
+
+	eq(t, "executions", executions, 1)
+	for i, resp := range responses {
+		eq(t, "body", resp.Body.String(), "popular")
+		eq(t, "cookie", resp.Header().Get("Set-Cookie") != "", i == 0)
+	}
+
+	// Requests that are not safe to share run on their own.
+	req, _ := http.NewRequest("POST", "/hotels/3", nil)
+	c := NewController(NewRequest(req), NewResponse(httptest.NewRecorder()))
+	CoalesceFilter(c, NilChain)
+	if c.Result != nil {
+		t.Errorf("Expected POST requests not to be coalesced, got %#v", c.Result)
+	}
+}
@@ -1,6 +1,7 @@
 package revel
 
 import (
+	"errors"
 	"io"
 	"net/http"
 	"strconv"
@@ -46,7 +47,7 @@ type CompressResponseWriter struct {
 
 func CompressFilter(c *Controller, fc []Filter) {
 	fc[0](c, fc[1:])
-	if Config.BoolDefault("results.compressed", false) {
+	if CurrentConfig().BoolDefault("results.compressed", false) {
 		if c.Response.Status != http.StatusNoContent && c.Response.Status != http.StatusNotModified {
 			writer := CompressResponseWriter{c.Response.Out, nil, "", false, make(chan bool, 1), nil, false}
 			writer.DetectCompressionType(c.Request, c.Response)
@@ -140,10 +141,40 @@ func (c *CompressResponseWriter) Write(b []byte) (int, error) {
 	return c.ResponseWriter.Write(b)
 }
 
+// ReadFrom copies the content from r.  Content that is not compressed is
+// handed to the underlying ResponseWriter, so that files may be sent with
+// sendfile where the OS supports it.
+func (c *CompressResponseWriter) ReadFrom(r io.Reader) (int64, error) {
+	if !c.headersWritten {
+		c.prepareHeaders()
+		c.headersWritten = true
+	}
+	if rf, ok := c.ResponseWriter.(io.ReaderFrom); ok && c.compressionType == "" && !c.closed {
+		return rf.ReadFrom(r)
+	}
+	// Hide ReadFrom from io.Copy, which would call it again.
+	return io.Copy(struct{ io.Writer }{c}, r)
+}
+
+// Unwrap returns the underlying ResponseWriter.
+func (c *CompressResponseWriter) Unwrap() http.ResponseWriter {
+	return c.ResponseWriter
+}
+
+// Flush sends any compressed data buffered so far to the client.
+func (c *CompressResponseWriter) Flush() {
+	if c.compressionType != "" && !c.closed {
+		c.compressWriter.Flush()
+	}
+	if w, ok := c.ResponseWriter.(http.Flusher); ok {
+		w.Flush()
+	}
+}
+
 // DetectCompressionType method detects the comperssion type
 // from header "Accept-Encoding"
 func (c *CompressResponseWriter) DetectCompressionType(req *Request, resp *Response) {
-	if Config.BoolDefault("results.compressed", false) {
+	if CurrentConfig().BoolDefault("results.compressed", false) {
 		acceptedEncodings := strings.Split(req.Request.Header.Get("Accept-Encoding"), ",")
 
 		largestQ := 0.0
@@ -219,3 +250,83 @@ func (c *CompressResponseWriter) DetectCompressionType(req *Request, resp *Respo
 		}
 	}
 }
+
+var (
+	// ErrUnsupportedContentEncoding is returned when a request body is sent
+	// with a Content-Encoding that can not be decompressed.
+	ErrUnsupportedContentEncoding = errors.New("revel: unsupported request Content-Encoding")
+
+	// ErrDecompressedBodyTooLarge is returned when a compressed request body
+	// expands past "http.maxdecompressedsize".
+	ErrDecompressedBodyTooLarge = errors.New("revel: decompressed request body too large")
+)
+
+// decompressBody replaces a gzip or deflate encoded request body with a
+// reader of its decompressed content.  The decompressed size is capped by
+// "http.maxdecompressedsize" to guard against zip bombs.  It defaults to the
+// limit set by the BodyLimitFilter, so that the same limit applies to the
+// compressed and decompressed bodies, or else to 32 MB.
+func decompressBody(req *Request) error {
+	encoding := strings.ToLower(strings.TrimSpace(req.Header.Get("Content-Encoding")))
+	if encoding == "" || encoding == "identity" || req.Body == nil {
+		return nil
+	}
+
+	var (
+		reader io.ReadCloser
+		err    error
+	)
+	switch encoding {
+	case "gzip", "x-gzip":
+		reader, err = gzip.NewReader(req.Body)
+	case "deflate":
+		reader, err = zlib.NewReader(req.Body)
+	default:
+		return ErrUnsupportedContentEncoding
+	}
+	if err != nil {
+		return err
+	}
+
+	limit := req.bodyLimit
+	if limit <= 0 {
+		limit = 32 << 20
+	}
+	req.Body = &decompressedBody{
+		reader:    reader,
+		body:      req.Body,
+		remaining: int64(CurrentConfig().IntDefault("http.maxdecompressedsize", int(limit))),
+		req:       req,
+	}
+	req.ContentLength = -1
+	req.Header.Del("Content-Encoding")
+	req.Header.Del("Content-Length")
+	return nil
+}
+
+// decompressedBody reads the decompressed request body, failing with
+// ErrDecompressedBodyTooLarge once more than the allowed bytes are produced.
+type decompressedBody struct {
+	reader    io.ReadCloser
+	body      io.ReadCloser
+	remaining int64
+	req       *Request
+}
+
+func (b *decompressedBody) Read(p []byte) (int, error) {
+	if int64(len(p)) > b.remaining+1 {
+		p = p[:b.remaining+1]
+	}
+	n, err := b.reader.Read(p)
+	b.remaining -= int64(n)
+	if b.remaining < 0 {
+		b.req.bodyLimitHit = true
+		return n + int(b.remaining), ErrDecompressedBodyTooLarge
+	}
+	return n, err
+}
+
+func (b *decompressedBody) Close() error {
+	b.reader.Close()
+	return b.body.Close()
+}
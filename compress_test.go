@@ -1,6 +1,8 @@
 package revel
 
 import (
+	"io"
+	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
@@ -49,3 +51,38 @@ func BenchmarkRenderUnCompressed(b *testing.B) {
 		hotels.Show(3).Apply(c.Request, c.Response)
 	}
 }
+
+// readFromRecorder records whether content was handed to ReadFrom.
+type readFromRecorder struct {
+	*httptest.ResponseRecorder
+	readFrom bool
+}
+
+func (r *readFromRecorder) ReadFrom(src io.Reader) (int64, error) {
+	r.readFrom = true
+	return io.Copy(r.ResponseRecorder, src)
+}
+
+func TestCompressResponseWriterReadFrom(t *testing.T) {
+	startFakeBookingApp()
+	Config.SetOption("results.compressed", "true")
+	copyFile := func(contentType string) *readFromRecorder {
+		req, _ := http.NewRequest("GET", "/public/file", nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		out := &readFromRecorder{ResponseRecorder: httptest.NewRecorder()}
+		writer := CompressResponseWriter{out, nil, "", false, make(chan bool, 1), nil, false}
+		writer.DetectCompressionType(NewRequest(req), NewResponse(out))
+		writer.Header().Set("Content-Type", contentType)
+		io.Copy(&writer, struct{ io.Reader }{strings.NewReader("content")}) // Hide WriteTo, to use ReadFrom.
+		writer.Close()
+		return out
+	}
+
+	// Content that is not compressed is handed to the underlying writer.
+	if out := copyFile("video/mp4"); !out.readFrom || out.Body.String() != "content" {
+		t.Errorf("Expected the content to be read by the underlying writer, got %q (readFrom: %v)", out.Body.String(), out.readFrom)
+	}
+	if out := copyFile("text/html"); out.readFrom || out.Header().Get("Content-Encoding") != "gzip" {
+		t.Errorf("Expected the content to be compressed, got %v (readFrom: %v)", out.Header(), out.readFrom)
+	}
+}
@@ -0,0 +1,121 @@
+package revel
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// ConcurrencyLimitFilter limits how many requests to an action may run at
+// the same time, e.g. to protect a downstream service with limited capacity.
+// Requests past the limit wait for a slot; once the wait queue is full they
+// are rejected with 503 Service Unavailable.  A request whose context is
+// cancelled while waiting (e.g. the client went away) gives up its place.
+//
+// The limit is read from "concurrency.limit" (0 means no limit) and the
+// queue size from "concurrency.queue" (defaults to 0, no waiting).  Both may
+// be overridden per action, e.g. "concurrency.limit.App.Report = 4".  Each
+// action is limited separately.
+//
+// It must run after the RouterFilter.
+func ConcurrencyLimitFilter(c *Controller, fc []Filter) {
+	limiter := actionLimiter(c.Action)
+	if limiter == nil {
+		fc[0](c, fc[1:])
+		return
+	}
+
+	select {
+	case limiter.slots <- struct{}{}:
+	default:
+		limiter.Lock()
+		if limiter.waiting >= limiter.queue {
+			limiter.Unlock()
+			c.Response.Status = http.StatusServiceUnavailable
+			c.Result = c.RenderError(&Error{
+				Title:       "Service Unavailable",
+				Description: "Too many concurrent requests for " + c.Action,
+			})
+			return
+		}
+		limiter.waiting++
+		limiter.Unlock()
+
+		select {
+		case limiter.slots <- struct{}{}:
+			limiter.dequeue()
+		case <-c.Request.Context().Done():
+			limiter.dequeue()
+			TRACE.Println("Request cancelled while waiting for", c.Action)
+			return
+		}
+	}
+	defer func() { <-limiter.slots }()
+
+	fc[0](c, fc[1:])
+}
+
+type concurrencyLimiter struct {
+	sync.Mutex
+	slots   chan struct{}
+	queue   int
+	waiting int
+}
+
+func (l *concurrencyLimiter) dequeue() {
+	l.Lock()
+	l.waiting--
+	l.Unlock()
+}
+
+var concurrencyLimiters = struct {
+	sync.Mutex
+	actions map[string]*concurrencyLimiter
+}{actions: make(map[string]*concurrencyLimiter)}
+
+func init() {
+	// The limiters are made from the config of the app being started, and
+	// again when it changes.  Requests already running keep their limiter;
+	// new ones get the new limits.
+	OnAppStart(resetConcurrencyLimiters)
+	OnConfigReload(func(changed map[string]bool) error {
+		for option := range changed {
+			if strings.HasPrefix(option, "concurrency.") {
+				resetConcurrencyLimiters()
+				break
+			}
+		}
+		return nil
+	})
+}
+
+// resetConcurrencyLimiters drops the limiters, which are made again from the
+// current config on the next request to each action.
+func resetConcurrencyLimiters() {
+	concurrencyLimiters.Lock()
+	concurrencyLimiters.actions = make(map[string]*concurrencyLimiter)
+	concurrencyLimiters.Unlock()
+}
+
+// actionLimiter returns the limiter for the given action, or nil if it is
+// not limited.
+func actionLimiter(action string) *concurrencyLimiter {
+	concurrencyLimiters.Lock()
+	defer concurrencyLimiters.Unlock()
+	if limiter, ok := concurrencyLimiters.actions[action]; ok {
+		return limiter
+	}
+
+	var limiter *concurrencyLimiter
+	limit := CurrentConfig().IntDefault("concurrency.limit", 0)
+	queue := CurrentConfig().IntDefault("concurrency.queue", 0)
+	if action != "" {
+		limit = CurrentConfig().IntDefault("concurrency.limit."+action, limit)
+		queue = CurrentConfig().IntDefault("concurrency.queue."+action, queue)
+	}
+	if limit > 0 {
+		limiter = &concurrencyLimiter{slots: make(chan struct{}, limit), queue: queue}
+	}
+	concurrencyLimiters.actions[action] = limiter
+	return limiter
+}
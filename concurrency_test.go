@@ -0,0 +1,86 @@
+package revel
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestConcurrencyLimitFilter(t *testing.T) {
+	startFakeBookingApp()
+	Config.SetOption("concurrency.limit.Hotels.Show", "1")
+	Config.SetOption("concurrency.queue.Hotels.Show", "1")
+	defer resetConcurrencyLimiters()
+
+	newController := func(ctx context.Context) *Controller {
+		c := NewController(NewRequest(showRequest.WithContext(ctx)), NewResponse(httptest.NewRecorder()))
+		c.Action = "Hotels.Show"
+		return c
+	}
+
+	// The first request takes the only slot until it is released.
+	release, running := make(chan struct{}), make(chan struct{})
+	go ConcurrencyLimitFilter(newController(context.Background()), []Filter{func(c *Controller, fc []Filter) {
+		close(running)
+		<-release
+	}})
+	<-running
+
+	// The second request waits in the queue, and leaves it when cancelled.
+	ctx, cancel := context.WithCancel(context.Background())
+	queued := make(chan *Controller)
+	go func() {
+		c := newController(ctx)
+		ConcurrencyLimitFilter(c, []Filter{func(c *Controller, fc []Filter) {
+			t.Error("Expected the cancelled request not to run")
+		}})
+		queued <- c
+	}()
+	limiter := actionLimiter("Hotels.Show")
+	for deadline := time.Now().Add(time.Second); ; time.Sleep(time.Millisecond) {
+		limiter.Lock()
+		waiting := limiter.waiting
+		limiter.Unlock()
+		if waiting == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for the request to be queued")
+		}
+	}
+
+	// The queue is full, so the third request is rejected.
+	c := newController(context.Background())
+	ConcurrencyLimitFilter(c, NilChain)
+	eq(t, "status", c.Response.Status, http.StatusServiceUnavailable)
+
+	cancel()
+	if c := <-queued; c.Result != nil {
+		t.Errorf("Expected no result for the cancelled request, got %#v", c.Result)
+	}
+
+	// Once the slot is released, the next request (queued meanwhile) runs.
+	close(release)
+	ran := false
+	ConcurrencyLimitFilter(newController(context.Background()), []Filter{func(c *Controller, fc []Filter) { ran = true }})
+	if !ran {
+		t.Error("Expected the request to run after the slot was released")
+	}
+
+	// Actions without a configured limit are not affected.
+	c = newController(context.Background())
+	c.Action = "Hotels.Index"
+	ran = false
+	ConcurrencyLimitFilter(c, []Filter{func(c *Controller, fc []Filter) { ran = true }})
+	if !ran {
+		t.Error("Expected unlimited actions to run")
+	}
+
+	// The limiters are made again from the config on app start.
+	Config.SetOption("concurrency.limit.Hotels.Show", "3")
+	eq(t, "cached limit", cap(actionLimiter("Hotels.Show").slots), 1)
+	resetConcurrencyLimiters()
+	eq(t, "new limit", cap(actionLimiter("Hotels.Show").slots), 3)
+}
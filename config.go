@@ -0,0 +1,236 @@
+package revel
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/revel/config"
+)
+
+// ConfOverlays are the config files merged on top of app.conf, in order, so
+// that later files override earlier ones.  "{mode}" is replaced by the run
+// mode.  Each is looked up in all of the ConfPaths, and missing files are
+// ignored; e.g. a conf/app.local.conf kept out of version control may hold
+// machine specific settings and secrets.
+//
+// It may be changed by the application, in an init() function.
+var ConfOverlays = []string{"app.{mode}.conf", "app.local.conf"}
+
+// envVarRegExp matches environment variable references in config values:
+// "${NAME}", or "${NAME:-default}" to use a default if NAME is unset or empty.
+var envVarRegExp = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(?::-([^}]*))?\}`)
+
+// loadConfig loads app.conf and the ConfOverlays for the given mode from the
+// given paths, and expands the environment variables referenced in values.
+func loadConfig(paths []string, mode string) (*config.Context, error) {
+	ctx, err := config.LoadContext("app.conf", paths)
+	if err != nil {
+		return nil, err
+	}
+	for _, overlay := range ConfOverlays {
+		name := strings.Replace(overlay, "{mode}", mode, -1)
+		for _, p := range paths {
+			conf, err := config.ReadDefault(filepath.Join(p, name))
+			if err != nil {
+				if os.IsNotExist(err) {
+					continue
+				}
+				return nil, err
+			}
+			ctx.Raw().Merge(conf)
+		}
+	}
+	expandConfigEnv(ctx.Raw())
+	return ctx, nil
+}
+
+// expandConfigEnv replaces the environment variable references in all
+// config values.  Unset variables expand to the empty string.
+func expandConfigEnv(conf *config.Config) {
+	for _, section := range conf.Sections() {
+		options, _ := conf.Options(section)
+		for _, option := range options {
+			value, err := conf.RawString(section, option)
+			if err != nil || !strings.Contains(value, "${") {
+				continue
+			}
+			conf.AddOption(section, option, expandEnv(value))
+		}
+	}
+}
+
+func expandEnv(value string) string {
+	return envVarRegExp.ReplaceAllStringFunc(value, func(ref string) string {
+		match := envVarRegExp.FindStringSubmatch(ref)
+		if v := os.Getenv(match[1]); v != "" {
+			return v
+		}
+		return match[2]
+	})
+}
+
+// ConfigError lists the problems found while loading a config struct.
+type ConfigError []string
+
+func (e ConfigError) Error() string {
+	return "config: " + strings.Join(e, "; ")
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// LoadConfig populates the struct pointed to by dest from Config.  Fields are
+// read from the key named by their conf tag, or from the default tag if the
+// key is missing:
+//
+//	type ServerConfig struct {
+//	  Port    int           `conf:"server.port" default:"9000"`
+//	  DBSpec  string        `conf:"db.spec,required"`
+//	  Timeout time.Duration `conf:"server.timeout" default:"30s"`
+//	  Tags    []string      `conf:"server.tags"` // comma separated
+//	  Cache   struct {
+//	    Size int `conf:"size"` // cache.size
+//	  } `conf:"cache"`
+//	}
+//
+// Strings, bools, numbers, durations and string slices are supported.  The
+// keys of a tagged nested struct are prefixed with its tag.  Fields whose
+// key and default are missing are left as is, unless marked required.
+//
+// All the missing required keys and unparseable values are reported in a
+// ConfigError.
+func LoadConfig(dest interface{}) error {
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("config: %T is not a pointer to a struct", dest)
+	}
+	var errs ConfigError
+	loadConfigStruct(v.Elem(), "", &errs)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// RegisterConfig populates the struct pointed to by dest with LoadConfig on
+// app start, before the other OnAppStart functions run.  It panics if the
+// config is invalid, so that misconfigured apps fail before serving requests.
+func RegisterConfig(dest interface{}) {
+	OnAppStart(func() {
+		if err := LoadConfig(dest); err != nil {
+			panic(err)
+		}
+	}, 0)
+}
+
+func loadConfigStruct(v reflect.Value, prefix string, errs *ConfigError) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		tag, tagged := field.Tag.Lookup("conf")
+		key, required := tag, false
+		if comma := strings.Index(tag, ","); comma >= 0 {
+			key, required = tag[:comma], tag[comma+1:] == "required"
+		}
+		if field.Type.Kind() == reflect.Struct && field.Type != durationType {
+			if key != "" {
+				key = prefix + key + "."
+			} else {
+				key = prefix
+			}
+			loadConfigStruct(v.Field(i), key, errs)
+			continue
+		}
+		if !tagged || key == "" {
+			continue
+		}
+		key = prefix + key
+
+		value, ok := CurrentConfig().String(key)
+		if !ok {
+			value, ok = field.Tag.Lookup("default")
+		}
+		if !ok {
+			if required {
+				*errs = append(*errs, key+" is required")
+			}
+			continue
+		}
+		if err := setConfigValue(v.Field(i), value); err != nil {
+			*errs = append(*errs, fmt.Sprintf("%s: invalid value %q: %s", key, value, err))
+		}
+	}
+}
+
+func setConfigValue(v reflect.Value, value string) error {
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Bool:
+		b, err := parseConfigBool(value)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, v.Type().Bits())
+		if err != nil {
+			return err.(*strconv.NumError).Err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, v.Type().Bits())
+		if err != nil {
+			return err.(*strconv.NumError).Err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, v.Type().Bits())
+		if err != nil {
+			return err.(*strconv.NumError).Err
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported type %s", v.Type())
+		}
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items).Convert(v.Type()))
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+// parseConfigBool accepts the same values as Config.Bool.
+func parseConfigBool(value string) (bool, error) {
+	switch strings.ToLower(value) {
+	case "1", "t", "true", "y", "yes", "on":
+		return true, nil
+	case "0", "f", "false", "n", "no", "off":
+		return false, nil
+	}
+	return false, fmt.Errorf("not a bool")
+}
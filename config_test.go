@@ -0,0 +1,126 @@
+package revel
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/revel/config"
+)
+
+func TestLoadConfigOverlays(t *testing.T) {
+	frameworkDir, appDir := t.TempDir(), t.TempDir()
+	writeConf := func(dir, name, content string) {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeConf(appDir, "app.conf", "app.name = base\ndb.host = localhost\ndb.user = app\n"+
+		"db.password = ${TEST_DB_PASSWORD}\ndb.port = ${TEST_DB_PORT:-5432}\n[dev]\nmode.dev = true\n[prod]\ndb.host = db.internal\n")
+	writeConf(appDir, "app.prod.conf", "[prod]\ndb.user = prod\ndb.pool = 20\n")
+	writeConf(frameworkDir, "app.local.conf", "[prod]\ndb.pool = 5\n")
+	writeConf(appDir, "app.local.conf", "[prod]\ndb.user = local\n")
+
+	os.Setenv("TEST_DB_PASSWORD", "s3cret")
+	defer os.Unsetenv("TEST_DB_PASSWORD")
+
+	conf, err := loadConfig([]string{frameworkDir, appDir}, "prod")
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf.SetSection("prod")
+	for option, expected := range map[string]string{
+		"app.name":    "base",
+		"db.host":     "db.internal",
+		"db.user":     "local",
+		"db.pool":     "5",
+		"db.password": "s3cret",
+		"db.port":     "5432",
+	} {
+		if actual := conf.StringDefault(option, ""); actual != expected {
+			t.Errorf("%s: expected %q, got %q", option, expected, actual)
+		}
+	}
+	if conf.BoolDefault("mode.dev", false) {
+		t.Error("expected the dev section not to apply")
+	}
+
+	// The overlays for another mode are not loaded, and missing files are
+	// ignored.
+	conf, err = loadConfig([]string{frameworkDir, appDir}, "dev")
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf.SetSection("dev")
+	if user := conf.StringDefault("db.user", ""); user != "app" {
+		t.Errorf("expected db.user app, got %q", user)
+	}
+
+	if _, err = loadConfig([]string{frameworkDir}, "prod"); err == nil {
+		t.Error("expected an error without app.conf")
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	conf := "app.name = Hotels\nserver.port = 8080\nserver.debug = yes\nserver.timeout = 5s\n" +
+		"server.tags = a, b,c\ncache.size = 100\ncache.ratio = 0.5\n"
+	if err := ioutil.WriteFile(filepath.Join(dir, "app.conf"), []byte(conf), 0644); err != nil {
+		t.Fatal(err)
+	}
+	defer func(c *config.Context) { Config = c }(Config)
+	var err error
+	if Config, err = loadConfig([]string{dir}, "DEFAULT"); err != nil {
+		t.Fatal(err)
+	}
+
+	type cacheConfig struct {
+		Size  uint    `conf:"size"`
+		Ratio float64 `conf:"ratio"`
+	}
+	var appConfig struct {
+		Name     string        `conf:"app.name,required"`
+		Port     int           `conf:"server.port" default:"9000"`
+		Addr     string        `conf:"server.addr" default:"localhost"`
+		Debug    bool          `conf:"server.debug"`
+		Timeout  time.Duration `conf:"server.timeout"`
+		Tags     []string      `conf:"server.tags"`
+		Cache    cacheConfig   `conf:"cache"`
+		Workers  int           `conf:"server.workers"`
+		Untagged string
+	}
+	appConfig.Workers = 4
+	if err = LoadConfig(&appConfig); err != nil {
+		t.Fatal(err)
+	}
+	if appConfig.Name != "Hotels" || appConfig.Port != 8080 || appConfig.Addr != "localhost" ||
+		!appConfig.Debug || appConfig.Timeout != 5*time.Second ||
+		!reflect.DeepEqual(appConfig.Tags, []string{"a", "b", "c"}) ||
+		appConfig.Cache != (cacheConfig{100, 0.5}) || appConfig.Workers != 4 {
+		t.Errorf("unexpected config: %+v", appConfig)
+	}
+
+	var invalid struct {
+		Secret  string        `conf:"app.secret,required"`
+		Port    uint8         `conf:"server.port"`
+		Debug   int           `conf:"server.debug"`
+		Timeout time.Duration `conf:"server.idle" default:"forever"`
+	}
+	err = LoadConfig(&invalid)
+	expected := ConfigError{
+		"app.secret is required",
+		`server.port: invalid value "8080": value out of range`,
+		`server.debug: invalid value "yes": invalid syntax`,
+		`server.idle: invalid value "forever": time: invalid duration "forever"`,
+	}
+	if !reflect.DeepEqual(err, expected) {
+		t.Errorf("expected %#v, got %#v", expected, err)
+	}
+
+	if err = LoadConfig(appConfig); err == nil {
+		t.Error("expected an error for a non-pointer")
+	}
+}
@@ -0,0 +1,47 @@
+package revel
+
+import (
+	"net"
+	"net/http"
+	"sync/atomic"
+)
+
+// connLimiter caps the number of simultaneous connections to the server.
+// The listener is owned by gracehttp, so the cap is enforced from the
+// server's ConnState hook: a connection accepted past the cap is closed
+// straight away, before a request is read from it, so its goroutine exits
+// instead of waiting on the client.
+type connLimiter struct {
+	max    int64
+	active int64 // accessed atomically
+	full   int32 // accessed atomically, 1 while connections are refused
+}
+
+func newConnLimiter(max int) *connLimiter {
+	return &connLimiter{max: int64(max)}
+}
+
+// connState counts the open connections and closes the new ones past the cap.
+func (l *connLimiter) connState(conn net.Conn, state http.ConnState) {
+	switch state {
+	case http.StateNew:
+		if atomic.AddInt64(&l.active, 1) <= l.max {
+			if atomic.CompareAndSwapInt32(&l.full, 1, 0) {
+				INFO.Println("Accepting connections again, below server.maxconns")
+			}
+			return
+		}
+		if atomic.CompareAndSwapInt32(&l.full, 0, 1) {
+			WARN.Printf("Refusing connections: server.maxconns (%d) reached", l.max)
+		}
+		// The server reports the connection closed, which releases it.
+		conn.Close()
+	case http.StateHijacked, http.StateClosed:
+		atomic.AddInt64(&l.active, -1)
+	}
+}
+
+// open returns the number of open connections.
+func (l *connLimiter) open() int {
+	return int(atomic.LoadInt64(&l.active))
+}
@@ -0,0 +1,59 @@
+package revel
+
+import (
+	"net/http"
+	"strings"
+)
+
+// ContentTypeFilter rejects requests with 415 Unsupported Media Type when
+// they are sent with an unsafe method (i.e. not GET, HEAD or OPTIONS) and a
+// body whose content type is not listed in "contenttypes.allowed" (comma
+// separated).  It runs before the body is parsed, so e.g. a JSON API may
+// refuse form posts outright, which also keeps forms on other sites from
+// posting to it:
+//
+//	contenttypes.allowed = application/json
+//
+// The list may be overridden per controller or action, with
+// "contenttypes.allowed.<Controller>" or "contenttypes.allowed.<Controller.Action>"
+// (the most specific wins), e.g. "contenttypes.allowed.Uploads =
+// multipart/form-data".  An empty list allows any content type.
+//
+// It must run after the RouterFilter.
+func ContentTypeFilter(c *Controller, fc []Filter) {
+	allowed := CurrentConfig().StringDefault("contenttypes.allowed", "")
+	if c.Name != "" {
+		allowed = CurrentConfig().StringDefault("contenttypes.allowed."+c.Name, allowed)
+		allowed = CurrentConfig().StringDefault("contenttypes.allowed."+c.Action, allowed)
+	}
+	if strings.TrimSpace(allowed) == "" || !hasUnsafeBody(c.Request) ||
+		contentTypeAllowed(c.Request.ContentType, allowed) {
+		fc[0](c, fc[1:])
+		return
+	}
+
+	c.Response.Status = http.StatusUnsupportedMediaType
+	c.Result = c.RenderError(&Error{
+		Title:       "Unsupported Media Type",
+		Description: "The content type " + c.Request.ContentType + " is not accepted (expected " + allowed + ")",
+	})
+}
+
+// hasUnsafeBody returns true if the request is sent with an unsafe method and
+// has a body, or at least declares a content type.
+func hasUnsafeBody(req *Request) bool {
+	switch req.Method {
+	case "GET", "HEAD", "OPTIONS":
+		return false
+	}
+	return req.ContentLength != 0 || req.Header.Get("Content-Type") != ""
+}
+
+func contentTypeAllowed(contentType, allowed string) bool {
+	for _, accepted := range strings.Split(allowed, ",") {
+		if strings.EqualFold(strings.TrimSpace(accepted), contentType) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+package revel
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestContentTypeFilter(t *testing.T) {
+	startFakeBookingApp()
+
+	run := func(method, action, contentType string, body io.Reader) (*httptest.ResponseRecorder, bool) {
+		req, _ := http.NewRequest(method, "/hotels", body)
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		resp := httptest.NewRecorder()
+		c := NewController(NewRequest(req), NewResponse(resp))
+		c.Name, c.Action = "Hotels", action
+		called := false
+		ContentTypeFilter(c, []Filter{func(c *Controller, fc []Filter) { called = true }})
+		if c.Result != nil {
+			c.Result.Apply(c.Request, c.Response)
+		}
+		return resp, called
+	}
+	form := func() io.Reader { return strings.NewReader("name=rob") }
+
+	if _, called := run("POST", "Hotels.Book", "application/x-www-form-urlencoded", form()); !called {
+		t.Error("Expected any content type to be allowed by default")
+	}
+
+	Config.SetOption("contenttypes.allowed", "application/json, application/merge-patch+json")
+	defer Config.SetOption("contenttypes.allowed", "")
+
+	if _, called := run("POST", "Hotels.Book", "application/json; charset=utf-8", strings.NewReader("{}")); !called {
+		t.Error("Expected a JSON body to be allowed")
+	}
+	if _, called := run("PATCH", "Hotels.Book", "Application/Merge-Patch+JSON", strings.NewReader("{}")); !called {
+		t.Error("Expected a merge patch body to be allowed")
+	}
+	for _, contentType := range []string{"application/x-www-form-urlencoded", "text/html", ""} {
+		if resp, called := run("POST", "Hotels.Book", contentType, form()); called || resp.Code != http.StatusUnsupportedMediaType {
+			t.Errorf("Expected %q to be rejected with 415, got %d (called: %v)", contentType, resp.Code, called)
+		}
+	}
+
+	// Safe methods and requests without a body are let through.
+	if _, called := run("GET", "Hotels.Index", "text/html", nil); !called {
+		t.Error("Expected a GET to be allowed")
+	}
+	if _, called := run("DELETE", "Hotels.Book", "", nil); !called {
+		t.Error("Expected a DELETE without a body to be allowed")
+	}
+
+	// Per controller and action.
+	Config.SetOption("contenttypes.allowed.Hotels", "")
+	Config.SetOption("contenttypes.allowed.Hotels.Book", "multipart/form-data")
+	defer Config.SetOption("contenttypes.allowed.Hotels.Book", "")
+	if _, called := run("POST", "Hotels.Index", "text/plain", form()); !called {
+		t.Error("Expected the controller override to apply")
+	}
+	if resp, called := run("POST", "Hotels.Book", "application/json", strings.NewReader("{}")); called || resp.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("Expected the action override to apply, got %d", resp.Code)
+	}
+}
@@ -1,6 +1,7 @@
 package revel
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -20,6 +21,7 @@ type Controller struct {
 	MethodType    *MethodType     // A description of the invoked action type.
 	AppController interface{}     // The controller that was instantiated.
 	Action        string          // The fully qualified action name, e.g. "App.Index"
+	Route         *RouteMatch     // The matched route, set by the RouterFilter.
 
 	Request  *Request
 	Response *Response
@@ -31,6 +33,10 @@ type Controller struct {
 	Args       map[string]interface{} // Per-request scratch space.
 	RenderArgs map[string]interface{} // Args passed to the template.
 	Validation *Validation            // Data validation helpers
+
+	aborted bool                        // Set by Abort; the remaining filters are skipped.
+	filters []Filter                    // The filter chain being run, see runChain.
+	jobs    []func(ctx context.Context) // Started by Go once the response is sent.
 }
 
 func NewController(req *Request, resp *Response) *Controller {
@@ -54,10 +60,70 @@ func (c *Controller) FlashParams() {
 	}
 }
 
+// ValidationFailed keeps the validation errors and flashes the params for the
+// next request, and redirects to the given URL, e.g. back to the form that was
+// posted (the POST-redirect-GET pattern):
+//
+//	c.Validation.Required(user.Name).Key("user.Name")
+//	if c.Validation.HasErrors() {
+//		return c.ValidationFailed("/users/new")
+//	}
+//
+// The form may then show the errors and submitted values with the "field"
+// template function, or the error of a field with "fieldError", e.g.
+// {{fieldError "user.Name" .}}.  The redirect is a 303 See Other, unless
+// another status was set, so that the form is fetched again with GET.
+func (c *Controller) ValidationFailed(redirectURL string) Result {
+	if c.Validation != nil {
+		c.Validation.Keep()
+	}
+	c.FlashParams()
+	c.setStatusIfNil(http.StatusSeeOther)
+	return c.Redirect(redirectURL)
+}
+
 func (c *Controller) SetCookie(cookie *http.Cookie) {
 	http.SetCookie(c.Response.Out, cookie)
 }
 
+// ClientIP returns the IP address of the client that made the request,
+// taking the configured trusted proxies into account.  See ClientIP.
+func (c *Controller) ClientIP() string {
+	return ClientIP(c.Request.Request)
+}
+
+// Abort stops the filter chain and renders the given result.  None of the
+// remaining filters, nor the action, are run once Abort has been called:
+// calls to the next filter become no-ops.  The filters that have already run
+// continue normally as the chain unwinds, so their post-processing (e.g.
+// writing the session cookie) and deferred cleanup still happen.
+//
+// The calling filter should return after calling Abort.  For example:
+//
+//	if !loggedIn(c) {
+//	  c.Abort(c.Forbidden("Login required"))
+//	  return
+//	}
+func (c *Controller) Abort(result Result) {
+	c.Result = result
+	c.aborted = true
+}
+
+// Aborted returns true if Abort has been called for this request.
+func (c *Controller) Aborted() bool {
+	return c.aborted
+}
+
+// BindErrors returns the params that could not be bound to the action
+// arguments (e.g. "page=two" for an int), and so were passed as zero values,
+// or by the action itself with c.Params.Bind.  See Params.BindErrors.
+func (c *Controller) BindErrors() []*BindError {
+	if c.Params == nil {
+		return nil
+	}
+	return c.Params.BindErrors()
+}
+
 func (c *Controller) RenderError(err error) Result {
 	c.setStatusIfNil(http.StatusInternalServerError)
 
@@ -127,18 +193,98 @@ func (c *Controller) RenderTemplate(templatePath string) Result {
 	}
 }
 
+// RenderTemplatePart renders just the named {{define}} or {{block}} of the
+// given template, using the current RenderArgs, e.g. to return a fragment of
+// a page to an HTMX or other AJAX request.
+func (c *Controller) RenderTemplatePart(templatePath, block string) Result {
+	template, err := MainTemplateLoader.Template(templatePath)
+	if err != nil {
+		return c.RenderError(err)
+	}
+	gotmpl, ok := template.(GoTemplate)
+	if !ok {
+		return c.RenderError(fmt.Errorf("Template %s does not support rendering blocks.", templatePath))
+	}
+	part, err := gotmpl.Part(block)
+	if err != nil {
+		return c.RenderError(err)
+	}
+
+	c.setStatusIfNil(http.StatusOK)
+	return &RenderTemplateResult{
+		Template:   part,
+		RenderArgs: c.RenderArgs,
+	}
+}
+
+// RenderTemplateOrPart renders the named block of the given template for
+// requests made by HTMX (see Request.IsHtmx), and the whole template for
+// others, so that an action serves both the page, on navigation, and the
+// fragment that HTMX swaps into it:
+//
+//	func (c Hotels) List() revel.Result {
+//		...
+//		return c.RenderTemplateOrPart("Hotels/List.html", "results")
+//	}
+//
+// Responses vary on the HX-Request header, for caches.
+func (c *Controller) RenderTemplateOrPart(templatePath, block string) Result {
+	c.Response.Out.Header().Add("Vary", "HX-Request")
+	if c.Request.IsHtmx() {
+		return c.RenderTemplatePart(templatePath, block)
+	}
+	return c.RenderTemplate(templatePath)
+}
+
 // Uses encoding/json.Marshal to return JSON to the client.
+// The result is a RenderJsonResult, whose encoding may be adjusted with
+// c.RenderJson(o).(revel.RenderJsonResult).WithOptions(...).
 func (c *Controller) RenderJson(o interface{}) Result {
 	c.setStatusIfNil(http.StatusOK)
 
-	return RenderJsonResult{o, ""}
+	return RenderJsonResult{obj: o}
 }
 
 // Renders a JSONP result using encoding/json.Marshal
 func (c *Controller) RenderJsonP(callback string, o interface{}) Result {
 	c.setStatusIfNil(http.StatusOK)
 
-	return RenderJsonResult{o, callback}
+	return RenderJsonResult{obj: o, callback: callback}
+}
+
+// Streams the values received from ch to the client as a JSON array, which
+// is closed once ch is closed.  If the client disconnects the remaining
+// values are discarded; producers may also watch c.Request.Context() to
+// stop early.
+func (c *Controller) RenderJsonStream(ch <-chan interface{}) Result {
+	c.setStatusIfNil(http.StatusOK)
+
+	return RenderJsonStreamResult{ch}
+}
+
+// RenderCSV renders a slice of structs as CSV, with a header row of the
+// column names (see RenderCSVResult).  Use WithOptions for a different
+// delimiter or to send it as a download, e.g.
+//
+//	return c.RenderCSV(bookings).WithOptions(revel.CSVOptions{Filename: "bookings.csv"})
+func (c *Controller) RenderCSV(rows interface{}) RenderCSVResult {
+	c.setStatusIfNil(http.StatusOK)
+
+	return RenderCSVResult{rows: rows}
+}
+
+// RenderProxy forwards a response received from an upstream server, e.g. by
+// a gateway action (see RenderProxyResult):
+//
+//	upstream, err := http.Get(catalogURL + "/hotels/" + id)
+//	if err != nil {
+//	  return c.RenderError(err)
+//	}
+//	return c.RenderProxy(upstream, revel.ProxyOptions{
+//	  Headers: []string{"Content-Type", "Content-Length", "Cache-Control"},
+//	})
+func (c *Controller) RenderProxy(resp *http.Response, opts ProxyOptions) Result {
+	return RenderProxyResult{upstream: resp, options: opts}
 }
 
 // Uses encoding/xml.Marshal to return XML to the client.
@@ -206,9 +352,29 @@ func (c *Controller) Forbidden(msg string, objs ...interface{}) Result {
 
 // RenderFile returns a file, either displayed inline or downloaded
 // as an attachment. The name and size are taken from the file info.
+// Range and conditional requests are answered from the file's size and
+// modification time, and the file is sent with sendfile where the OS and
+// response writer allow it.
+//
+// If "results.precompressed" is set, a pre-compressed variant of the file
+// (e.g. "app.css.br" or "app.css.gz" for "app.css", not older than it) is sent
+// instead to clients that accept its encoding.
 func (c *Controller) RenderFile(file *os.File, delivery ContentDisposition) Result {
 	c.setStatusIfNil(http.StatusOK)
 
+	name := filepath.Base(file.Name())
+	if CurrentConfig().BoolDefault("results.precompressed", false) {
+		compressed, encoding, found := openPrecompressed(c.Request, file.Name())
+		if found {
+			c.Response.Out.Header().Add("Vary", "Accept-Encoding")
+		}
+		if compressed != nil {
+			file.Close()
+			file = compressed
+			c.Response.Out.Header().Set("Content-Encoding", encoding)
+		}
+	}
+
 	var (
 		modtime       = time.Now()
 		fileInfo, err = file.Stat()
@@ -219,7 +385,23 @@ func (c *Controller) RenderFile(file *os.File, delivery ContentDisposition) Resu
 	if fileInfo != nil {
 		modtime = fileInfo.ModTime()
 	}
-	return c.RenderBinary(file, filepath.Base(file.Name()), delivery, modtime)
+	return c.RenderBinary(file, name, delivery, modtime)
+}
+
+// RenderFileName returns the file at the given path like RenderFile, or 404
+// Not Found if there is no such file.
+func (c *Controller) RenderFileName(filename string, delivery ContentDisposition) Result {
+	file, err := os.Open(filename)
+	if os.IsNotExist(err) {
+		return c.NotFound("File not found: %s", filepath.Base(filename))
+	} else if err != nil {
+		return c.RenderError(err)
+	}
+	if info, err := file.Stat(); err == nil && info.IsDir() {
+		file.Close()
+		return c.NotFound("File not found: %s", filepath.Base(filename))
+	}
+	return c.RenderFile(file, delivery)
 }
 
 // RenderBinary is like RenderFile() except that it instead of a file on disk,
@@ -239,6 +421,25 @@ func (c *Controller) RenderBinary(memfile io.Reader, filename string, delivery C
 	}
 }
 
+// RenderDownload returns the content read from r as a file download (an
+// attachment, unless changed with WithDisposition) of the given name and
+// content type.  The content type is
+// guessed from the filename if empty.  As the content is seekable, range
+// requests are supported and the Content-Length is set, so that reports
+// generated in memory need not be written to a temporary file first.
+func (c *Controller) RenderDownload(r io.ReadSeeker, filename string, contentType string) Result {
+	c.setStatusIfNil(http.StatusOK)
+
+	return &BinaryResult{
+		Reader:      r,
+		Name:        filename,
+		Delivery:    Attachment,
+		Length:      -1,
+		ModTime:     time.Now(),
+		ContentType: contentType,
+	}
+}
+
 // Redirect to an action or to a URL.
 //   c.Redirect(Controller.Action)
 //   c.Redirect("/controller/action")
@@ -0,0 +1,164 @@
+package revel
+
+import (
+	"encoding"
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+)
+
+var textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+
+// CSVOptions control how RenderCSV writes its result.
+type CSVOptions struct {
+	// Comma is the field delimiter, ',' if not set (e.g. ';' or '\t').
+	Comma rune
+
+	// Filename, if set, sends the CSV as a download of that name.
+	Filename string
+}
+
+// RenderCSVResult writes a slice of structs as CSV (RFC 4180): a header row
+// of the column names, then a row per element.  Rows are written to the
+// response as they are formatted, rather than buffering the whole document.
+//
+// Columns are the exported fields of the struct, named by their "csv" tag or
+// else their field name.  A field tagged `csv:"-"` is left out, and the
+// fields of untagged (exported) embedded structs are inlined.  Values are formatted
+// with their MarshalText method if they have one, times as RFC 3339, and
+// everything else with fmt.
+type RenderCSVResult struct {
+	rows    interface{}
+	options CSVOptions
+}
+
+// WithOptions returns the result with the given options.
+func (r RenderCSVResult) WithOptions(opts CSVOptions) RenderCSVResult {
+	r.options = opts
+	return r
+}
+
+func (r RenderCSVResult) Apply(req *Request, resp *Response) {
+	rows := reflect.ValueOf(r.rows)
+	if rows.Kind() != reflect.Slice && rows.Kind() != reflect.Array {
+		renderCSVError(req, resp, fmt.Errorf("RenderCSV requires a slice of structs, got %T", r.rows))
+		return
+	}
+	elemType := rows.Type().Elem()
+	if elemType.Kind() == reflect.Ptr {
+		elemType = elemType.Elem()
+	}
+	if elemType.Kind() != reflect.Struct {
+		renderCSVError(req, resp, fmt.Errorf("RenderCSV requires a slice of structs, got %T", r.rows))
+		return
+	}
+	columns := csvColumns(elemType, nil)
+
+	if r.options.Filename != "" {
+		resp.Out.Header().Set("Content-Disposition", contentDisposition(Attachment, r.options.Filename))
+	}
+	resp.WriteHeader(http.StatusOK, textContentType("text/csv"))
+
+	w := csv.NewWriter(resp.Out)
+	w.UseCRLF = true
+	if r.options.Comma != 0 {
+		w.Comma = r.options.Comma
+	}
+
+	record := make([]string, len(columns))
+	for i, column := range columns {
+		record[i] = column.name
+	}
+	if err := w.Write(record); err != nil {
+		return
+	}
+	for i := 0; i < rows.Len(); i++ {
+		row := rows.Index(i)
+		for j, column := range columns {
+			record[j] = csvValue(row, column.index)
+		}
+		if err := w.Write(record); err != nil {
+			ERROR.Println("Failed to write CSV row:", err)
+			return
+		}
+	}
+	w.Flush()
+}
+
+func renderCSVError(req *Request, resp *Response, err error) {
+	ErrorResult{Error: &Error{
+		Title:       "CSV Rendering Error",
+		Description: err.Error(),
+	}}.Apply(req, resp)
+}
+
+type csvColumn struct {
+	name  string
+	index []int // The field index, as for reflect.Value.FieldByIndex.
+}
+
+// csvColumns returns the columns of the struct type typ.
+func csvColumns(typ reflect.Type, index []int) []csvColumn {
+	var columns []csvColumn
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		name := strings.Split(field.Tag.Get("csv"), ",")[0]
+		if name == "-" || field.PkgPath != "" {
+			continue
+		}
+		fieldIndex := append(append([]int(nil), index...), i)
+
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		if field.Anonymous && name == "" && fieldType.Kind() == reflect.Struct && !reflect.PtrTo(fieldType).Implements(textMarshalerType) {
+			columns = append(columns, csvColumns(fieldType, fieldIndex)...)
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		columns = append(columns, csvColumn{name, fieldIndex})
+	}
+	return columns
+}
+
+// csvValue formats the field at the given index of the struct v (or pointer
+// to it).  Nil pointers are written as empty values.
+func csvValue(v reflect.Value, index []int) string {
+	for _, i := range index {
+		for v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return ""
+			}
+			v = v.Elem()
+		}
+		v = v.Field(i)
+	}
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+
+	if t, ok := v.Interface().(time.Time); ok {
+		return t.Format(time.RFC3339)
+	}
+	marshaler, ok := v.Interface().(encoding.TextMarshaler)
+	if !ok && v.CanAddr() {
+		marshaler, ok = v.Addr().Interface().(encoding.TextMarshaler)
+	}
+	if ok {
+		text, err := marshaler.MarshalText()
+		if err != nil {
+			WARN.Println("Failed to format CSV value:", err)
+		}
+		return string(text)
+	}
+	return fmt.Sprint(v.Interface())
+}
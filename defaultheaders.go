@@ -0,0 +1,169 @@
+package revel
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strings"
+	"text/template"
+)
+
+// defaultHeader is a response header added by the DefaultHeadersFilter.  Its
+// value is a template if it has any actions, and is used verbatim otherwise.
+type defaultHeader struct {
+	name     string
+	value    string
+	template *template.Template
+}
+
+// DefaultHeaderData is what a default header value template is executed with.
+type DefaultHeaderData struct {
+	RequestID string // The X-Request-Id request header.
+	Action    string // The action, e.g. "Hotels.Show", if routed.
+	Request   *Request
+}
+
+var (
+	// registeredHeaders are the default headers added with RegisterDefaultHeader.
+	registeredHeaders = make(map[string]*defaultHeader)
+
+	// defaultHeaders are the registered default headers and those configured
+	// as "headers.default.<Header>", which win.  They are keyed by canonical
+	// header name.
+	defaultHeaders map[string]*defaultHeader
+
+	// defaultHeaderOverrides are the "headers.default.<Header>.<Controller>"
+	// and "headers.default.<Header>.<Controller.Action>" overrides, keyed by
+	// canonical header name then lowercase controller or action.
+	defaultHeaderOverrides map[string]map[string]*defaultHeader
+)
+
+func init() {
+	OnAppStart(func() {
+		defaultHeaders, defaultHeaderOverrides = configDefaultHeaders()
+	})
+}
+
+// RegisterDefaultHeader adds a header to every response sent through the
+// DefaultHeadersFilter, e.g.
+//
+//	revel.RegisterDefaultHeader("X-Content-Type-Options", "nosniff")
+//	revel.RegisterDefaultHeader("X-Request-Id", "{{.RequestID}}")
+//
+// The value is a text/template executed with a DefaultHeaderData; it panics if
+// the template is invalid.  A "headers.default.<Header>" config option of the
+// same name takes precedence.  It should be called from an init function.
+func RegisterDefaultHeader(name, value string) {
+	name = http.CanonicalHeaderKey(name)
+	registeredHeaders[name] = newDefaultHeader(name, value)
+}
+
+func newDefaultHeader(name, value string) *defaultHeader {
+	header := &defaultHeader{name: name, value: value}
+	if strings.Contains(value, "{{") {
+		tmpl, err := template.New(name).Parse(value)
+		if err != nil {
+			panic(fmt.Errorf("default header %s invalid: %s", name, err))
+		}
+		header.template = tmpl
+	}
+	return header
+}
+
+func configDefaultHeaders() (map[string]*defaultHeader, map[string]map[string]*defaultHeader) {
+	const prefix = "headers.default."
+	headers := make(map[string]*defaultHeader)
+	for name, header := range registeredHeaders {
+		headers[name] = header
+	}
+	overrides := make(map[string]map[string]*defaultHeader)
+	for _, option := range CurrentConfig().Options(prefix) {
+		value := CurrentConfig().StringDefault(option, "")
+		name, target := option[len(prefix):], ""
+		if i := strings.Index(name, "."); i >= 0 {
+			name, target = name[:i], name[i+1:]
+		}
+		name = http.CanonicalHeaderKey(name)
+		header := newDefaultHeader(name, value)
+		if target == "" {
+			headers[name] = header
+			continue
+		}
+		if overrides[name] == nil {
+			overrides[name] = make(map[string]*defaultHeader)
+		}
+		overrides[name][strings.ToLower(target)] = header
+	}
+	return headers, overrides
+}
+
+// DefaultHeadersFilter adds the default headers to the response, before the
+// action runs, so that an action or result may still set them differently.
+// They are registered with RegisterDefaultHeader or configured in app.conf:
+//
+//	headers.default.X-Content-Type-Options = nosniff
+//	headers.default.X-Request-Id = {{.RequestID}}
+//
+// A header may be overridden per controller or action, with
+// "headers.default.<Header>.<Controller>" or
+// "headers.default.<Header>.<Controller.Action>" (the most specific wins),
+// e.g. "headers.default.X-Frame-Options.Embeds = ALLOWALL".  A header whose
+// value is empty, or expands to nothing, is not sent.
+//
+// It should run after the RouterFilter, for the overrides to apply.
+func DefaultHeadersFilter(c *Controller, fc []Filter) {
+	header := c.Response.Out.Header()
+	for name, h := range defaultHeaders {
+		if value := c.defaultHeader(name, h).render(c); value != "" {
+			header.Set(name, value)
+		}
+	}
+	for name := range defaultHeaderOverrides {
+		if _, ok := defaultHeaders[name]; ok {
+			continue
+		}
+		if value := c.defaultHeader(name, nil).render(c); value != "" {
+			header.Set(name, value)
+		}
+	}
+
+	fc[0](c, fc[1:])
+}
+
+// defaultHeader returns the named default header as overridden for the
+// controller's action, if it is, or else the given one.
+func (c *Controller) defaultHeader(name string, header *defaultHeader) *defaultHeader {
+	if c.Name == "" {
+		return header
+	}
+	overrides := defaultHeaderOverrides[name]
+	if override, ok := overrides[strings.ToLower(c.Action)]; ok {
+		return override
+	}
+	if override, ok := overrides[strings.ToLower(c.Name)]; ok {
+		return override
+	}
+	return header
+}
+
+// render returns the value of the header for the request, or "" if the
+// header is not sent.
+func (h *defaultHeader) render(c *Controller) string {
+	if h == nil {
+		return ""
+	}
+	if h.template == nil {
+		return h.value
+	}
+	var buf bytes.Buffer
+	err := h.template.Execute(&buf, DefaultHeaderData{
+		RequestID: c.Request.Header.Get("X-Request-Id"),
+		Action:    c.Action,
+		Request:   c.Request,
+	})
+	if err != nil {
+		ERROR.Printf("Failed to render the default header %s: %s", h.name, err)
+		return ""
+	}
+	return strings.TrimSpace(buf.String())
+}
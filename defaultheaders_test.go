@@ -0,0 +1,58 @@
+package revel
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDefaultHeadersFilter(t *testing.T) {
+	startFakeBookingApp()
+	defer func() {
+		registeredHeaders = make(map[string]*defaultHeader)
+		defaultHeaders, defaultHeaderOverrides = nil, nil
+	}()
+
+	run := func(action string) http.Header {
+		req, _ := http.NewRequest("GET", "/hotels", nil)
+		req.Header.Set("X-Request-Id", "req-1")
+		resp := httptest.NewRecorder()
+		c := NewController(NewRequest(req), NewResponse(resp))
+		c.Name, c.Action = "Hotels", action
+		called := false
+		DefaultHeadersFilter(c, []Filter{func(c *Controller, fc []Filter) { called = true }})
+		if !called {
+			t.Error("Expected the filter chain to continue")
+		}
+		return resp.Header()
+	}
+
+	RegisterDefaultHeader("x-content-type-options", "nosniff")
+	RegisterDefaultHeader("X-Frame-Options", "DENY")
+	Config.SetOption("headers.default.X-Frame-Options", "SAMEORIGIN")
+	Config.SetOption("headers.default.X-Request-Id", "{{.RequestID}}/{{.Action}}")
+	Config.SetOption("headers.default.X-Frame-Options.Hotels", "ALLOWALL")
+	Config.SetOption("headers.default.X-Frame-Options.Hotels.Book", "")
+	Config.SetOption("headers.default.X-Robots-Tag.Hotels.Show", "noindex")
+	defer func() {
+		for _, option := range []string{"X-Frame-Options", "X-Request-Id", "X-Frame-Options.Hotels",
+			"X-Frame-Options.Hotels.Book", "X-Robots-Tag.Hotels.Show"} {
+			Config.SetOption("headers.default."+option, "")
+		}
+	}()
+	defaultHeaders, defaultHeaderOverrides = configDefaultHeaders()
+
+	header := run("Hotels.Index")
+	eq(t, "registered header", header.Get("X-Content-Type-Options"), "nosniff")
+	eq(t, "controller override", header.Get("X-Frame-Options"), "ALLOWALL")
+	eq(t, "templated header", header.Get("X-Request-Id"), "req-1/Hotels.Index")
+	eq(t, "unconfigured override", header.Get("X-Robots-Tag"), "")
+
+	header = run("Hotels.Book")
+	if _, ok := header["X-Frame-Options"]; ok {
+		t.Errorf("Expected the empty action override to leave the header out, got %q", header.Get("X-Frame-Options"))
+	}
+
+	header = run("Hotels.Show")
+	eq(t, "action only header", header.Get("X-Robots-Tag"), "noindex")
+}
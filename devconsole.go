@@ -0,0 +1,37 @@
+package revel
+
+// DevRoutesPath is where the DevRoutesFilter shows the routes.
+const DevRoutesPath = "/@revel/routes"
+
+// devRoute describes a route and the filters that apply to it, as shown on
+// the dev console.
+type devRoute struct {
+	*Route
+	Filters []string // nil when the route uses the global filters.
+}
+
+// DevRoutesFilter shows the route table, with the filters applied to each
+// route, and the global filter order at DevRoutesPath.  It is added to the
+// filter chain in dev mode only.
+func DevRoutesFilter(c *Controller, fc []Filter) {
+	if !DevMode || c.Request.URL.Path != DevRoutesPath {
+		fc[0](c, fc[1:])
+		return
+	}
+
+	var routes []devRoute
+	for _, route := range MainRouter.Routes {
+		routes = append(routes, devRoute{route, filterNames(getOverrideChain(route.ControllerName, route.Action))})
+	}
+	c.RenderArgs["routes"] = routes
+	c.RenderArgs["filters"] = filterNames(Filters)
+	c.Result = c.RenderTemplate("revel/routes.html")
+}
+
+func filterNames(fc []Filter) []string {
+	var names []string
+	for _, f := range fc {
+		names = append(names, filterName(f))
+	}
+	return names
+}
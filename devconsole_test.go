@@ -0,0 +1,40 @@
+package revel
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDevRoutesFilter(t *testing.T) {
+	startFakeBookingApp()
+	defer func() { filterOverrides = make(map[string][]Filter) }()
+	FilterAction(Hotels.Show).Add(csrfFilter)
+
+	req, _ := http.NewRequest("GET", DevRoutesPath, nil)
+	render := func() (*httptest.ResponseRecorder, *Controller) {
+		resp := httptest.NewRecorder()
+		c := NewController(NewRequest(req), NewResponse(resp))
+		DevRoutesFilter(c, NilChain)
+		if c.Result != nil {
+			c.Result.Apply(c.Request, c.Response)
+		}
+		return resp, c
+	}
+
+	// The console is not available outside of dev mode.
+	if _, c := render(); c.Result != nil {
+		t.Errorf("Expected the routes to be hidden in prod mode, got %#v", c.Result)
+	}
+
+	DevMode = true
+	defer func() { DevMode = false }()
+	resp, _ := render()
+	body := resp.Body.String()
+	for _, expected := range []string{"/hotels/:id", "Hotels.Show", "csrfFilter", "<code>RouterFilter</code>"} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("Expected the routes page to contain %q:\n%s", expected, body)
+		}
+	}
+}
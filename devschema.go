@@ -0,0 +1,102 @@
+package revel
+
+import (
+	"strings"
+)
+
+// DevSchemaPath is where the DevSchemaFilter serves the route schema.
+const DevSchemaPath = "/@revel/schema"
+
+// RouteSchema describes the parameters accepted by a route's action, as
+// served at DevSchemaPath.
+type RouteSchema struct {
+	Method string        `json:"method"`
+	Path   string        `json:"path"`
+	Action string        `json:"action"`
+	Params []ParamSchema `json:"params"`
+}
+
+// ParamSchema describes a single action argument.
+type ParamSchema struct {
+	Name string `json:"name"`
+	Type string `json:"type"` // e.g. "int", "[]string", "models.Booking"
+	In   string `json:"in"`   // "path", "query" or "body"
+}
+
+// RouteSchemas describes the routes of the router whose actions could be
+// resolved to a registered controller method.  Routes with a wildcard
+// controller or action are skipped, as are arguments bound from fixed
+// route params, since clients do not send them.
+func RouteSchemas(router *Router) []RouteSchema {
+	var schemas []RouteSchema
+	for _, route := range router.Routes {
+		if route.ControllerName == "" || route.MethodName == "" ||
+			route.ControllerName[0] == ':' || route.MethodName[0] == ':' {
+			continue
+		}
+		ct, ok := controllers[strings.ToLower(route.ControllerName)]
+		if !ok {
+			continue
+		}
+		method := ct.Method(route.MethodName)
+		if method == nil {
+			continue
+		}
+
+		pathParams := route.pathParamNames()
+		schema := RouteSchema{
+			Method: route.Method,
+			Path:   route.Path,
+			Action: ct.Type.Name() + "." + method.Name,
+			Params: []ParamSchema{},
+		}
+		for i, arg := range method.Args {
+			if i < len(route.FixedParams) || isInjectedArgType(arg.Type) {
+				continue
+			}
+			schema.Params = append(schema.Params, ParamSchema{
+				Name: arg.Name,
+				Type: arg.Type.String(),
+				In:   paramLocation(route.Method, arg.Name, pathParams),
+			})
+		}
+		schemas = append(schemas, schema)
+	}
+	return schemas
+}
+
+// pathParamNames returns the names of the ":name" and "*name" segments of
+// the route path.
+func (route *Route) pathParamNames() map[string]bool {
+	names := make(map[string]bool)
+	for _, el := range strings.Split(route.Path, "/") {
+		if el != "" && (el[0] == ':' || el[0] == '*') {
+			names[el[1:]] = true
+		}
+	}
+	return names
+}
+
+// paramLocation returns where a client sends the named argument: in the
+// path, in the body for methods that carry one, or in the query string.
+func paramLocation(method, name string, pathParams map[string]bool) string {
+	switch {
+	case pathParams[name]:
+		return "path"
+	case method == "POST" || method == "PUT" || method == "PATCH":
+		return "body"
+	}
+	return "query"
+}
+
+// DevSchemaFilter serves the RouteSchemas of the main router as JSON at
+// DevSchemaPath, for generating API docs and client stubs.  It is added to
+// the filter chain in dev mode only.
+func DevSchemaFilter(c *Controller, fc []Filter) {
+	if !DevMode || c.Request.URL.Path != DevSchemaPath {
+		fc[0](c, fc[1:])
+		return
+	}
+
+	c.Result = c.RenderJson(RouteSchemas(MainRouter))
+}
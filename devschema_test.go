@@ -0,0 +1,69 @@
+package revel
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestDevSchemaFilter(t *testing.T) {
+	startFakeBookingApp()
+
+	req, _ := http.NewRequest("GET", DevSchemaPath, nil)
+	render := func() (*httptest.ResponseRecorder, *Controller) {
+		resp := httptest.NewRecorder()
+		c := NewController(NewRequest(req), NewResponse(resp))
+		DevSchemaFilter(c, NilChain)
+		if c.Result != nil {
+			c.Result.Apply(c.Request, c.Response)
+		}
+		return resp, c
+	}
+
+	// The schema is not available outside of dev mode.
+	if _, c := render(); c.Result != nil {
+		t.Errorf("Expected the schema to be hidden in prod mode, got %#v", c.Result)
+	}
+
+	DevMode = true
+	defer func() { DevMode = false }()
+	resp, _ := render()
+	var schemas []RouteSchema
+	if err := json.Unmarshal(resp.Body.Bytes(), &schemas); err != nil {
+		t.Fatalf("Failed to decode the schema: %s\n%s", err, resp.Body.String())
+	}
+
+	expected := map[string][]ParamSchema{
+		"/hotels":             {},
+		"/hotels/:id":         {{"id", "int", "path"}},
+		"/hotels/:id/booking": {{"id", "int", "path"}},
+		"/public/*filepath":   {{"filepath", "string", "path"}},
+		"/favicon.ico":        {},
+	}
+	found := make(map[string][]ParamSchema)
+	for _, schema := range schemas {
+		found[schema.Path] = schema.Params
+	}
+	for path, params := range expected {
+		if !reflect.DeepEqual(found[path], params) {
+			t.Errorf("Expected %s to have params %v, got %v", path, params, found[path])
+		}
+	}
+}
+
+func TestParamLocation(t *testing.T) {
+	pathParams := map[string]bool{"id": true}
+	for _, test := range []struct{ method, name, in string }{
+		{"GET", "id", "path"},
+		{"GET", "q", "query"},
+		{"POST", "id", "path"},
+		{"POST", "booking", "body"},
+		{"DELETE", "force", "query"},
+	} {
+		if in := paramLocation(test.method, test.name, pathParams); in != test.in {
+			t.Errorf("%s %s: expected %q, got %q", test.method, test.name, test.in, in)
+		}
+	}
+}
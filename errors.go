@@ -19,6 +19,16 @@ type Error struct {
 	Link                     string   // A configurable link to wrap the error source in
 }
 
+// StackFrame is a call in the stack trace of a panic, as shown on the dev
+// error page.
+type StackFrame struct {
+	Function string // e.g. "github.com/revel/samples/booking/app/controllers.Hotels.Show(...)"
+	Location string // e.g. "/.../app/controllers/hotels.go:191 +0x44735"
+	IsApp    bool   // Whether the call is in application or module code.
+	IsFirst  bool   // Whether this is the first call in application code.
+	Omitted  int    // The number of framework frames trimmed in its place.
+}
+
 // An object to hold the per-source-line details.
 type sourceLine struct {
 	Source  string
@@ -90,11 +100,12 @@ func (e *Error) ContextSource() []sourceLine {
 	if e.SourceLines == nil {
 		return nil
 	}
-	start := (e.Line - 1) - 5
+	context := CurrentConfig().IntDefault("errors.source.lines", 5)
+	start := (e.Line - 1) - context
 	if start < 0 {
 		start = 0
 	}
-	end := (e.Line - 1) + 5
+	end := (e.Line - 1) + context
 	if end > len(e.SourceLines) {
 		end = len(e.SourceLines)
 	}
@@ -107,6 +118,84 @@ func (e *Error) ContextSource() []sourceLine {
 	return lines
 }
 
+// StackFrames method returns the calls of the stack trace, for display on the
+// dev error page.  The first call in application code is marked, and the
+// display is configured by:
+//
+//	errors.stack.trim  - replace runs of framework and runtime frames with a
+//	                     count of the frames omitted (default false)
+//	errors.stack.depth - the maximum number of frames shown, 0 for all
+//	                     (default 0)
+func (e *Error) StackFrames() []StackFrame {
+	var (
+		frames []StackFrame
+		fn     string
+		trim   = CurrentConfig().BoolDefault("errors.stack.trim", false)
+		depth  = CurrentConfig().IntDefault("errors.stack.depth", 0)
+		first  = true
+	)
+	for _, line := range strings.Split(e.Stack, "\n") {
+		if line = strings.TrimSpace(line); line == "" || strings.HasPrefix(line, "goroutine ") {
+			continue
+		}
+		if !isStackLocation(line) {
+			fn = line
+			continue
+		}
+
+		frame := StackFrame{Function: fn, Location: line, IsApp: isAppSource(line)}
+		fn = ""
+		if frame.IsApp && first {
+			frame.IsFirst, first = true, false
+		}
+		if trim && !frame.IsApp {
+			if n := len(frames); n > 0 && frames[n-1].Omitted > 0 {
+				frames[n-1].Omitted++
+			} else {
+				frames = append(frames, StackFrame{Omitted: 1})
+			}
+			continue
+		}
+		frames = append(frames, frame)
+	}
+	if depth > 0 && len(frames) > depth {
+		frames = frames[:depth]
+	}
+	return frames
+}
+
+// isStackLocation returns true if the stack trace line is the file and line of
+// a call, e.g. "/.../hotels.go:191 +0x44735".
+func isStackLocation(line string) bool {
+	file := strings.Fields(line)[0]
+	colon := strings.LastIndex(file, ":")
+	if colon == -1 || !strings.HasSuffix(file[:colon], ".go") {
+		return false
+	}
+	_, err := strconv.Atoi(file[colon+1:])
+	return err == nil
+}
+
+// isAppSource returns true if the stack trace location lies in the application
+// or one of its modules, but not in revel itself or the vendored packages of
+// the application.
+func isAppSource(location string) bool {
+	if (RevelPath != "" && strings.HasPrefix(location, filepath.ToSlash(RevelPath)+"/")) ||
+		strings.Contains(location, "/"+REVEL_IMPORT_PATH+"/") || strings.Contains(location, "/"+REVEL_IMPORT_PATH+"@") {
+		return false
+	}
+	for _, module := range Modules {
+		if strings.HasPrefix(location, filepath.ToSlash(module.Path)+"/") {
+			return true
+		}
+	}
+	if BasePath == "" {
+		return false
+	}
+	basePath := filepath.ToSlash(BasePath)
+	return strings.HasPrefix(location, basePath+"/") && !strings.HasPrefix(location, basePath+"/vendor/")
+}
+
 // SetLink method prepares a link and assign to Error.Link attribute
 func (e *Error) SetLink(errorLink string) {
 	errorLink = strings.Replace(errorLink, "{{Path}}", e.Path, -1)
@@ -0,0 +1,175 @@
+package revel
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// FieldNamer returns the param name bound to a struct field that has no name
+// in its "param" tag.
+type FieldNamer func(field reflect.StructField) string
+
+// FieldNamers are the field namers that may be selected in app.conf with
+// "binder.fieldnames" (default "exact").  Applications may register their own.
+var FieldNamers = map[string]FieldNamer{
+	"exact": ExactFieldName,
+	"snake": SnakeCaseFieldName,
+}
+
+// DefaultFieldNamer names the struct fields bound from params that do not set
+// their own FieldNamer.  It is set from "binder.fieldnames" on startup.
+var DefaultFieldNamer FieldNamer = ExactFieldName
+
+func init() {
+	OnAppStart(func() {
+		DefaultFieldNamer = configFieldNamer()
+	})
+}
+
+// ExactFieldName binds a field from the param of the same name, e.g. UserName
+// from "user.UserName".
+func ExactFieldName(field reflect.StructField) string {
+	return field.Name
+}
+
+// SnakeCaseFieldName binds a field from the param of its snake_case name, e.g.
+// UserName from "user.user_name" and UserID from "user.user_id".
+func SnakeCaseFieldName(field reflect.StructField) string {
+	return snakeCase(field.Name)
+}
+
+func snakeCase(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+// configFieldNamer returns the field namer selected by "binder.fieldnames".
+func configFieldNamer() FieldNamer {
+	name := CurrentConfig().StringDefault("binder.fieldnames", "exact")
+	if namer, ok := FieldNamers[name]; ok {
+		return namer
+	}
+	WARN.Println("Unknown binder.fieldnames:", name)
+	return ExactFieldName
+}
+
+// fieldNamer returns the field namer used to bind these params: the
+// FieldNamer set on them, or else the DefaultFieldNamer.
+func (p *Params) fieldNamer() FieldNamer {
+	if p.FieldNamer != nil {
+		return p.FieldNamer
+	}
+	return DefaultFieldNamer
+}
+
+// renameJSONFields returns the JSON document to be decoded into a value of
+// type typ with the members of its objects that are named by the namer
+// renamed to the names of their struct fields, so that encoding/json matches
+// them, e.g. "user_name" to "UserName" with the SnakeCaseFieldName.  Fields
+// with a name in their "json" tag keep it.  The document is returned as is
+// with the ExactFieldName, or if it does not match typ.
+func renameJSONFields(data []byte, typ reflect.Type, namer FieldNamer) []byte {
+	if reflect.ValueOf(namer).Pointer() == reflect.ValueOf(ExactFieldName).Pointer() {
+		return data
+	}
+	return renameJSONValue(data, typ, namer)
+}
+
+func renameJSONValue(data []byte, typ reflect.Type, namer FieldNamer) []byte {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if reflect.PtrTo(typ).Implements(jsonUnmarshalerType) {
+		return data
+	}
+
+	var renamed interface{}
+	switch typ.Kind() {
+	case reflect.Struct:
+		var object map[string]json.RawMessage
+		if json.Unmarshal(data, &object) != nil {
+			return data
+		}
+		fields := make(map[string]reflect.StructField)
+		namedJSONFields(typ, namer, fields)
+		members := make(map[string]json.RawMessage, len(object))
+		for key, value := range object {
+			if field, ok := fields[key]; ok {
+				members[field.Name] = renameJSONValue(value, field.Type, namer)
+			} else if _, ok := members[key]; !ok {
+				members[key] = value
+			}
+		}
+		renamed = members
+
+	case reflect.Map:
+		var object map[string]json.RawMessage
+		if json.Unmarshal(data, &object) != nil {
+			return data
+		}
+		for key, value := range object {
+			object[key] = renameJSONValue(value, typ.Elem(), namer)
+		}
+		renamed = object
+
+	case reflect.Slice, reflect.Array:
+		var array []json.RawMessage
+		if json.Unmarshal(data, &array) != nil {
+			return data
+		}
+		for i, value := range array {
+			array[i] = renameJSONValue(value, typ.Elem(), namer)
+		}
+		renamed = array
+
+	default:
+		return data
+	}
+
+	if encoded, err := json.Marshal(renamed); err == nil {
+		return encoded
+	}
+	return data
+}
+
+// namedJSONFields adds the fields of the struct type that encoding/json
+// decodes by their name to fields, by the names the namer gives them.  The
+// fields of untagged embedded structs are added too, as encoding/json
+// promotes them.
+func namedJSONFields(typ reflect.Type, namer FieldNamer, fields map[string]reflect.StructField) {
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" || (field.PkgPath != "" && !field.Anonymous) {
+			continue
+		}
+		if name := strings.Split(tag, ",")[0]; name != "" {
+			continue
+		}
+		if embedded := field.Type; field.Anonymous {
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				namedJSONFields(embedded, namer, fields)
+			}
+			continue
+		}
+		if _, ok := fields[namer(field)]; !ok {
+			fields[namer(field)] = field
+		}
+	}
+}
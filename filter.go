@@ -1,18 +1,44 @@
 package revel
 
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"strings"
+)
+
 type Filter func(c *Controller, filterChain []Filter)
 
 // Filters is the default set of global filters.
 // It may be set by the application on initialization.
 var Filters = []Filter{
+	VerboseLogFilter,        // Mark a sample of the requests for verbose logging.
+	SlowRequestFilter,       // Log the requests slower than log.slowrequest.threshold.
 	PanicFilter,             // Recover from panics and display an error page instead.
+	AppErrorFilter,          // Render AppErrors returned by the action.
+	HostFilter,              // Reject requests for hosts other than hosts.allowed.
+	SecureFilter,            // Enforce HTTPS and set security headers, if configured.
+	CleanPathFilter,         // Resolve "//", "." and ".." in the request path.
+	MaintenanceFilter,       // Reply 503 while in maintenance mode.
+	OverloadFilter,          // Reply 503 while past the overload.* limits.
+	AssetsFilter,            // Serve hashed asset URLs with long cache headers.
 	RouterFilter,            // Use the routing table to select the right Action.
+	TracingFilter,           // Record a span for the request, if a Tracer is set.
 	FilterConfiguringFilter, // A hook for adding or removing per-Action filters.
+	RequiredHeadersFilter,   // Reject requests without the headers.required headers.
+	ContentTypeFilter,       // Reject bodies not of the contenttypes.allowed types.
+	BodyLimitFilter,         // Cap the size of the request body.
+	ConcurrencyLimitFilter,  // Limit how many requests run an action at once.
+	ResponseBudgetFilter,    // Count the response bytes and enforce response.budget.
+	BodyLogFilter,           // Log the request and response bodies, if configured.
 	ParamsFilter,            // Parse parameters into Controller.Params.
+	SetCookieFilter,         // Drop duplicate Set-Cookie headers, warn if too large.
 	SessionFilter,           // Restore and write the session cookie.
 	FlashFilter,             // Restore and write the flash cookie.
 	ValidationFilter,        // Restore kept validation errors and save new ones from cookie.
 	I18nFilter,              // Resolve the requested language.
+	DefaultHeadersFilter,    // Add the headers.default headers to the response.
+	ResultHookFilter,        // Transform the result with the registered ResultHooks.
 	InterceptorFilter,       // Run interceptors around the action.
 	CompressFilter,          // Compress the result.
 	ActionInvoker,           // Invoke the action.
@@ -23,3 +49,192 @@ var (
 	NilFilter = func(_ *Controller, _ []Filter) {}
 	NilChain  = []Filter{NilFilter}
 )
+
+// runChain runs the filter chain fc for the controller.  Each filter is
+// called through runFilter, which skips it once the controller has been
+// aborted (see Controller.Abort).
+func runChain(c *Controller, fc []Filter) {
+	if len(fc) == 0 {
+		return
+	}
+	outer := c.filters
+	c.filters = fc
+	runners := make([]Filter, len(fc))
+	for i := range runners {
+		runners[i] = runFilter
+	}
+	runners[0](c, runners[1:])
+	c.filters = outer
+}
+
+// runFilter runs the filter of c.filters that is followed by the given rest
+// of the chain, unless the controller has been aborted.
+func runFilter(c *Controller, rest []Filter) {
+	if c.aborted {
+		return
+	}
+	c.filters[len(c.filters)-len(rest)-1](c, rest)
+}
+
+// NamedFilter is a filter registered with RegisterFilter.  Before and After
+// name the filters it must run before or after.  Filters already in the
+// chain are named by their function name (e.g. "SessionFilter"); registered
+// filters by their Name.  Unknown names are ignored, so a filter may order
+// itself relative to filters that are not installed.
+type NamedFilter struct {
+	Name   string
+	Filter Filter
+	Before []string
+	After  []string
+}
+
+var registeredFilters []NamedFilter
+
+// RegisterFilter adds a filter to the chain, positioned according to its
+// Before and After dependencies when the server starts.  For example:
+//
+//	revel.RegisterFilter(revel.NamedFilter{
+//	  Name:   "CSRFFilter",
+//	  Filter: CSRFFilter,
+//	  After:  []string{"SessionFilter"},
+//	  Before: []string{"InterceptorFilter"},
+//	})
+//
+// The filters in Filters keep their relative order.  A registered filter is
+// placed as late in the chain as its dependencies allow, but always before
+// the last filter (the ActionInvoker).
+func RegisterFilter(f NamedFilter) {
+	registeredFilters = append(registeredFilters, f)
+}
+
+// applyRegisteredFilters sorts the registered filters into Filters and into
+// the per-controller and per-action filter chains.
+func applyRegisteredFilters() {
+	if len(registeredFilters) == 0 {
+		return
+	}
+	filters, err := sortFilters(Filters, registeredFilters)
+	if err != nil {
+		ERROR.Fatalln("Failed to order filters:", err)
+	}
+	Filters = filters
+
+	// The overridden chains start after the FilterConfiguringFilter, so they
+	// only receive the filters that were placed after it.
+	var later []NamedFilter
+	configuring := -1
+	for i, f := range Filters {
+		if FilterEq(f, FilterConfiguringFilter) {
+			configuring = i
+		}
+		for _, named := range registeredFilters {
+			if configuring != -1 && i > configuring && FilterEq(f, named.Filter) {
+				later = append(later, named)
+			}
+		}
+	}
+	for key, chain := range filterOverrides {
+		if filterOverrides[key], err = sortFilters(chain, later); err != nil {
+			ERROR.Fatalln("Failed to order filters for "+key+":", err)
+		}
+	}
+}
+
+// sortFilters returns chain with the given filters inserted, topologically
+// sorted by their dependencies.  It returns an error if the dependencies
+// form a cycle.
+func sortFilters(chain []Filter, named []NamedFilter) ([]Filter, error) {
+	var (
+		filters = make([]Filter, 0, len(chain)+len(named))
+		names   = make([]string, 0, len(chain)+len(named))
+		deps    = make([]NamedFilter, 0, len(named))
+		index   = make(map[string]int)
+	)
+	add := func(name string, f Filter) {
+		if _, ok := index[name]; !ok {
+			index[name] = len(filters)
+		}
+		filters = append(filters, f)
+		names = append(names, name)
+	}
+	for _, f := range chain {
+		add(filterName(f), f)
+	}
+NAMED:
+	for _, nf := range named {
+		for _, f := range chain {
+			if FilterEq(f, nf.Filter) {
+				continue NAMED
+			}
+		}
+		add(nf.Name, nf.Filter)
+		deps = append(deps, nf)
+	}
+
+	// edges[i] holds the filters that must run after filter i.
+	edges := make([][]int, len(filters))
+	incoming := make([]int, len(filters))
+	edge := func(from, to int) {
+		edges[from] = append(edges[from], to)
+		incoming[to]++
+	}
+	for i := 1; i < len(chain); i++ {
+		edge(i-1, i)
+	}
+	for n, nf := range deps {
+		i := len(chain) + n
+		for _, name := range nf.After {
+			if j, ok := index[name]; ok {
+				edge(j, i)
+			}
+		}
+		for _, name := range nf.Before {
+			if j, ok := index[name]; ok {
+				edge(i, j)
+			}
+		}
+		if len(chain) > 0 {
+			edge(i, len(chain)-1)
+		}
+	}
+
+	// Always take the earliest ready filter, so that chain filters are
+	// preferred and registered filters are placed as late as possible.
+	sorted := make([]Filter, 0, len(filters))
+	done := make([]bool, len(filters))
+	for len(sorted) < len(filters) {
+		next := -1
+		for i := range filters {
+			if !done[i] && incoming[i] == 0 {
+				next = i
+				break
+			}
+		}
+		if next == -1 {
+			var cycle []string
+			for i, name := range names {
+				if !done[i] {
+					cycle = append(cycle, name)
+				}
+			}
+			return nil, fmt.Errorf("dependency cycle between filters %s", strings.Join(cycle, ", "))
+		}
+		done[next] = true
+		sorted = append(sorted, filters[next])
+		for _, j := range edges[next] {
+			incoming[j]--
+		}
+	}
+	return sorted, nil
+}
+
+// filterName returns the function name of f without its package, e.g.
+// "SessionFilter".
+func filterName(f Filter) string {
+	fn := runtime.FuncForPC(reflect.ValueOf(f).Pointer())
+	if fn == nil {
+		return ""
+	}
+	name := fn.Name()
+	return name[strings.LastIndex(name, ".")+1:]
+}
@@ -0,0 +1,91 @@
+package revel
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func authFilter(c *Controller, fc []Filter)    { fc[0](c, fc[1:]) }
+func csrfFilter(c *Controller, fc []Filter)    { fc[0](c, fc[1:]) }
+func corsFilter(c *Controller, fc []Filter)    { fc[0](c, fc[1:]) }
+func metricsFilter(c *Controller, fc []Filter) { fc[0](c, fc[1:]) }
+
+func chainNames(fc []Filter) string {
+	return strings.Join(filterNames(fc), ",")
+}
+
+func TestSortFilters(t *testing.T) {
+	chain := []Filter{PanicFilter, RouterFilter, SessionFilter, InterceptorFilter, ActionInvoker}
+	sorted, err := sortFilters(chain, []NamedFilter{
+		{Name: "csrf", Filter: csrfFilter, After: []string{"SessionFilter", "auth"}},
+		{Name: "auth", Filter: authFilter, After: []string{"RouterFilter"}, Before: []string{"InterceptorFilter"}},
+		{Name: "cors", Filter: corsFilter, Before: []string{"RouterFilter"}, After: []string{"MissingFilter"}},
+		{Name: "metrics", Filter: metricsFilter},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	eq(t, "order", chainNames(sorted),
+		"PanicFilter,corsFilter,RouterFilter,SessionFilter,authFilter,InterceptorFilter,csrfFilter,metricsFilter,ActionInvoker")
+
+	_, err = sortFilters(chain, []NamedFilter{
+		{Name: "auth", Filter: authFilter, Before: []string{"csrf"}},
+		{Name: "csrf", Filter: csrfFilter, Before: []string{"auth"}},
+	})
+	if err == nil || !strings.Contains(err.Error(), "auth, csrf") {
+		t.Errorf("Expected a dependency cycle error, got %v", err)
+	}
+}
+
+func TestApplyRegisteredFilters(t *testing.T) {
+	oldFilters, oldOverrides := Filters, filterOverrides
+	defer func() {
+		Filters, filterOverrides, registeredFilters = oldFilters, oldOverrides, nil
+	}()
+
+	Filters = []Filter{PanicFilter, FilterConfiguringFilter, SessionFilter, ActionInvoker}
+	filterOverrides = make(map[string][]Filter)
+	FilterAction(FakeController.Foo).Remove(SessionFilter)
+
+	RegisterFilter(NamedFilter{Name: "cors", Filter: corsFilter, Before: []string{"FilterConfiguringFilter"}})
+	RegisterFilter(NamedFilter{Name: "auth", Filter: authFilter, After: []string{"SessionFilter"}})
+	applyRegisteredFilters()
+
+	eq(t, "Filters", chainNames(Filters), "PanicFilter,corsFilter,FilterConfiguringFilter,SessionFilter,authFilter,ActionInvoker")
+	eq(t, "override", chainNames(filterOverrides["FakeController.Foo"]), "authFilter,ActionInvoker")
+}
+
+func TestAbort(t *testing.T) {
+	startFakeBookingApp()
+	oldFilters := Filters
+	defer func() { Filters = oldFilters }()
+
+	var calls []string
+	Filters = []Filter{
+		func(c *Controller, fc []Filter) {
+			defer func() { calls = append(calls, "cleanup") }()
+			calls = append(calls, "outer")
+			fc[0](c, fc[1:])
+			calls = append(calls, "outer after, aborted="+fmt.Sprint(c.Aborted()))
+		},
+		func(c *Controller, fc []Filter) {
+			c.Abort(c.RenderText("denied"))
+			// Calling the next filter after Abort is a no-op.
+			fc[0](c, fc[1:])
+		},
+		func(c *Controller, fc []Filter) {
+			calls = append(calls, "downstream")
+			fc[0](c, fc[1:])
+		},
+		func(c *Controller, fc []Filter) {
+			calls = append(calls, "action")
+		},
+	}
+
+	resp := httptest.NewRecorder()
+	handleInternal(resp, showRequest, nil)
+	eq(t, "calls", strings.Join(calls, ","), "outer,outer after, aborted=true,cleanup")
+	eq(t, "body", resp.Body.String(), "denied")
+}
@@ -202,7 +202,7 @@ func FilterEq(a, b Filter) bool {
 // filter chain for the action being invoked.
 func FilterConfiguringFilter(c *Controller, fc []Filter) {
 	if newChain := getOverrideChain(c.Name, c.Action); newChain != nil {
-		newChain[0](c, newChain[1:])
+		runChain(c, newChain)
 		return
 	}
 	fc[0](c, fc[1:])
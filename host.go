@@ -0,0 +1,130 @@
+package revel
+
+import (
+	"fmt"
+	"html/template"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+var (
+	// allowedHosts are the hosts served by the HostFilter, e.g. "example.com"
+	// or "*.example.com".
+	// They may be specified in config as "hosts.allowed" (comma separated).
+	allowedHosts []string
+
+	// canonicalURL is the scheme and host of absolute URLs generated by
+	// reverse routing, e.g. https://www.example.com.
+	// It may be specified in config as "hosts.canonical".
+	canonicalURL *url.URL
+)
+
+func init() {
+	OnAppStart(func() {
+		allowedHosts = nil
+		for _, host := range strings.Split(CurrentConfig().StringDefault("hosts.allowed", ""), ",") {
+			if host = strings.ToLower(strings.TrimSpace(host)); host != "" {
+				allowedHosts = append(allowedHosts, host)
+			}
+		}
+
+		canonicalURL = nil
+		if canonical := CurrentConfig().StringDefault("hosts.canonical", ""); canonical != "" {
+			u, err := url.Parse(canonical)
+			if err != nil || u.Scheme == "" || u.Host == "" {
+				panic(fmt.Errorf("hosts.canonical invalid: %q (expected e.g. https://www.example.com)", canonical))
+			}
+			canonicalURL = &url.URL{Scheme: u.Scheme, Host: u.Host}
+		}
+	})
+}
+
+// HostFilter rejects requests with 400 Bad Request unless their Host header
+// is one of the "hosts.allowed" (comma separated), so that links built from
+// the Host (e.g. in password reset emails) can not point to another site.
+// A "*." prefix allows any subdomain, but not the domain itself:
+//
+//	hosts.allowed = example.com, *.example.com
+//
+// The port is ignored.  The Host of allowed requests is normalized to lower
+// case, without a trailing dot.  Every host is allowed if none are listed.
+//
+// Absolute URLs should be built from the "hosts.canonical" URL instead (see
+// ActionDefinition.AbsoluteUrl), which does not depend on the request at all.
+func HostFilter(c *Controller, fc []Filter) {
+	if len(allowedHosts) == 0 {
+		fc[0](c, fc[1:])
+		return
+	}
+
+	host, port := splitHost(c.Request.Host)
+	if !hostAllowed(host) {
+		c.Response.Status = http.StatusBadRequest
+		c.Result = c.RenderError(&Error{
+			Title:       "Bad Request",
+			Description: fmt.Sprintf("The host %q is not served", c.Request.Host),
+		})
+		return
+	}
+	if port != "" {
+		host = net.JoinHostPort(host, port)
+	}
+	c.Request.Host = host
+	fc[0](c, fc[1:])
+}
+
+// splitHost returns the normalized host name and the port of a Host header.
+func splitHost(hostport string) (host, port string) {
+	host = hostport
+	if h, p, err := net.SplitHostPort(hostport); err == nil {
+		host, port = h, p
+	}
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	return strings.TrimSuffix(strings.TrimPrefix(host, "["), "]"), port
+}
+
+func hostAllowed(host string) bool {
+	if host == "" {
+		return false
+	}
+	for _, allowed := range allowedHosts {
+		if domain := strings.TrimPrefix(allowed, "*"); domain != allowed {
+			if strings.HasSuffix(host, domain) && len(host) > len(domain) {
+				return true
+			}
+		} else if host == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// canonicalHost returns the "hosts.canonical" host, if configured.
+func canonicalHost() string {
+	if canonicalURL == nil {
+		return ""
+	}
+	return canonicalURL.Host
+}
+
+// AbsoluteUrl returns the URL of the action prefixed with the "hosts.canonical"
+// scheme and host, or just the URL if it is not configured.
+func (a *ActionDefinition) AbsoluteUrl() string {
+	if canonicalURL == nil {
+		return a.Url
+	}
+	return canonicalURL.String() + a.Url
+}
+
+// ReverseAbsoluteUrl is ReverseUrl, with the URL prefixed with the
+// "hosts.canonical" scheme and host.  It is available to templates as
+// "absurl", e.g. {{absurl "Users.ResetPassword" .token}}.
+func ReverseAbsoluteUrl(args ...interface{}) (template.URL, error) {
+	u, err := ReverseUrl(args...)
+	if err != nil || canonicalURL == nil {
+		return u, err
+	}
+	return template.URL(canonicalURL.String()) + u, nil
+}
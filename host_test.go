@@ -0,0 +1,60 @@
+package revel
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestHostFilter(t *testing.T) {
+	startFakeBookingApp()
+	defer func() { allowedHosts = nil }()
+
+	run := func(host string) (*Controller, bool) {
+		req, _ := http.NewRequest("GET", "/hotels", nil)
+		req.Host = host
+		c := NewController(NewRequest(req), NewResponse(httptest.NewRecorder()))
+		called := false
+		HostFilter(c, []Filter{func(c *Controller, fc []Filter) { called = true }})
+		return c, called
+	}
+
+	if _, called := run("evil.com"); !called {
+		t.Error("Expected every host to be allowed by default")
+	}
+
+	allowedHosts = []string{"example.com", "*.example.org"}
+	for host, expected := range map[string]string{
+		"example.com":          "example.com",
+		"Example.COM.:9000":    "example.com:9000",
+		"api.example.org":      "api.example.org",
+		"a.b.example.org:8080": "a.b.example.org:8080",
+	} {
+		if c, called := run(host); !called || c.Request.Host != expected {
+			t.Errorf("Expected %s to be allowed as %s, got %s (called: %v)", host, expected, c.Request.Host, called)
+		}
+	}
+	for _, host := range []string{"evil.com", "example.org", "notexample.com", "evilexample.org", "example.com.evil.com", ""} {
+		if c, called := run(host); called || c.Response.Status != http.StatusBadRequest {
+			t.Errorf("Expected %q to be rejected with 400, got %d (called: %v)", host, c.Response.Status, called)
+		}
+	}
+}
+
+func TestReverseAbsoluteUrl(t *testing.T) {
+	startFakeBookingApp()
+	defer func() { canonicalURL = nil }()
+
+	if u, err := ReverseAbsoluteUrl("Hotels.Show", 3); err != nil || u != "/hotels/3" {
+		t.Errorf("Expected a relative URL without hosts.canonical, got %s (%v)", u, err)
+	}
+
+	canonicalURL = &url.URL{Scheme: "https", Host: "www.example.com"}
+	if u, err := ReverseAbsoluteUrl("Hotels.Show", 3); err != nil || u != "https://www.example.com/hotels/3" {
+		t.Errorf("Expected an absolute URL, got %s (%v)", u, err)
+	}
+	action := MainRouter.Reverse("Hotels.Show", map[string]string{"id": "3"})
+	eq(t, "Host", action.Host, "www.example.com")
+	eq(t, "AbsoluteUrl", action.AbsoluteUrl(), "https://www.example.com/hotels/3")
+}
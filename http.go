@@ -1,12 +1,16 @@
 package revel
 
 import (
+	"bufio"
 	"bytes"
 	"fmt"
+	"io"
+	"net"
 	"net/http"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"golang.org/x/net/websocket"
 )
@@ -18,6 +22,9 @@ type Request struct {
 	AcceptLanguages AcceptLanguages
 	Locale          string
 	Websocket       *websocket.Conn
+
+	bodyLimit    int64 // The body size limit set by the BodyLimitFilter, if any.
+	bodyLimitHit bool  // Set when reading the body exceeded a size limit.
 }
 
 type Response struct {
@@ -25,6 +32,9 @@ type Response struct {
 	ContentType string
 
 	Out http.ResponseWriter
+
+	headerWritten bool // Set by WriteHeader.
+	aborted       bool // Set to close the connection without completing the response.
 }
 
 func NewResponse(w http.ResponseWriter) *Response {
@@ -52,6 +62,72 @@ func (resp *Response) WriteHeader(defaultStatusCode int, defaultContentType stri
 	}
 	resp.Out.Header().Set("Content-Type", resp.ContentType)
 	resp.Out.WriteHeader(resp.Status)
+	resp.headerWritten = true
+}
+
+// Header returns the response headers, which must be set before WriteHeader.
+func (resp *Response) Header() http.Header {
+	return resp.Out.Header()
+}
+
+// Write writes to the response body.  If the header has not been written, it
+// is written first, with the status in resp.Status (200 if not set) and the
+// content type from the headers or else sniffed from b.
+func (resp *Response) Write(b []byte) (int, error) {
+	if !resp.headerWritten {
+		contentType := resp.Out.Header().Get("Content-Type")
+		if contentType == "" {
+			contentType = http.DetectContentType(b)
+		}
+		resp.WriteHeader(http.StatusOK, contentType)
+	}
+	return resp.Out.Write(b)
+}
+
+// SetWriteDeadline changes the deadline for writing the response, which the
+// server sets from "server.writetimeout".  Long-lived responses (e.g. event
+// streams) lift it with a zero time:
+//
+//	c.Response.SetWriteDeadline(time.Time{})
+//
+// It returns an error wrapping http.ErrNotSupported if the underlying
+// connection does not support deadlines.
+func (resp *Response) SetWriteDeadline(deadline time.Time) error {
+	// Unwraps the writers of the filters and results, e.g. the compression.
+	return http.NewResponseController(resp.Out).SetWriteDeadline(deadline)
+}
+
+// Flush sends any buffered response data to the client, if the underlying
+// writer supports it.
+func (resp *Response) Flush() {
+	if w, ok := resp.Out.(http.Flusher); ok {
+		w.Flush()
+	}
+}
+
+// readFromWriter copies r to w, through its ReadFrom if it has one, for the
+// writers wrapping another one: the files sent by the results may then still
+// be sent with sendfile.
+func readFromWriter(w http.ResponseWriter, r io.Reader) (int64, error) {
+	if rf, ok := w.(io.ReaderFrom); ok {
+		return rf.ReadFrom(r)
+	}
+	return io.Copy(w, r)
+}
+
+// hijackWriter hijacks the connection of w, for the writers wrapping another
+// one.
+func hijackWriter(w http.ResponseWriter) (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(w).Hijack()
+}
+
+// closeNotifyWriter returns the CloseNotify channel of w, or nil if it has
+// none, for the writers wrapping another one.
+func closeNotifyWriter(w http.ResponseWriter) <-chan bool {
+	if cn, ok := w.(http.CloseNotifier); ok {
+		return cn.CloseNotify()
+	}
+	return nil
 }
 
 // Get the content type.
@@ -92,6 +168,14 @@ func ResolveFormat(req *http.Request) string {
 	return "html"
 }
 
+// IsHtmx returns true if the request was made by HTMX to swap a fragment into
+// the page, i.e. it has "HX-Request: true".  History restoration requests,
+// which HTMX makes for the whole page, are not counted.
+func (req *Request) IsHtmx() bool {
+	return req.Header.Get("HX-Request") == "true" &&
+		req.Header.Get("HX-History-Restore-Request") != "true"
+}
+
 // AcceptLanguage is a single language from the Accept-Language HTTP header.
 type AcceptLanguage struct {
 	Language string
@@ -147,6 +231,50 @@ func ResolveAcceptLanguage(req *http.Request) AcceptLanguages {
 		}
 	}
 
-	sort.Sort(acceptLanguages)
+	sort.Stable(acceptLanguages)
 	return acceptLanguages
 }
+
+// Match returns the supported language best matching the accepted languages,
+// or false if none does.  The accepted languages are tried in order of
+// quality (those with a quality of 0 are skipped, and "*" matches the first
+// supported language).  For each, the supported languages are searched for, in
+// order:
+//  1. The same language and region, e.g. "en-GB" for "en-GB"
+//  2. The language without a region, e.g. "en" for "en-GB"
+//  3. The language with another region, e.g. "en-US" for "en-GB" or "en"
+//
+// Languages are compared case-insensitively.
+func (al AcceptLanguages) Match(supported []string) (string, bool) {
+	for _, accepted := range al {
+		language := strings.TrimSpace(accepted.Language)
+		if accepted.Quality <= 0 || language == "" {
+			continue
+		}
+		if language == "*" && len(supported) > 0 {
+			return supported[0], true
+		}
+		primary := primaryLanguage(language)
+		for _, candidate := range []func(string) bool{
+			func(s string) bool { return strings.EqualFold(s, language) },
+			func(s string) bool { return strings.EqualFold(s, primary) },
+			func(s string) bool { return strings.EqualFold(primaryLanguage(s), primary) },
+		} {
+			for _, s := range supported {
+				if candidate(s) {
+					return s, true
+				}
+			}
+		}
+	}
+	return "", false
+}
+
+// primaryLanguage returns the language of a language tag without its region,
+// e.g. "en" for "en-GB".
+func primaryLanguage(tag string) string {
+	if i := strings.IndexAny(tag, "-_"); i != -1 {
+		return tag[:i]
+	}
+	return tag
+}
@@ -1,11 +1,15 @@
 package revel
 
 import (
+	"encoding/json"
 	"fmt"
+	"net/http"
 	"os"
 	"path/filepath"
 	"regexp"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/revel/config"
 )
@@ -13,17 +17,26 @@ import (
 const (
 	CurrentLocaleRenderArg = "currentLocale" // The key for the current locale render arg value
 
-	messageFilesDirectory  = "messages"
-	messageFilePattern     = `^\w+\.[a-zA-Z]{2}$`
-	defaultUnknownFormat   = "??? %s ???"
-	unknownFormatConfigKey = "i18n.unknown_format"
-	defaultLanguageOption  = "i18n.default_language"
-	localeCookieConfigKey  = "i18n.cookie"
+	messageFilesDirectory   = "messages"
+	messageFilePattern      = `^\w+\.[a-zA-Z]{2}(\.json)?$`
+	jsonMessageFileExt      = ".json"
+	defaultUnknownFormat    = "??? %s ???"
+	unknownFormatConfigKey  = "i18n.unknown_format"
+	defaultLanguageOption   = "i18n.default_language"
+	localeCookieConfigKey   = "i18n.cookie"
+	fallbackConfigKeyPrefix = "i18n.fallback."
+	missingConfigKey        = "i18n.missing"
 )
 
 var (
 	// All currently loaded message configs.
-	messages map[string]*config.Config
+	messages     map[string]*config.Config
+	messagesLock sync.RWMutex
+
+	// The loader of the application's messages, watched in dev mode.
+	mainMessageLoader messageLoader
+
+	localePattern = regexp.MustCompile(`^[a-zA-Z]{2,3}(-[a-zA-Z0-9]{2,8})?$`)
 )
 
 // Setting MessageFunc allows you to override the translation interface.
@@ -36,6 +49,8 @@ var MessageFunc func(locale, message string, args ...interface{}) (value string)
 
 // Return all currently loaded message languages.
 func MessageLanguages() []string {
+	messagesLock.RLock()
+	defer messagesLock.RUnlock()
 	languages := make([]string, len(messages))
 	i := 0
 	for language, _ := range messages {
@@ -47,43 +62,99 @@ func MessageLanguages() []string {
 
 // Perform a message look-up for the given locale and message using the given arguments.
 //
-// When either an unknown locale or message is detected, a specially formatted string is returned.
+// When the message is not found for the locale, the locales of its fallback chain are tried in
+// order (see localeFallbacks).  When none of them has the message, the result depends on the
+// "i18n.missing" option (see missingMessage).
 func Message(locale, message string, args ...interface{}) string {
-	language, region := parseLocale(locale)
-	unknownValueFormat := getUnknownValueFormat()
-
-	messageConfig, knownLanguage := messages[language]
-	if !knownLanguage {
-		TRACE.Printf("Unsupported language for locale '%s' and message '%s', trying default language", locale, message)
+	for _, candidate := range localeFallbacks(locale) {
+		language, region := parseLocale(candidate)
+		messagesLock.RLock()
+		messageConfig, knownLanguage := messages[language]
+		messagesLock.RUnlock()
+		if !knownLanguage {
+			TRACE.Printf("Unsupported language for locale '%s' and message '%s', trying the next fallback", candidate, message)
+			continue
+		}
 
-		if defaultLanguage, found := Config.String(defaultLanguageOption); found {
-			TRACE.Printf("Using default language '%s'", defaultLanguage)
+		// This works because unlike the goconfig documentation suggests it will actually
+		// try to resolve message in DEFAULT if it did not find it in the given section.
+		value, error := messageConfig.String(region, message)
+		if error != nil {
+			TRACE.Printf("Unknown message '%s' for locale '%s', trying the next fallback", message, candidate)
+			continue
+		}
 
-			messageConfig, knownLanguage = messages[defaultLanguage]
-			if !knownLanguage {
-				WARN.Printf("Unsupported default language for locale '%s' and message '%s'", defaultLanguage, message)
-				return fmt.Sprintf(unknownValueFormat, message)
-			}
-		} else {
-			WARN.Printf("Unable to find default language option (%s); messages for unsupported locales will never be translated", defaultLanguageOption)
-			return fmt.Sprintf(unknownValueFormat, message)
+		if len(args) > 0 {
+			TRACE.Printf("Arguments detected, formatting '%s' with %v", value, args)
+			value = fmt.Sprintf(value, args...)
 		}
+
+		return value
 	}
 
-	// This works because unlike the goconfig documentation suggests it will actually
-	// try to resolve message in DEFAULT if it did not find it in the given section.
-	value, error := messageConfig.String(region, message)
-	if error != nil {
-		WARN.Printf("Unknown message '%s' for locale '%s'", message, locale)
-		return fmt.Sprintf(unknownValueFormat, message)
+	return missingMessage(locale, message)
+}
+
+// localeFallbacks returns the locales to look a message up in, in order:
+//  1. The locale itself, e.g. "pt-BR"
+//  2. Its language, e.g. "pt"
+//  3. The locales configured in "i18n.fallback.<locale>" or "i18n.fallback.<language>"
+//     (comma separated, each followed by its language), e.g. "i18n.fallback.pt = es"
+//  4. The default language ("i18n.default_language")
+func localeFallbacks(locale string) []string {
+	var (
+		chain []string
+		seen  = make(map[string]bool)
+	)
+	add := func(candidate string) {
+		if candidate = strings.TrimSpace(candidate); candidate != "" && !seen[candidate] {
+			seen[candidate] = true
+			chain = append(chain, candidate)
+		}
+		if language, region := parseLocale(candidate); region != "" && !seen[language] {
+			seen[language] = true
+			chain = append(chain, language)
+		}
 	}
 
-	if len(args) > 0 {
-		TRACE.Printf("Arguments detected, formatting '%s' with %v", value, args)
-		value = fmt.Sprintf(value, args...)
+	add(locale)
+	language, _ := parseLocale(locale)
+	configured, found := CurrentConfig().String(fallbackConfigKeyPrefix + locale)
+	if !found {
+		configured, _ = CurrentConfig().String(fallbackConfigKeyPrefix + language)
+	}
+	for _, fallback := range strings.Split(configured, ",") {
+		add(fallback)
+	}
+	if defaultLanguage, found := CurrentConfig().String(defaultLanguageOption); found {
+		add(defaultLanguage)
+	} else {
+		TRACE.Printf("Unable to find default language option (%s)", defaultLanguageOption)
 	}
+	return chain
+}
+
+// missingMessages records the messages already reported in "log" mode.
+var missingMessages sync.Map
 
-	return value
+// missingMessage returns the value shown for a message, according to "i18n.missing":
+//
+//	placeholder - the message formatted with "i18n.unknown_format", e.g. "??? greeting ???" (default)
+//	key         - the message key itself
+//	log         - the message key itself, logging the missing message only once
+func missingMessage(locale, message string) string {
+	switch CurrentConfig().StringDefault(missingConfigKey, "placeholder") {
+	case "key":
+		WARN.Printf("Unknown message '%s' for locale '%s'", message, locale)
+		return message
+	case "log":
+		if _, logged := missingMessages.LoadOrStore(locale+"\x00"+message, true); !logged {
+			WARN.Printf("Unknown message '%s' for locale '%s'", message, locale)
+		}
+		return message
+	}
+	WARN.Printf("Unknown message '%s' for locale '%s'", message, locale)
+	return fmt.Sprintf(getUnknownValueFormat(), message)
 }
 
 func parseLocale(locale string) (language, region string) {
@@ -97,30 +168,39 @@ func parseLocale(locale string) (language, region string) {
 
 // Retrieve message format or default format when i18n message is missing.
 func getUnknownValueFormat() string {
-	return Config.StringDefault(unknownFormatConfigKey, defaultUnknownFormat)
+	return CurrentConfig().StringDefault(unknownFormatConfigKey, defaultUnknownFormat)
 }
 
 // Recursively read and cache all available messages from all message files on the given path.
-func loadMessages(path string) {
-	messages = make(map[string]*config.Config)
+// Message files that can not be parsed are logged and skipped.
+func loadMessages(path string) *Error {
+	loaded := make(map[string]*config.Config)
+	loadFile := func(path string, info os.FileInfo, osError error) error {
+		return loadMessageFile(loaded, path, info, osError)
+	}
 
 	// Read in messages from the modules. Load the module messges first,
 	// so that it can be override in parent application
 	for _, module := range Modules {
 		TRACE.Println("Importing messages from module:", module.ImportPath)
-		if err := Walk(filepath.Join(module.Path, messageFilesDirectory), loadMessageFile); err != nil &&
+		if err := Walk(filepath.Join(module.Path, messageFilesDirectory), loadFile); err != nil &&
 			!os.IsNotExist(err) {
-			ERROR.Println("Error reading messages files from module:", err)
+			return messageFileError(err)
 		}
 	}
 
-	if err := Walk(path, loadMessageFile); err != nil && !os.IsNotExist(err) {
-		ERROR.Println("Error reading messages files:", err)
+	if err := Walk(path, loadFile); err != nil && !os.IsNotExist(err) {
+		return messageFileError(err)
 	}
+
+	messagesLock.Lock()
+	messages = loaded
+	messagesLock.Unlock()
+	return nil
 }
 
-// Load a single message file
-func loadMessageFile(path string, info os.FileInfo, osError error) error {
+// Load a single message file into the given messages
+func loadMessageFile(messages map[string]*config.Config, path string, info os.FileInfo, osError error) error {
 	if osError != nil {
 		return osError
 	}
@@ -130,7 +210,7 @@ func loadMessageFile(path string, info os.FileInfo, osError error) error {
 
 	if matched, _ := regexp.MatchString(messageFilePattern, info.Name()); matched {
 		if config, error := parseMessagesFile(path); error != nil {
-			return error
+			ERROR.Printf("Skipping message file %s: %s", path, error)
 		} else {
 			locale := parseLocaleFromFileName(info.Name())
 
@@ -151,29 +231,142 @@ func loadMessageFile(path string, info os.FileInfo, osError error) error {
 	return nil
 }
 
+// messageFileError converts an error reading the message files to an Error,
+// for the error page.
+func messageFileError(err error) *Error {
+	return &Error{
+		Title:       "Failed to load message files",
+		Description: err.Error(),
+	}
+}
+
 func parseMessagesFile(path string) (messageConfig *config.Config, error error) {
+	if filepath.Ext(path) == jsonMessageFileExt {
+		return parseJsonMessagesFile(path)
+	}
 	messageConfig, error = config.ReadDefault(path)
 	return
 }
 
+// parseJsonMessagesFile reads a JSON message file, e.g. "messages/app.en.json".
+// Nested objects are flattened to dotted keys, so that
+//
+//	{"greeting": "Hello", "booking": {"confirm": "Book %s?"}}
+//
+// holds the messages "greeting" and "booking.confirm".
+func parseJsonMessagesFile(path string) (*config.Config, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var values map[string]interface{}
+	decoder := json.NewDecoder(file)
+	decoder.UseNumber()
+	if err := decoder.Decode(&values); err != nil {
+		return nil, err
+	}
+
+	messageConfig := config.NewDefault()
+	if err := addJsonMessages(messageConfig, "", values); err != nil {
+		return nil, err
+	}
+	return messageConfig, nil
+}
+
+func addJsonMessages(messageConfig *config.Config, prefix string, values map[string]interface{}) error {
+	for key, value := range values {
+		switch value := value.(type) {
+		case map[string]interface{}:
+			if err := addJsonMessages(messageConfig, prefix+key+".", value); err != nil {
+				return err
+			}
+		case string:
+			messageConfig.AddOption(config.DEFAULT_SECTION, prefix+key, value)
+		case json.Number, bool:
+			messageConfig.AddOption(config.DEFAULT_SECTION, prefix+key, fmt.Sprint(value))
+		default:
+			return fmt.Errorf("message '%s' is not a string or an object", prefix+key)
+		}
+	}
+	return nil
+}
+
 func parseLocaleFromFileName(file string) string {
-	extension := filepath.Ext(file)[1:]
+	extension := filepath.Ext(strings.TrimSuffix(file, jsonMessageFileExt))[1:]
 	return strings.ToLower(extension)
 }
 
+// messageLoader (re)loads the messages of the application and its modules.
+// In dev mode it is registered with the watcher, so that edited message files
+// are reloaded on the next request.
+type messageLoader struct {
+	path string
+}
+
+func (loader messageLoader) Refresh() *Error {
+	err := loadMessages(loader.path)
+	if err != nil {
+		ERROR.Println(err)
+	}
+	return err
+}
+
+func (loader messageLoader) WatchDir(info os.FileInfo) bool {
+	return !strings.HasPrefix(info.Name(), ".")
+}
+
+func (loader messageLoader) WatchFile(basename string) bool {
+	matched, _ := regexp.MatchString(messageFilePattern, filepath.Base(basename))
+	return matched
+}
+
+// paths returns the existing message directories, of the modules and the
+// application.
+func (loader messageLoader) paths() []string {
+	var paths []string
+	for _, module := range Modules {
+		paths = append(paths, filepath.Join(module.Path, messageFilesDirectory))
+	}
+	paths = append(paths, loader.path)
+
+	existing := paths[:0]
+	for _, path := range paths {
+		if _, err := os.Stat(path); err == nil {
+			existing = append(existing, path)
+		}
+	}
+	return existing
+}
+
 func init() {
 	OnAppStart(func() {
-		loadMessages(filepath.Join(BasePath, messageFilesDirectory))
+		mainMessageLoader = messageLoader{filepath.Join(BasePath, messageFilesDirectory)}
+		mainMessageLoader.Refresh()
 	})
 }
 
+// I18nFilter resolves the locale of the request, in order of precedence:
+//  1. The locale cookie (see Controller.SetLocale)
+//  2. The Accept-Language header, in order of quality
+//  3. The default language ("i18n.default_language"), if the request sent
+//     a cookie or header but none of its locales was valid
+//
+// Locales that are malformed, or whose language has no messages, are
+// skipped.  The resolved locale is available as c.CurrentLocale(); it is ""
+// if the request sent neither, and messages then use the default language.
 func I18nFilter(c *Controller, fc []Filter) {
-	if foundCookie, cookieValue := hasLocaleCookie(c.Request); foundCookie {
+	foundCookie, cookieValue := hasLocaleCookie(c.Request)
+	if foundCookie && isValidLocale(cookieValue) {
 		TRACE.Printf("Found locale cookie value: %s", cookieValue)
 		setCurrentLocaleControllerArguments(c, cookieValue)
 	} else if foundHeader, headerValue := hasAcceptLanguageHeader(c.Request); foundHeader {
 		TRACE.Printf("Found Accept-Language header value: %s", headerValue)
 		setCurrentLocaleControllerArguments(c, headerValue)
+	} else if defaultLanguage := CurrentConfig().StringDefault(defaultLanguageOption, ""); (foundCookie || len(c.Request.AcceptLanguages) > 0) && isValidLocale(defaultLanguage) {
+		TRACE.Printf("Unable to find locale in cookie or header, using default language: %s", defaultLanguage)
+		setCurrentLocaleControllerArguments(c, defaultLanguage)
 	} else {
 		TRACE.Println("Unable to find locale in cookie or header, using empty string")
 		setCurrentLocaleControllerArguments(c, "")
@@ -181,19 +374,88 @@ func I18nFilter(c *Controller, fc []Filter) {
 	fc[0](c, fc[1:])
 }
 
+// SetLocale switches the locale for the rest of the request, and stores it
+// in the locale cookie ("i18n.cookie") so that it is used for later requests
+// too.  An error is returned, and the locale left unchanged, if the locale
+// is malformed or its language has no messages.
+func (c *Controller) SetLocale(locale string) error {
+	if !isValidLocale(locale) {
+		return fmt.Errorf("revel/i18n: unsupported locale %q", locale)
+	}
+	setCurrentLocaleControllerArguments(c, locale)
+	c.SetCookie(&http.Cookie{
+		Name:     CurrentConfig().StringDefault(localeCookieConfigKey, CookiePrefix+"_LANG"),
+		Value:    locale,
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   CookieSecure,
+		Expires:  time.Now().AddDate(1, 0, 0).UTC(),
+	})
+	return nil
+}
+
+// CurrentLocale returns the locale used for the request, as resolved by the
+// I18nFilter or set with SetLocale.
+func (c *Controller) CurrentLocale() string {
+	return c.Request.Locale
+}
+
+// PreferredLanguage returns the language of the supported set that best
+// matches the Accept-Language header (see AcceptLanguages.Match), e.g.
+//
+//	lang := c.PreferredLanguage([]string{"en", "en-US", "fr"})
+//
+// If none matches, it returns the default language ("i18n.default_language")
+// if it is supported, or else the first supported language.
+func (c *Controller) PreferredLanguage(supported []string) string {
+	if language, ok := c.Request.AcceptLanguages.Match(supported); ok {
+		return language
+	}
+	defaultLanguage := CurrentConfig().StringDefault(defaultLanguageOption, "")
+	for _, language := range supported {
+		if strings.EqualFold(language, defaultLanguage) {
+			return language
+		}
+	}
+	if len(supported) > 0 {
+		return supported[0]
+	}
+	return ""
+}
+
+// isValidLocale returns true for well-formed locales (e.g. "en", "en-US")
+// whose language has messages.  When no messages are loaded at all, any
+// well-formed locale is accepted.
+func isValidLocale(locale string) bool {
+	if !localePattern.MatchString(locale) {
+		return false
+	}
+	messagesLock.RLock()
+	defer messagesLock.RUnlock()
+	if len(messages) == 0 {
+		return true
+	}
+	language, _ := parseLocale(locale)
+	_, found := messages[strings.ToLower(language)]
+	return found
+}
+
 // Set the current locale controller argument (CurrentLocaleControllerArg) with the given locale.
 func setCurrentLocaleControllerArguments(c *Controller, locale string) {
 	c.Request.Locale = locale
 	c.RenderArgs[CurrentLocaleRenderArg] = locale
 }
 
-// Determine whether the given request has valid Accept-Language value.
+// Determine whether the given request has valid Accept-Language value, returning the best
+// supported one.
 //
 // Assumes that the accept languages stored in the request are sorted according to quality, with top
 // quality first in the slice.
 func hasAcceptLanguageHeader(request *Request) (bool, string) {
-	if request.AcceptLanguages != nil && len(request.AcceptLanguages) > 0 {
-		return true, request.AcceptLanguages[0].Language
+	for _, acceptLanguage := range request.AcceptLanguages {
+		if isValidLocale(acceptLanguage.Language) {
+			return true, acceptLanguage.Language
+		}
 	}
 
 	return false, ""
@@ -202,7 +464,7 @@ func hasAcceptLanguageHeader(request *Request) (bool, string) {
 // Determine whether the given request has a valid language cookie value.
 func hasLocaleCookie(request *Request) (bool, string) {
 	if request != nil && request.Cookies() != nil {
-		name := Config.StringDefault(localeCookieConfigKey, CookiePrefix+"_LANG")
+		name := CurrentConfig().StringDefault(localeCookieConfigKey, CookiePrefix+"_LANG")
 		if cookie, error := request.Cookie(name); error == nil {
 			return true, cookie.Value
 		} else {
@@ -4,6 +4,10 @@ import (
 	"io/ioutil"
 	"log"
 	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -123,6 +127,11 @@ func TestBeforeRequest(t *testing.T) {
 		t.Errorf("Expected to find current language '%s' in controller, found '%s' instead", "", c.Request.Locale)
 	}
 
+	c = NewController(buildRequestWithCookie("APP_LANG", "xx"), nil)
+	if I18nFilter(c, NilChain); c.Request.Locale != "en" {
+		t.Errorf("Expected to find default language '%s' in controller, found '%s' instead", "en", c.Request.Locale)
+	}
+
 	c = NewController(buildRequestWithCookie("APP_LANG", "en-US"), nil)
 	if I18nFilter(c, NilChain); c.Request.Locale != "en-US" {
 		t.Errorf("Expected to find current language '%s' in controller, found '%s' instead", "en-US", c.Request.Locale)
@@ -134,6 +143,75 @@ func TestBeforeRequest(t *testing.T) {
 	}
 }
 
+func TestI18nLocaleResolution(t *testing.T) {
+	loadMessages(testDataPath)
+	loadTestI18nConfig(t)
+
+	// Unsupported or malformed locales fall through to the next source.
+	c := NewController(buildRequestWithCookie("APP_LANG", "xx"), nil)
+	c.Request.AcceptLanguages = buildRequestWithAcceptLanguages("fr-FR", "nl").AcceptLanguages
+	if I18nFilter(c, NilChain); c.CurrentLocale() != "nl" {
+		t.Errorf("Expected the supported Accept-Language 'nl', found '%s' instead", c.CurrentLocale())
+	}
+
+	c = NewController(buildRequestWithCookie("APP_LANG", "english"), nil)
+	if I18nFilter(c, NilChain); c.CurrentLocale() != "en" {
+		t.Errorf("Expected the default language 'en', found '%s' instead", c.CurrentLocale())
+	}
+}
+
+func TestSetLocale(t *testing.T) {
+	loadMessages(testDataPath)
+	loadTestI18nConfig(t)
+
+	resp := httptest.NewRecorder()
+	c := NewController(buildRequestWithCookie("APP_LANG", "en"), NewResponse(resp))
+	I18nFilter(c, NilChain)
+
+	if err := c.SetLocale("nl-BE"); err != nil {
+		t.Fatal(err)
+	}
+	if c.CurrentLocale() != "nl-BE" || c.RenderArgs[CurrentLocaleRenderArg] != "nl-BE" {
+		t.Errorf("Expected the locale to be switched to 'nl-BE', found '%s'", c.CurrentLocale())
+	}
+	if message := c.Message("greeting"); message != "Hallokes" {
+		t.Errorf("Expected the message in the new locale, got '%s'", message)
+	}
+	if cookie := resp.Header().Get("Set-Cookie"); !strings.HasPrefix(cookie, "APP_LANG=nl-BE") {
+		t.Errorf("Expected the locale cookie to be set, got '%s'", cookie)
+	}
+
+	if err := c.SetLocale("xx"); err == nil || c.CurrentLocale() != "nl-BE" {
+		t.Errorf("Expected unsupported locales to be rejected, got %v and '%s'", err, c.CurrentLocale())
+	}
+}
+
+func TestI18nMessageFallbacks(t *testing.T) {
+	loadMessages(testDataPath)
+	loadTestI18nConfig(t)
+	Config.SetOption("i18n.fallback.de", "en-AU")
+
+	tests := []struct{ locale, message, expected string }{
+		{"nl-BE", "greeting", "Hallokes"},           // The region.
+		{"nl-LU", "greeting", "Hallo"},              // The region's language.
+		{"nl", "only_exists_in_default", "Default"}, // The default language.
+		{"de-CH", "greeting", "G'day"},              // The configured fallback.
+		{"nl", "unknown message", "??? unknown message ???"},
+	}
+	for _, test := range tests {
+		if message := Message(test.locale, test.message); message != test.expected {
+			t.Errorf("Message '%s' for locale '%s' was '%s', expected '%s'", test.message, test.locale, message, test.expected)
+		}
+	}
+
+	for _, mode := range []string{"key", "log"} {
+		Config.SetOption("i18n.missing", mode)
+		if message := Message("nl", "unknown message"); message != "unknown message" {
+			t.Errorf("Expected the key for missing messages in %s mode, got '%s'", mode, message)
+		}
+	}
+}
+
 func TestI18nMessageUnknownValueFormat(t *testing.T) {
 	loadMessages(testDataPath)
 	loadTestI18nConfigWithUnknowFormatOption(t)
@@ -152,6 +230,95 @@ func TestI18nMessageUnknownValueFormat(t *testing.T) {
 	}
 }
 
+func TestI18nJsonMessages(t *testing.T) {
+	loadMessages(testDataPath)
+	loadTestI18nConfig(t)
+
+	if message := Message("en", "json.greeting"); message != "Hello from JSON" {
+		t.Errorf("Message 'json.greeting' for locale 'en' (%s) does not have the expected value", message)
+	}
+	if message := Message("en", "json.nested.arguments", 3, "Hotel Rivoli"); message != "3 nights at Hotel Rivoli" {
+		t.Errorf("Message 'json.nested.arguments' for locale 'en' (%s) does not have the expected value", message)
+	}
+	if message := Message("en", "json.count"); message != "3" {
+		t.Errorf("Message 'json.count' for locale 'en' (%s) does not have the expected value", message)
+	}
+	// The JSON messages are merged with the other message files of the locale.
+	if message := Message("en", "greeting"); message != "Hello" {
+		t.Errorf("Message 'greeting' for locale 'en' (%s) does not have the expected value", message)
+	}
+
+	if locale := parseLocaleFromFileName("app.nl.json"); locale != "nl" {
+		t.Errorf("Expected locale 'nl' for app.nl.json, got '%s'", locale)
+	}
+}
+
+func TestI18nMessageLoaderRefresh(t *testing.T) {
+	dir, err := ioutil.TempDir("", "revel-messages")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "app.en")
+	writeFile := func(content string) {
+		if err := ioutil.WriteFile(file, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	loadTestI18nConfig(t)
+	loader := messageLoader{dir}
+
+	writeFile("greeting=Hello\n")
+	if err := loader.Refresh(); err != nil {
+		t.Fatalf("Unexpected error loading the messages: %s", err)
+	}
+	if message := Message("en", "greeting"); message != "Hello" {
+		t.Errorf("Expected 'Hello', got '%s'", message)
+	}
+
+	// A broken message file is skipped, and the others are still loaded.
+	broken := filepath.Join(dir, "app.nl")
+	if err := ioutil.WriteFile(broken, []byte("greeting=Hallo\n[BE]\nbroken\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := loader.Refresh(); err != nil {
+		t.Errorf("Unexpected error for the broken message file: %s", err)
+	}
+	if message := Message("en", "greeting"); message != "Hello" {
+		t.Errorf("Expected the other messages to be loaded, got '%s'", message)
+	}
+	if message := Message("nl", "greeting"); message == "Hallo" {
+		t.Error("Expected the broken message file to be skipped")
+	}
+	os.Remove(broken)
+
+	writeFile("greeting=Hi\n")
+	if err := loader.Refresh(); err != nil {
+		t.Fatalf("Unexpected error reloading the messages: %s", err)
+	}
+	if message := Message("en", "greeting"); message != "Hi" {
+		t.Errorf("Expected the reloaded 'Hi', got '%s'", message)
+	}
+
+	writeFile(`{"greeting": ["Hi"]}`)
+	if err := os.Rename(file, file+".json"); err != nil {
+		t.Fatal(err)
+	}
+	if err := loader.Refresh(); err != nil {
+		t.Errorf("Unexpected error for %s.json: %s", file, err)
+	}
+	if message := Message("en", "greeting"); message == "Hi" {
+		t.Errorf("Expected %s.json to be skipped, got '%s'", file, message)
+	}
+
+	if !loader.WatchFile(file) || !loader.WatchFile(file+".json") || loader.WatchFile(filepath.Join(dir, "app.en.swp")) {
+		t.Error("Expected only message files to be watched")
+	}
+	if paths := loader.paths(); len(paths) != 1 || paths[0] != dir {
+		t.Errorf("Expected the watched paths to be [%s], got %v", dir, paths)
+	}
+}
+
 func BenchmarkI18nLoadMessages(b *testing.B) {
 	excludeFromTimer(b, func() { TRACE = log.New(ioutil.Discard, "", 0) })
 
@@ -0,0 +1,49 @@
+package revel
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sync"
+)
+
+var (
+	// IDSource is the source of randomness of NewID and NewUUID, crypto/rand
+	// by default.  Tests may replace it with a deterministic source, e.g.
+	//
+	//	revel.IDSource = mathrand.New(mathrand.NewSource(1))
+	//
+	// Reads are serialized, so the source need not be safe for concurrent use.
+	IDSource io.Reader = rand.Reader
+
+	idSourceLock sync.Mutex
+)
+
+// NewID returns a random identifier of 256 bits, hex encoded (64 characters).
+// It is used for session IDs, and is suitable for any token that must not be
+// guessable.
+func NewID() string {
+	return hex.EncodeToString(randomBytes(32))
+}
+
+// NewUUID returns a random (version 4) UUID, e.g.
+// "9b2f5c74-3c1e-4c55-a6d2-0e8f4d3b7a61".
+func NewUUID() string {
+	uuid := randomBytes(16)
+	uuid[6] = uuid[6]&0x0f | 0x40 // Version 4
+	uuid[8] = uuid[8]&0x3f | 0x80 // RFC 4122 variant
+	return fmt.Sprintf("%x-%x-%x-%x-%x", uuid[0:4], uuid[4:6], uuid[6:8], uuid[8:10], uuid[10:])
+}
+
+// randomBytes reads n bytes from the IDSource.  It panics if the source
+// fails, as no secure identifier can be produced.
+func randomBytes(n int) []byte {
+	buffer := make([]byte, n)
+	idSourceLock.Lock()
+	defer idSourceLock.Unlock()
+	if _, err := io.ReadFull(IDSource, buffer); err != nil {
+		panic(fmt.Errorf("revel: failed to generate a random ID: %s", err))
+	}
+	return buffer
+}
@@ -0,0 +1,39 @@
+package revel
+
+import (
+	"bytes"
+	"crypto/rand"
+	"regexp"
+	"testing"
+)
+
+func TestNewID(t *testing.T) {
+	defer func() { IDSource = rand.Reader }()
+
+	IDSource = bytes.NewReader(bytes.Repeat([]byte{0xab}, 32+16))
+	if id := NewID(); id != string(bytes.Repeat([]byte("ab"), 32)) {
+		t.Errorf("Unexpected ID from a deterministic source: %s", id)
+	}
+	if uuid := NewUUID(); uuid != "abababab-abab-4bab-abab-abababababab" {
+		t.Errorf("Unexpected UUID from a deterministic source: %s", uuid)
+	}
+
+	IDSource = bytes.NewReader(bytes.Repeat([]byte{0x01}, 8))
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("Expected an exhausted source to panic")
+			}
+		}()
+		NewUUID()
+	}()
+
+	IDSource = rand.Reader
+	uuidPattern := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	if uuid := NewUUID(); !uuidPattern.MatchString(uuid) {
+		t.Errorf("Expected a version 4 UUID, got %s", uuid)
+	}
+	if NewID() == NewID() {
+		t.Error("Expected random IDs to differ")
+	}
+}
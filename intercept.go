@@ -3,6 +3,7 @@ package revel
 import (
 	"log"
 	"reflect"
+	"strings"
 )
 
 // An "interceptor" is functionality invoked by the framework BEFORE or AFTER
@@ -60,6 +61,7 @@ type Interception struct {
 	callable     reflect.Value
 	target       reflect.Type
 	interceptAll bool
+	methodPrefix string // Only intercept the actions with this prefix.
 }
 
 // Perform the given interception.
@@ -109,7 +111,7 @@ func invokeInterceptors(when When, c *Controller) {
 		app    = reflect.ValueOf(c.AppController)
 		result Result
 	)
-	for _, intc := range getInterceptors(when, app) {
+	for _, intc := range getInterceptors(when, app, c.MethodName) {
 		resultValue := intc.Invoke(app)
 		if !resultValue.IsNil() {
 			result = resultValue.Interface().(Result)
@@ -140,6 +142,30 @@ func InterceptFunc(intc InterceptorFunc, when When, target interface{}) {
 	})
 }
 
+// InterceptController installs an interceptor for all the actions of the
+// given controller type (and of the controllers embedding it), e.g.
+//
+//	revel.InterceptController(reflect.TypeOf((*Admin)(nil)), revel.BEFORE, checkAdmin)
+func InterceptController(target reflect.Type, when When, intc InterceptorFunc) {
+	InterceptMethodPrefix(target, "", when, intc)
+}
+
+// InterceptMethodPrefix installs an interceptor for the actions of the given
+// controller type whose method name starts with prefix, e.g. all the
+// "Api..." actions.  A nil target applies it to every controller.
+//
+// Like every interceptor, they run in the order they are installed.
+func InterceptMethodPrefix(target reflect.Type, prefix string, when When, intc InterceptorFunc) {
+	interceptors = append(interceptors, &Interception{
+		When:         when,
+		function:     intc,
+		callable:     reflect.ValueOf(intc),
+		target:       target,
+		interceptAll: target == nil,
+		methodPrefix: prefix,
+	})
+}
+
 // Install an interceptor method that applies to its own Controller.
 //   func (c AppController) example() revel.Result
 //   func (c *AppController) example() revel.Result
@@ -157,10 +183,10 @@ func InterceptMethod(intc InterceptorMethod, when When) {
 	})
 }
 
-func getInterceptors(when When, val reflect.Value) []*Interception {
+func getInterceptors(when When, val reflect.Value, methodName string) []*Interception {
 	result := []*Interception{}
 	for _, intc := range interceptors {
-		if intc.When != when {
+		if intc.When != when || !strings.HasPrefix(methodName, intc.methodPrefix) {
 			continue
 		}
 
@@ -8,17 +8,17 @@ import (
 var funcP = func(c *Controller) Result { return nil }
 var funcP2 = func(c *Controller) Result { return nil }
 
-type InterceptController struct{ *Controller }
-type InterceptControllerN struct{ InterceptController }
-type InterceptControllerP struct{ *InterceptController }
+type InterceptedController struct{ *Controller }
+type InterceptControllerN struct{ InterceptedController }
+type InterceptControllerP struct{ *InterceptedController }
 type InterceptControllerNP struct {
 	*Controller
 	InterceptControllerN
 	InterceptControllerP
 }
 
-func (c InterceptController) methN() Result  { return nil }
-func (c *InterceptController) methP() Result { return nil }
+func (c InterceptedController) methN() Result  { return nil }
+func (c *InterceptedController) methP() Result { return nil }
 
 func (c InterceptControllerN) methNN() Result  { return nil }
 func (c *InterceptControllerN) methNP() Result { return nil }
@@ -27,16 +27,16 @@ func (c *InterceptControllerP) methPP() Result { return nil }
 
 // Methods accessible from InterceptControllerN
 var METHODS_N = []interface{}{
-	InterceptController.methN,
-	(*InterceptController).methP,
+	InterceptedController.methN,
+	(*InterceptedController).methP,
 	InterceptControllerN.methNN,
 	(*InterceptControllerN).methNP,
 }
 
 // Methods accessible from InterceptControllerP
 var METHODS_P = []interface{}{
-	InterceptController.methN,
-	(*InterceptController).methP,
+	InterceptedController.methN,
+	(*InterceptedController).methP,
 	InterceptControllerP.methPN,
 	(*InterceptControllerP).methPP,
 }
@@ -44,8 +44,8 @@ var METHODS_P = []interface{}{
 // This checks that all the various kinds of interceptor functions/methods are
 // properly invoked.
 func TestInvokeArgType(t *testing.T) {
-	n := InterceptControllerN{InterceptController{&Controller{}}}
-	p := InterceptControllerP{&InterceptController{&Controller{}}}
+	n := InterceptControllerN{InterceptedController{&Controller{}}}
+	p := InterceptControllerP{&InterceptedController{&Controller{}}}
 	np := InterceptControllerNP{&Controller{}, n, p}
 	testInterceptorController(t, reflect.ValueOf(&n), METHODS_N)
 	testInterceptorController(t, reflect.ValueOf(&p), METHODS_P)
@@ -60,7 +60,7 @@ func testInterceptorController(t *testing.T, appControllerPtr reflect.Value, met
 	for _, m := range methods {
 		InterceptMethod(m, BEFORE)
 	}
-	ints := getInterceptors(BEFORE, appControllerPtr)
+	ints := getInterceptors(BEFORE, appControllerPtr, "")
 
 	if len(ints) != 6 {
 		t.Fatalf("N: Expected 6 interceptors, got %d.", len(ints))
@@ -79,3 +79,34 @@ func testInterception(t *testing.T, intc *Interception, arg reflect.Value) {
 		t.Errorf("Failed (%s): Expected nil got %v", intc, val)
 	}
 }
+
+func TestInterceptControllerAndPrefix(t *testing.T) {
+	defer func(saved []*Interception) { interceptors = saved }(interceptors)
+	interceptors = []*Interception{}
+
+	var calls []string
+	record := func(name string) InterceptorFunc {
+		return func(c *Controller) Result {
+			calls = append(calls, name)
+			return nil
+		}
+	}
+	InterceptController(reflect.TypeOf(InterceptedController{}), BEFORE, record("controller"))
+	InterceptMethodPrefix(reflect.TypeOf((*InterceptControllerN)(nil)), "Api", BEFORE, record("prefix"))
+	InterceptMethodPrefix(nil, "Api", AFTER, record("all api"))
+	InterceptController(reflect.TypeOf(InterceptControllerP{}), BEFORE, record("other controller"))
+
+	run := func(methodName string) []string {
+		calls = nil
+		c := &Controller{MethodName: methodName}
+		c.AppController = &InterceptControllerN{InterceptedController{c}}
+		InterceptorFilter(c, NilChain)
+		return calls
+	}
+	if calls := run("ApiList"); !reflect.DeepEqual(calls, []string{"controller", "prefix", "all api"}) {
+		t.Errorf("ApiList: unexpected interceptors %v", calls)
+	}
+	if calls := run("Index"); !reflect.DeepEqual(calls, []string{"controller"}) {
+		t.Errorf("Index: unexpected interceptors %v", calls)
+	}
+}
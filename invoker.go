@@ -1,7 +1,9 @@
 package revel
 
 import (
+	"context"
 	"io"
+	"net/http"
 	"reflect"
 
 	"golang.org/x/net/websocket"
@@ -11,21 +13,50 @@ var (
 	controllerType    = reflect.TypeOf(Controller{})
 	controllerPtrType = reflect.TypeOf(&Controller{})
 	websocketType     = reflect.TypeOf((*websocket.Conn)(nil))
+	httpRequestType   = reflect.TypeOf((*http.Request)(nil))
+	contextType       = reflect.TypeOf((*context.Context)(nil)).Elem()
 )
 
+// injectedArg returns the value of an action argument that is given by the
+// request rather than bound from the params: the websocket connection, the
+// *http.Request, or the request's context.Context (so that the action sees
+// when it is cancelled).  It returns false for other types.
+func injectedArg(c *Controller, typ reflect.Type) (reflect.Value, bool) {
+	switch typ {
+	case websocketType:
+		return reflect.ValueOf(c.Request.Websocket), true
+	case httpRequestType:
+		return reflect.ValueOf(c.Request.Request), true
+	case contextType:
+		return reflect.ValueOf(c.Request.Context()), true
+	}
+	return reflect.Value{}, false
+}
+
+// isInjectedArgType returns true if action arguments of the type are given
+// by the request, see injectedArg.
+func isInjectedArgType(typ reflect.Type) bool {
+	return typ == websocketType || typ == httpRequestType || typ == contextType
+}
+
 func ActionInvoker(c *Controller, _ []Filter) {
 	// Instantiate the method.
 	methodValue := reflect.ValueOf(c.AppController).MethodByName(c.MethodType.Name)
 
 	// Collect the values for the method's arguments.
 	var methodArgs []reflect.Value
+	if c.Params != nil {
+		c.Params.actionArgs = c.Params.actionArgs[:0]
+		for _, arg := range c.MethodType.Args {
+			c.Params.actionArgs = append(c.Params.actionArgs, arg.Name)
+		}
+	}
 	for _, arg := range c.MethodType.Args {
-		// If they accept a websocket connection, treat that arg specially.
-		var boundArg reflect.Value
-		if arg.Type == websocketType {
-			boundArg = reflect.ValueOf(c.Request.Websocket)
-		} else {
-			boundArg = Bind(c.Params, arg.Name, arg.Type)
+		// If they accept a websocket connection, the request or its context,
+		// treat that arg specially.
+		boundArg, injected := injectedArg(c, arg.Type)
+		if !injected {
+			boundArg = c.Params.bindArg(arg.Name, arg.Type)
 			// #756 - If the argument is a closer, defer a Close call,
 			// so we don't risk on leaks.
 			if closer, ok := boundArg.Interface().(io.Closer); ok {
@@ -35,6 +66,16 @@ func ActionInvoker(c *Controller, _ []Filter) {
 		methodArgs = append(methodArgs, boundArg)
 	}
 
+	// Report the required params that were missing, and the invalid IP
+	// addresses.
+	if c.Params != nil && c.Validation != nil {
+		for _, err := range c.Params.bindErrors {
+			if err.Err == ErrParamRequired || err.Err == ErrInvalidIP {
+				c.Validation.Errors = append(c.Validation.Errors, err.validationError())
+			}
+		}
+	}
+
 	var resultValue reflect.Value
 	if methodValue.Type().IsVariadic() {
 		resultValue = methodValue.CallSlice(methodArgs)[0]
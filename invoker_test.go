@@ -1,6 +1,9 @@
 package revel
 
 import (
+	"context"
+	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"reflect"
 	"testing"
@@ -131,3 +134,108 @@ func BenchmarkInvoker(b *testing.B) {
 		ActionInvoker(&c, nil)
 	}
 }
+
+func TestInvokerBindErrors(t *testing.T) {
+	startFakeBookingApp()
+
+	invoke := func(params *Params) *Controller {
+		c := NewController(NewRequest(showRequest), NewResponse(httptest.NewRecorder()))
+		if err := c.SetAction("Hotels", "Show"); err != nil {
+			t.Fatal(err)
+		}
+		c.Params = params
+		ActionInvoker(c, nil)
+		return c
+	}
+
+	if c := invoke(&Params{Values: url.Values{"id": {"3"}}}); len(c.BindErrors()) != 0 {
+		t.Errorf("Expected no bind errors, got %v", c.BindErrors())
+	}
+	if c := invoke(&Params{Values: url.Values{"id": {"0"}}}); len(c.BindErrors()) != 0 {
+		t.Errorf("Expected a zero value to bind, got %v", c.BindErrors())
+	}
+
+	c := invoke(&Params{Values: url.Values{"id": {"three"}}})
+	if errs := c.BindErrors(); len(errs) != 1 {
+		t.Errorf("Expected a bind error for id, got %v", errs)
+	} else {
+		eq(t, "param", errs[0].Param, "id")
+		eq(t, "value", errs[0].Value, "three")
+		eq(t, "type", errs[0].Type, reflect.TypeOf(0))
+	}
+
+	c = invoke(&Params{Values: url.Values{}, JSON: []byte(`{"id": true}`)})
+	if errs := c.BindErrors(); len(errs) != 1 || errs[0].Value != "true" {
+		t.Errorf("Expected a bind error for the JSON id, got %v", errs)
+	}
+
+	// The params the action binds itself are reported too.
+	var page int
+	c.Params.Values.Set("page", "two")
+	c.Params.Bind(&page, "page")
+	if errs := c.BindErrors(); len(errs) != 2 || errs[1].Param != "page" {
+		t.Errorf("Expected a bind error for page, got %v", errs)
+	}
+
+	// So are the fields of a struct that is not zero.
+	var booking struct {
+		Name   string
+		Nights int
+	}
+	c.Params.Values.Set("booking.Name", "Ritz")
+	c.Params.Values.Set("booking.Nights", "three")
+	c.Params.Bind(&booking, "booking")
+	if errs := c.BindErrors(); booking.Name != "Ritz" || len(errs) != 3 || errs[2].Param != "booking.Nights" || errs[2].Field != "Nights" {
+		t.Errorf("Expected a bind error for booking.Nights, got %v", errs)
+	}
+}
+
+// RawHandler takes the request and its context as arguments.
+type RawHandler struct{ *Controller }
+
+var rawHandlerArgs []interface{}
+
+func (c RawHandler) Handle(req *http.Request, ctx context.Context, id int) Result {
+	rawHandlerArgs = []interface{}{req, ctx, id}
+	return nil
+}
+
+func TestInvokerInjectedArgs(t *testing.T) {
+	RegisterController((*RawHandler)(nil), []*MethodType{{
+		Name: "Handle",
+		Args: []*MethodArg{
+			{Name: "req", Type: reflect.TypeOf((**http.Request)(nil))},
+			{Name: "ctx", Type: reflect.TypeOf((*context.Context)(nil))},
+			{Name: "id", Type: reflect.TypeOf((*int)(nil))},
+		},
+	}})
+
+	type key struct{}
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), key{}, "value"))
+	req := showRequest.WithContext(ctx)
+	c := NewController(NewRequest(req), NewResponse(httptest.NewRecorder()))
+	if err := c.SetAction("RawHandler", "Handle"); err != nil {
+		t.Fatal(err)
+	}
+	c.Params = &Params{Values: url.Values{"id": {"3"}, "req": {"x"}, "ctx": {"y"}}}
+	ActionInvoker(c, nil)
+
+	if len(rawHandlerArgs) != 3 {
+		t.Fatalf("Expected the action to be called, got %v", rawHandlerArgs)
+	}
+	if rawHandlerArgs[0].(*http.Request) != req {
+		t.Error("Expected the *http.Request to be injected")
+	}
+	argCtx := rawHandlerArgs[1].(context.Context)
+	if argCtx.Value(key{}) != "value" {
+		t.Error("Expected the request context to be injected")
+	}
+	cancel()
+	if argCtx.Err() != context.Canceled {
+		t.Error("Expected the request cancellation to propagate")
+	}
+	eq(t, "id", rawHandlerArgs[2], 3)
+	if len(c.BindErrors()) != 0 {
+		t.Errorf("Expected no bind errors, got %v", c.BindErrors())
+	}
+}
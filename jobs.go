@@ -0,0 +1,80 @@
+package revel
+
+import (
+	"context"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// backgroundJobs tracks the jobs started by Controller.Go, which the server
+// waits for on shutdown.
+var backgroundJobs sync.WaitGroup
+
+// Go runs fn in a new goroutine once the response has been written, for work
+// that should not delay it (e.g. sending an email or writing an audit log).
+//
+// The context passed to fn carries the values of the request context (such as
+// the trace span) but is not canceled when the request completes.  On
+// shutdown, the server waits up to "server.draintimeout" for outstanding jobs
+// to finish.  A panic in fn is logged.
+func (c *Controller) Go(fn func(ctx context.Context)) {
+	c.jobs = append(c.jobs, fn)
+}
+
+// startJobs starts the jobs scheduled with Go.
+func (c *Controller) startJobs() {
+	if len(c.jobs) == 0 {
+		return
+	}
+	ctx := detachedContext{c.Request.Context()}
+	for _, fn := range c.jobs {
+		backgroundJobs.Add(1)
+		go runJob(ctx, fn)
+	}
+	c.jobs = nil
+}
+
+func runJob(ctx context.Context, fn func(ctx context.Context)) {
+	defer backgroundJobs.Done()
+	defer func() {
+		if err := recover(); err != nil {
+			ERROR.Print("Background job panicked: ", err, "\n", string(debug.Stack()))
+		}
+	}()
+	fn(ctx)
+}
+
+// waitForJobs waits for the outstanding jobs to finish, or for the timeout to
+// elapse if it is positive.  Returns false on timeout.
+func waitForJobs(timeout time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		backgroundJobs.Wait()
+		close(done)
+	}()
+	if timeout <= 0 {
+		<-done
+		return true
+	}
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// detachedContext keeps the values of its parent but not its deadline or
+// cancelation.
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}       { return nil }
+func (detachedContext) Err() error                  { return nil }
+
+func (ctx detachedContext) Value(key interface{}) interface{} {
+	return ctx.parent.Value(key)
+}
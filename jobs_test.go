@@ -0,0 +1,54 @@
+package revel
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type jobContextKey struct{}
+
+func TestControllerGo(t *testing.T) {
+	startFakeBookingApp()
+
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), jobContextKey{}, "trace"))
+	req, _ := http.NewRequest("GET", "/hotels", nil)
+	c := NewController(NewRequest(req.WithContext(ctx)), NewResponse(httptest.NewRecorder()))
+
+	done := make(chan context.Context, 1)
+	c.Go(func(ctx context.Context) {
+		done <- ctx
+	})
+	c.Go(func(ctx context.Context) {
+		panic("job failure")
+	})
+	select {
+	case <-done:
+		t.Fatal("Expected the job to wait for the response")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	c.startJobs()
+	cancel()
+	jobCtx := <-done
+	if jobCtx.Value(jobContextKey{}) != "trace" {
+		t.Error("Expected the job context to carry the request values")
+	}
+	if jobCtx.Err() != nil || jobCtx.Done() != nil {
+		t.Error("Expected the job context not to be canceled with the request")
+	}
+	if !waitForJobs(time.Second) {
+		t.Error("Expected the jobs to finish")
+	}
+
+	block := make(chan struct{})
+	c.Go(func(ctx context.Context) { <-block })
+	c.startJobs()
+	if waitForJobs(10 * time.Millisecond) {
+		t.Error("Expected waiting for a blocked job to time out")
+	}
+	close(block)
+	waitForJobs(0)
+}
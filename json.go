@@ -0,0 +1,365 @@
+package revel
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+
+// JsonOptions control how RenderJson encodes its result.
+type JsonOptions struct {
+	// EscapeHTML escapes <, > and & within strings (encoding/json's default).
+	EscapeHTML bool
+
+	// Indent is the indentation used for each nesting level.  An empty
+	// Indent produces compact output.
+	Indent string
+
+	// Int64AsString encodes int, int64, uint, uint64 and uintptr values as
+	// JSON strings, so that clients storing numbers as doubles (e.g.
+	// JavaScript) do not lose precision on large values.  Values encoded by
+	// their own MarshalJSON or MarshalText methods are left alone.
+	Int64AsString bool
+}
+
+// DefaultJsonOptions returns the options configured in app.conf:
+//
+//	results.pretty             - indent with two spaces (default false)
+//	results.json.escapehtml    - escape HTML characters (default true)
+//	results.json.int64asstring - encode 64-bit integers as strings (default false)
+func DefaultJsonOptions() JsonOptions {
+	opts := JsonOptions{
+		EscapeHTML:    CurrentConfig().BoolDefault("results.json.escapehtml", true),
+		Int64AsString: CurrentConfig().BoolDefault("results.json.int64asstring", false),
+	}
+	if CurrentConfig().BoolDefault("results.pretty", false) {
+		opts.Indent = "  "
+	}
+	return opts
+}
+
+// encodeJson encodes o according to the given options.
+func encodeJson(o interface{}, opts JsonOptions) ([]byte, error) {
+	if opts.Int64AsString {
+		var err error
+		if o, err = int64sToStrings(o); err != nil {
+			return nil, err
+		}
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(opts.EscapeHTML)
+	enc.SetIndent("", opts.Indent)
+	if err := enc.Encode(o); err != nil {
+		return nil, err
+	}
+	// Encoder terminates each value with a newline, which Marshal does not.
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// int64String and uint64String are encoded as JSON strings.  They replace
+// the 64-bit integers of the values encoded with Int64AsString.
+type (
+	int64String  int64
+	uint64String uint64
+)
+
+func (i int64String) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + strconv.FormatInt(int64(i), 10) + `"`), nil
+}
+
+func (i uint64String) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + strconv.FormatUint(uint64(i), 10) + `"`), nil
+}
+
+var (
+	int64StringType  = reflect.TypeOf(int64String(0))
+	uint64StringType = reflect.TypeOf(uint64String(0))
+	interfaceType    = reflect.TypeOf((*interface{})(nil)).Elem()
+)
+
+// int64sToStrings returns a copy of o in which every int, int64, uint, uint64
+// and uintptr is replaced by an int64String or uint64String, so that
+// encoding/json encodes them as strings.  The copy is of a mirror type of o
+// (see jsonMirror), so that encoding/json applies its own rules to everything
+// else: the tags and their options, embedded fields, map keys and marshalers.
+func int64sToStrings(o interface{}) (interface{}, error) {
+	v := reflect.ValueOf(o)
+	if !v.IsValid() {
+		return o, nil
+	}
+	copied, err := jsonMirrorOf(v.Type()).copy(v, 0)
+	if err != nil {
+		return nil, err
+	}
+	return copied.Interface(), nil
+}
+
+// jsonMirror describes the type that the values of another type are copied
+// to for Int64AsString: the same type with the integers replaced, and structs
+// reduced to the fields that encoding/json encodes.
+type jsonMirror struct {
+	typ     reflect.Type
+	convert bool          // False if the values are used as they are.
+	dynamic bool          // True if the values are copied by their dynamic type.
+	elem    *jsonMirror   // Of pointers, slices, arrays and maps.
+	fields  []mirrorField // Of structs.
+}
+
+// mirrorField is a field of a struct mirror type.
+type mirrorField struct {
+	index     int // Of the field in the original struct.
+	omitEmpty bool
+	mirror    *jsonMirror
+}
+
+var (
+	// jsonMirrors caches the mirrors by type.
+	jsonMirrors = make(map[reflect.Type]*jsonMirror)
+	// dynamicJsonMirror copies interfaces, and the structs that refer to
+	// themselves, which reflect can not build mirror types of.
+	dynamicJsonMirror = &jsonMirror{typ: interfaceType, convert: true, dynamic: true}
+	jsonMirrorsLock   sync.Mutex
+)
+
+// jsonMirrorOf returns the mirror of the given type.
+func jsonMirrorOf(t reflect.Type) *jsonMirror {
+	jsonMirrorsLock.Lock()
+	defer jsonMirrorsLock.Unlock()
+	return buildJsonMirror(t, make(map[reflect.Type]bool), false)
+}
+
+// isJsonMarshaler returns true if encoding/json encodes the values of the
+// type, or pointers to them, with their own methods.
+func isJsonMarshaler(t reflect.Type) bool {
+	ptr := reflect.PtrTo(t)
+	return t.Implements(jsonMarshalerType) || ptr.Implements(jsonMarshalerType) ||
+		t.Implements(textMarshalerType) || ptr.Implements(textMarshalerType)
+}
+
+// buildJsonMirror returns the mirror of t, building it if it is not cached.
+// The structs being built are set in building.  The mirrors of flattened
+// embedded structs are always built, as reflect only embeds types without
+// methods, and not cached.
+func buildJsonMirror(t reflect.Type, building map[reflect.Type]bool, flatten bool) *jsonMirror {
+	if mirror, ok := jsonMirrors[t]; ok && !flatten {
+		return mirror
+	}
+	if building[t] {
+		return dynamicJsonMirror
+	}
+
+	mirror := &jsonMirror{typ: t}
+	switch {
+	case isJsonMarshaler(t):
+	case t.Kind() == reflect.Int, t.Kind() == reflect.Int64:
+		mirror = &jsonMirror{typ: int64StringType, convert: true}
+	case t.Kind() == reflect.Uint, t.Kind() == reflect.Uint64, t.Kind() == reflect.Uintptr:
+		mirror = &jsonMirror{typ: uint64StringType, convert: true}
+	case t.Kind() == reflect.Interface:
+		mirror = dynamicJsonMirror
+	case t.Kind() == reflect.Ptr:
+		elem := buildJsonMirror(t.Elem(), building, flatten)
+		if elem.dynamic {
+			mirror = dynamicJsonMirror
+		} else if elem.convert {
+			mirror = &jsonMirror{typ: reflect.PtrTo(elem.typ), convert: true, elem: elem}
+		}
+	case t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 && !isJsonMarshaler(t.Elem()):
+		// Encoded as base64.
+	case t.Kind() == reflect.Slice, t.Kind() == reflect.Array, t.Kind() == reflect.Map:
+		elem := buildJsonMirror(t.Elem(), building, false)
+		if elem.convert {
+			mirror = &jsonMirror{convert: true, elem: elem}
+			switch t.Kind() {
+			case reflect.Slice:
+				mirror.typ = reflect.SliceOf(elem.typ)
+			case reflect.Array:
+				mirror.typ = reflect.ArrayOf(t.Len(), elem.typ)
+			case reflect.Map:
+				mirror.typ = reflect.MapOf(t.Key(), elem.typ)
+			}
+		}
+	case t.Kind() == reflect.Struct:
+		building[t] = true
+		mirror = buildJsonStructMirror(t, building, flatten)
+		delete(building, t)
+	}
+	if !flatten {
+		jsonMirrors[t] = mirror
+	}
+	return mirror
+}
+
+// buildJsonStructMirror builds the mirror of the struct t, with the fields
+// that encoding/json encodes, in the same order.
+func buildJsonStructMirror(t reflect.Type, building map[reflect.Type]bool, flatten bool) (mirror *jsonMirror) {
+	mirror = &jsonMirror{convert: flatten}
+	var fields []reflect.StructField
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts := tag, ""
+		if comma := strings.Index(tag, ","); comma != -1 {
+			name, opts = tag[:comma], tag[comma+1:]
+		}
+		typ := field.Type
+		if typ.Kind() == reflect.Ptr {
+			typ = typ.Elem()
+		}
+
+		var fieldMirror *jsonMirror
+		if field.Anonymous && name == "" && typ.Kind() == reflect.Struct {
+			// Flattened by encoding/json, even if unexported.
+			fieldMirror = buildJsonMirror(field.Type, building, true)
+		} else if field.PkgPath != "" && (!field.Anonymous || typ.Kind() != reflect.Struct) {
+			continue
+		} else {
+			// Other embedded fields are encoded as any other field, under
+			// their name.
+			fieldMirror = buildJsonMirror(field.Type, building, false)
+			field.Anonymous = false
+		}
+		if fieldMirror.dynamic && field.Anonymous {
+			// A struct embedding itself, which is left as it is.
+			return &jsonMirror{typ: t}
+		}
+		mirror.convert = mirror.convert || fieldMirror.convert
+		mirror.fields = append(mirror.fields, mirrorField{i, strings.Contains(","+opts+",", ",omitempty,"), fieldMirror})
+
+		// reflect.StructOf only takes exported fields.  encoding/json does
+		// not use the names of the unexported fields it encodes: they are
+		// either flattened or tagged.
+		if field.PkgPath != "" {
+			field.Name = "X" + field.Name
+			field.PkgPath = ""
+		}
+		field.Type, field.Index, field.Offset = fieldMirror.typ, nil, 0
+		fields = append(fields, field)
+	}
+	if !mirror.convert {
+		return &jsonMirror{typ: t}
+	}
+
+	// reflect.StructOf panics on the structs it can not build, e.g. with
+	// fields named alike once exported, which are left as they are.
+	defer func() {
+		if recover() != nil {
+			mirror = &jsonMirror{typ: t}
+		}
+	}()
+	mirror.typ = reflect.StructOf(fields)
+	return mirror
+}
+
+// maxJsonMirrorDepth is how deep values are copied before they are taken to
+// be cyclic, as encoding/json does with pointers.
+const maxJsonMirrorDepth = 1000
+
+// copy returns a copy of v, of the mirror type.
+func (m *jsonMirror) copy(v reflect.Value, depth int) (reflect.Value, error) {
+	if !m.convert {
+		return v, nil
+	}
+	if depth++; depth > maxJsonMirrorDepth {
+		return reflect.Value{}, &json.UnsupportedValueError{Value: v, Str: "encountered a cycle via " + v.Type().String()}
+	}
+
+	copied := reflect.New(m.typ).Elem()
+	switch {
+	case m.typ == int64StringType:
+		copied.SetInt(v.Int())
+	case m.typ == uint64StringType:
+		copied.SetUint(v.Uint())
+	case m.dynamic:
+		if v.Kind() == reflect.Interface {
+			if v.IsNil() {
+				return copied, nil
+			}
+			v = v.Elem()
+		}
+		elem, err := jsonMirrorOf(v.Type()).copy(v, depth)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		copied.Set(elem)
+	case v.Kind() == reflect.Ptr:
+		if v.IsNil() {
+			return copied, nil
+		}
+		elem, err := m.elem.copy(v.Elem(), depth)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		copied.Set(reflect.New(m.typ.Elem()))
+		copied.Elem().Set(elem)
+	case v.Kind() == reflect.Slice, v.Kind() == reflect.Array:
+		if v.Kind() == reflect.Slice {
+			if v.IsNil() {
+				return copied, nil
+			}
+			copied.Set(reflect.MakeSlice(m.typ, v.Len(), v.Len()))
+		}
+		for i := 0; i < v.Len(); i++ {
+			elem, err := m.elem.copy(v.Index(i), depth)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			copied.Index(i).Set(elem)
+		}
+	case v.Kind() == reflect.Map:
+		if v.IsNil() {
+			return copied, nil
+		}
+		copied.Set(reflect.MakeMapWithSize(m.typ, v.Len()))
+		for iter := v.MapRange(); iter.Next(); {
+			elem, err := m.elem.copy(iter.Value(), depth)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			copied.SetMapIndex(iter.Key(), elem)
+		}
+	case v.Kind() == reflect.Struct:
+		for i, field := range m.fields {
+			value := v.Field(field.index)
+			if field.omitEmpty && isEmptyJsonValue(value) {
+				// Left zero, so omitted too, even as an interface.
+				continue
+			}
+			elem, err := field.mirror.copy(value, depth)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			copied.Field(i).Set(elem)
+		}
+	}
+	return copied, nil
+}
+
+// isEmptyJsonValue reports whether v would be omitted by "omitempty".
+func isEmptyJsonValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
@@ -0,0 +1,111 @@
+package revel
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"strconv"
+)
+
+// unmarshalJSON decodes the JSON document into "dest" like json.Unmarshal,
+// keeping numbers decoded into interface{} values as json.Number if useNumber
+// is set.
+func unmarshalJSON(data []byte, dest interface{}, useNumber bool) error {
+	if !useNumber {
+		return json.Unmarshal(data, dest)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(dest); err != nil {
+		return err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return errors.New("revel/params: invalid data after JSON document")
+	}
+	return nil
+}
+
+// unmarshalJSONLenient decodes the JSON document into "dest" like
+// unmarshalJSON, but first converts scalars to the kinds of the fields they
+// are decoded into: numbers and bools to strings for string fields, and
+// strings to numbers or bools for numeric and bool fields.  So an ID may be
+// sent either as 123 or as "123".
+func unmarshalJSONLenient(data []byte, dest interface{}, useNumber bool) error {
+	var doc interface{}
+	if err := unmarshalJSON(data, &doc, true); err != nil {
+		return err
+	}
+
+	coerced, err := json.Marshal(coerceJSON(doc, reflect.TypeOf(dest)))
+	if err != nil {
+		return err
+	}
+	return unmarshalJSON(coerced, dest, useNumber)
+}
+
+// coerceJSON converts the scalars of a JSON document, decoded with UseNumber,
+// to suit the given type.  Types that unmarshal themselves are left alone.
+func coerceJSON(doc interface{}, typ reflect.Type) interface{} {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if reflect.PtrTo(typ).Implements(jsonUnmarshalerType) {
+		return doc
+	}
+
+	switch v := doc.(type) {
+	case map[string]interface{}:
+		switch typ.Kind() {
+		case reflect.Struct:
+			for key, elem := range v {
+				if name, ok := jsonFieldName(typ, key); ok {
+					field, _ := typ.FieldByName(name)
+					v[key] = coerceJSON(elem, field.Type)
+				}
+			}
+		case reflect.Map:
+			for key, elem := range v {
+				v[key] = coerceJSON(elem, typ.Elem())
+			}
+		}
+
+	case []interface{}:
+		if typ.Kind() == reflect.Slice || typ.Kind() == reflect.Array {
+			for i, elem := range v {
+				v[i] = coerceJSON(elem, typ.Elem())
+			}
+		}
+
+	case json.Number:
+		switch typ.Kind() {
+		case reflect.String:
+			return string(v)
+		case reflect.Bool:
+			if b, err := strconv.ParseBool(string(v)); err == nil {
+				return b
+			}
+		}
+
+	case string:
+		switch typ.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+			reflect.Float32, reflect.Float64:
+			if _, err := strconv.ParseFloat(v, 64); err == nil && json.Valid([]byte(v)) {
+				return json.Number(v)
+			}
+		case reflect.Bool:
+			if b, err := strconv.ParseBool(v); err == nil {
+				return b
+			}
+		}
+
+	case bool:
+		if typ.Kind() == reflect.String {
+			return strconv.FormatBool(v)
+		}
+	}
+	return doc
+}
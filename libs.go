@@ -5,22 +5,48 @@ import (
 	"crypto/sha1"
 	"encoding/hex"
 	"io"
+	"strings"
 )
 
 // Sign a given string with the app-configured secret key.
 // If no secret key is set, returns the empty string.
 // Return the signature in base64 (URLEncoding).
 func Sign(message string) string {
-	if len(secretKey) == 0 {
+	if len(secretKeys) == 0 {
 		return ""
 	}
-	mac := hmac.New(sha1.New, secretKey)
-	io.WriteString(mac, message)
-	return hex.EncodeToString(mac.Sum(nil))
+	return signWith(secretKeys[0], message)
 }
 
 // Verify returns true if the given signature is correct for the given message.
 // e.g. it matches what we generate with Sign()
+// Signatures made with any of the previous secrets listed in "app.secrets"
+// are also accepted, so that rotating the secret does not invalidate them.
 func Verify(message, sig string) bool {
-	return hmac.Equal([]byte(sig), []byte(Sign(message)))
+	if len(secretKeys) == 0 {
+		return sig == ""
+	}
+	for _, key := range secretKeys {
+		if hmac.Equal([]byte(sig), []byte(signWith(key, message))) {
+			return true
+		}
+	}
+	return false
+}
+
+func signWith(key []byte, message string) string {
+	mac := hmac.New(sha1.New, key)
+	io.WriteString(mac, message)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// parseSecretKeys splits a comma separated list of secrets, current first.
+func parseSecretKeys(secrets string) [][]byte {
+	var keys [][]byte
+	for _, secret := range strings.Split(secrets, ",") {
+		if secret = strings.TrimSpace(secret); secret != "" {
+			keys = append(keys, []byte(secret))
+		}
+	}
+	return keys
 }
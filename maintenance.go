@@ -0,0 +1,92 @@
+package revel
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// maintenanceMode is set while the application is down for maintenance.  It
+// starts out as "maintenance.enabled" and is toggled by SetMaintenanceMode, or
+// by changing "maintenance.enabled" and reloading the config.
+var maintenanceMode atomic.Bool
+
+func init() {
+	OnAppStart(func() {
+		maintenanceMode.Store(CurrentConfig().BoolDefault("maintenance.enabled", false))
+	})
+	OnConfigReload(func(changed map[string]bool) error {
+		if changed["maintenance.enabled"] {
+			SetMaintenanceMode(CurrentConfig().BoolDefault("maintenance.enabled", false))
+		}
+		return nil
+	})
+}
+
+// SetMaintenanceMode turns maintenance mode on or off, e.g. from an admin
+// action or a deploy hook, without restarting the application.
+func SetMaintenanceMode(enabled bool) {
+	if maintenanceMode.Swap(enabled) != enabled {
+		INFO.Println("Maintenance mode enabled:", enabled)
+	}
+}
+
+// InMaintenanceMode returns true while maintenance mode is on.
+func InMaintenanceMode() bool {
+	return maintenanceMode.Load()
+}
+
+// MaintenanceResult returns the result of the requests rejected in
+// maintenance mode.  By default it renders the 503 error template with
+// "maintenance.message".  The status is set to 503 and the Retry-After
+// header beforehand.
+var MaintenanceResult = func(c *Controller) Result {
+	return c.RenderError(&Error{
+		Title:       "Service Unavailable",
+		Description: CurrentConfig().StringDefault("maintenance.message", "The site is down for maintenance."),
+	})
+}
+
+// MaintenanceFilter rejects requests with 503 Service Unavailable while in
+// maintenance mode (see SetMaintenanceMode), except for the paths listed in
+// "maintenance.allow" (comma separated), which should include the health
+// checks.  A path ending with "*" allows every path with that prefix, e.g.
+// "/admin/*".  The Retry-After header is set to "maintenance.retryafter"
+// seconds (default 300, 0 to leave it out).
+func MaintenanceFilter(c *Controller, fc []Filter) {
+	if !InMaintenanceMode() || maintenanceAllowed(c.Request.URL.Path) {
+		fc[0](c, fc[1:])
+		return
+	}
+
+	if retryAfter := CurrentConfig().IntDefault("maintenance.retryafter", 300); retryAfter > 0 {
+		c.Response.Out.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+	}
+	c.Response.Status = http.StatusServiceUnavailable
+	c.Result = MaintenanceResult(c)
+}
+
+// maintenanceAllowed returns true if the path is served in maintenance mode.
+func maintenanceAllowed(path string) bool {
+	return pathListed(CurrentConfig().StringDefault("maintenance.allow", ""), path)
+}
+
+// pathListed returns true if the path is in the comma separated list of
+// paths, where a path ending with "*" matches every path with that prefix.
+func pathListed(list, path string) bool {
+	for _, allowed := range strings.Split(list, ",") {
+		allowed = strings.TrimSpace(allowed)
+		if allowed == "" {
+			continue
+		}
+		if prefix := strings.TrimSuffix(allowed, "*"); prefix != allowed {
+			if strings.HasPrefix(path, prefix) {
+				return true
+			}
+		} else if path == allowed {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,48 @@
+package revel
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMaintenanceFilter(t *testing.T) {
+	startFakeBookingApp()
+	defer SetMaintenanceMode(false)
+
+	run := func(path string) (*httptest.ResponseRecorder, bool) {
+		req, _ := http.NewRequest("GET", path, nil)
+		resp := httptest.NewRecorder()
+		c := NewController(NewRequest(req), NewResponse(resp))
+		called := false
+		MaintenanceFilter(c, []Filter{func(c *Controller, fc []Filter) { called = true }})
+		if c.Result != nil {
+			c.Result.Apply(c.Request, c.Response)
+		}
+		return resp, called
+	}
+
+	if _, called := run("/hotels"); !called {
+		t.Error("Expected requests to be served outside of maintenance mode")
+	}
+
+	Config.SetOption("maintenance.allow", "/healthz, /admin/*")
+	SetMaintenanceMode(true)
+	resp, called := run("/hotels")
+	if called || resp.Code != http.StatusServiceUnavailable || resp.Header().Get("Retry-After") != "300" {
+		t.Errorf("Expected a 503 with Retry-After, got %d (Retry-After %q, called: %v)", resp.Code, resp.Header().Get("Retry-After"), called)
+	}
+	for _, path := range []string{"/healthz", "/admin/maintenance"} {
+		if _, called := run(path); !called {
+			t.Errorf("Expected %s to be served in maintenance mode", path)
+		}
+	}
+	if _, called := run("/healthz/deep"); called {
+		t.Error("Expected only the exact allowed path to be served")
+	}
+
+	SetMaintenanceMode(false)
+	if _, called := run("/hotels"); !called {
+		t.Error("Expected requests to be served once maintenance mode is off")
+	}
+}
@@ -0,0 +1,151 @@
+package revel
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+)
+
+var jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// ApplyMergePatch applies the JSON request body onto the value pointed to by
+// "target" as a JSON merge patch (RFC 7386): the members of the patch object
+// are merged recursively into the existing structs and maps, a null member
+// deletes the map key (or zeroes the struct field) and any other value,
+// arrays included, replaces the existing one wholesale.  Struct fields are
+// matched to members in the same way as encoding/json.
+//
+// The patch is merged into a copy of the value, which replaces it only if the
+// whole patch applied: on error the value, and those it points to, are left
+// as they were.
+//
+// Where BindJSON replaces the whole value, ApplyMergePatch is meant for PATCH:
+//
+//	hotel := loadHotel(id)
+//	if err := c.Params.ApplyMergePatch(hotel); err != nil {
+//	  return c.RenderError(err)
+//	}
+func (p *Params) ApplyMergePatch(target interface{}) error {
+	value := reflect.ValueOf(target)
+	if value.Kind() != reflect.Ptr || value.IsNil() {
+		return errors.New("revel/params: ApplyMergePatch requires a non-nil pointer")
+	}
+	if p.jsonIncomplete && paramsJSONPartial == "reject" {
+		return ErrIncompleteBody
+	}
+	if len(p.JSON) == 0 {
+		return errors.New("revel/params: no JSON body to merge")
+	}
+	merged := reflect.New(value.Elem().Type()).Elem()
+	merged.Set(value.Elem())
+	if err := mergePatch(merged, renameJSONFields(p.JSON, merged.Type(), p.fieldNamer())); err != nil {
+		return err
+	}
+	value.Elem().Set(merged)
+	return nil
+}
+
+// mergePatch merges the patch into dest, which must be settable.  The values
+// dest points to are not modified: pointers and maps are replaced by merged
+// copies.
+func mergePatch(dest reflect.Value, patch []byte) error {
+	var members map[string]json.RawMessage
+	if !isJSONObject(patch) || json.Unmarshal(patch, &members) != nil {
+		return replaceJSON(dest, patch)
+	}
+	if dest.CanAddr() && dest.Addr().Type().Implements(jsonUnmarshalerType) {
+		return replaceJSON(dest, patch)
+	}
+
+	switch dest.Kind() {
+	case reflect.Ptr:
+		elem := reflect.New(dest.Type().Elem())
+		if !dest.IsNil() {
+			elem.Elem().Set(dest.Elem())
+		}
+		if err := mergePatch(elem.Elem(), patch); err != nil {
+			return err
+		}
+		dest.Set(elem)
+		return nil
+
+	case reflect.Struct:
+		for key, raw := range members {
+			name, ok := jsonFieldName(dest.Type(), key)
+			if !ok {
+				continue
+			}
+			field := dest.FieldByName(name)
+			if isJSONNull(raw) {
+				field.Set(reflect.Zero(field.Type()))
+			} else if err := mergePatch(field, raw); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case reflect.Map:
+		if dest.Type().Key().Kind() != reflect.String {
+			return replaceJSON(dest, patch)
+		}
+		merged := reflect.MakeMapWithSize(dest.Type(), dest.Len()+len(members))
+		for iter := dest.MapRange(); iter.Next(); {
+			merged.SetMapIndex(iter.Key(), iter.Value())
+		}
+		for key, raw := range members {
+			mapKey := reflect.ValueOf(key).Convert(dest.Type().Key())
+			if isJSONNull(raw) {
+				merged.SetMapIndex(mapKey, reflect.Value{})
+				continue
+			}
+			// Map elements are not addressable, so merge into a copy.
+			elem := reflect.New(dest.Type().Elem()).Elem()
+			if existing := merged.MapIndex(mapKey); existing.IsValid() {
+				elem.Set(existing)
+			}
+			if err := mergePatch(elem, raw); err != nil {
+				return err
+			}
+			merged.SetMapIndex(mapKey, elem)
+		}
+		dest.Set(merged)
+		return nil
+
+	case reflect.Interface:
+		if dest.NumMethod() > 0 {
+			return replaceJSON(dest, patch)
+		}
+		// A non-object is merged as if it were an empty object.
+		obj, ok := dest.Interface().(map[string]interface{})
+		if !ok || obj == nil {
+			obj = make(map[string]interface{})
+		}
+		if err := mergePatch(reflect.ValueOf(&obj).Elem(), patch); err != nil {
+			return err
+		}
+		dest.Set(reflect.ValueOf(obj))
+		return nil
+	}
+	return replaceJSON(dest, patch)
+}
+
+// replaceJSON decodes the JSON value into a new value of the type of dest,
+// which replaces it.
+func replaceJSON(dest reflect.Value, value []byte) error {
+	replacement := reflect.New(dest.Type())
+	if err := json.Unmarshal(value, replacement.Interface()); err != nil {
+		return err
+	}
+	dest.Set(replacement.Elem())
+	return nil
+}
+
+func isJSONObject(value []byte) bool {
+	value = bytes.TrimSpace(value)
+	return len(value) > 0 && value[0] == '{'
+}
+
+func isJSONNull(value []byte) bool {
+	return bytes.Equal(bytes.TrimSpace(value), []byte("null"))
+}
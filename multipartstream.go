@@ -0,0 +1,182 @@
+package revel
+
+import (
+	"errors"
+	"io"
+	"mime/multipart"
+)
+
+// ErrMultipartParsed is returned by ParseMultipart when the multipart body
+// was parsed by the ParamsFilter, i.e. "params.multipart.stream" is not set
+// for the action.
+var ErrMultipartParsed = errors.New("revel/params: multipart body already parsed")
+
+// multipartMaxMemory is how much of a multipart form is kept in memory, the
+// rest of the files being written to temp files.
+const multipartMaxMemory = 32 << 20 // 32 MB
+
+// StreamFileTo makes ParseMultipart copy the files uploaded in the given form
+// field to w as they are read, instead of keeping them in memory or a temp
+// file, e.g. to pass a large upload on to another server.  The files are not
+// added to Files.  Several files uploaded in the same field are written to w
+// one after the other.
+//
+// The body of a multipart request is only left unparsed by the ParamsFilter
+// if "params.multipart.stream" is set for the action, e.g.
+// "params.multipart.stream.Uploads.Proxy = true".
+func (p *Params) StreamFileTo(field string, w io.Writer) {
+	if p.fileSinks == nil {
+		p.fileSinks = make(map[string]io.Writer)
+	}
+	p.fileSinks[field] = w
+}
+
+// ParseMultipart parses the multipart request body that the ParamsFilter left
+// unparsed, streaming the files of the fields registered with StreamFileTo to
+// their writers.  The other fields are parsed as usual into Form, Files and
+// Values.  As the action arguments are bound before the body is parsed, the
+// form values must be read from the Params, e.g.
+//
+//	func (c Uploads) Proxy() revel.Result {
+//	  pw := upstream.Writer()
+//	  c.Params.StreamFileTo("file", pw)
+//	  if err := c.Params.ParseMultipart(); err != nil {
+//	    return c.RenderError(err)
+//	  }
+//	  name := c.Params.Get("name")
+//	  ...
+//	}
+//
+// It returns ErrMultipartParsed if there is no unparsed body.
+func (p *Params) ParseMultipart() error {
+	req := p.multipartRequest
+	if req == nil {
+		return ErrMultipartParsed
+	}
+	p.multipartRequest = nil
+
+	form, err := p.readStreamedMultipart(req)
+	if form != nil {
+		// Removed with the temp files of any other multipart form, by the
+		// ParamsFilter.
+		req.MultipartForm = form
+	}
+	if err != nil {
+		return err
+	}
+	p.Form = form.Value
+	p.Files = form.File
+	if err := checkParamsUTF8(p); err != nil {
+		return err
+	}
+	p.Values = transformParams(p.calcValues())
+	return nil
+}
+
+// parseMultipartForm parses the multipart request body as
+// Request.ParseMultipartForm does, but stops reading it as soon as it has more
+// than "params.form.maxkeys" distinct keys or a value longer than
+// "params.form.maxvaluelen".
+func (p *Params) parseMultipartForm(req *Request) error {
+	if paramsFormMaxKeys <= 0 && paramsFormMaxValueLen <= 0 {
+		return req.ParseMultipartForm(multipartMaxMemory)
+	}
+	form, err := p.readStreamedMultipart(req)
+	if form != nil {
+		req.MultipartForm = form
+	}
+	if err != nil {
+		return err
+	}
+	setRequestForm(req, form.Value, p.Query)
+	return nil
+}
+
+// readStreamedMultipart copies the parts of the request body to their
+// registered writers, and the rest to a multipart reader that parses them
+// as usual.  It gives up with ErrTooManyParams as soon as the form values
+// have more than "params.form.maxkeys" distinct keys, and applies
+// "params.form.maxvaluelen" to the values as they are read.
+func (p *Params) readStreamedMultipart(req *Request) (*multipart.Form, error) {
+	reader, err := req.MultipartReader()
+	if err != nil {
+		return nil, err
+	}
+
+	pr, pw := io.Pipe()
+	writer := multipart.NewWriter(pw)
+	type formResult struct {
+		form *multipart.Form
+		err  error
+	}
+	parsed := make(chan formResult, 1)
+	go func() {
+		form, err := multipart.NewReader(pr, writer.Boundary()).ReadForm(multipartMaxMemory)
+		// Unblocks the copy below if the form is rejected before its end.
+		pr.CloseWithError(err)
+		parsed <- formResult{form, err}
+	}()
+
+	copyErr := func() error {
+		keys := make(map[string]bool)
+		for {
+			part, err := reader.NextPart()
+			if err == io.EOF {
+				return writer.Close()
+			}
+			if err != nil {
+				return err
+			}
+			if sink, ok := p.fileSinks[part.FormName()]; ok && part.FileName() != "" {
+				if _, err := io.Copy(sink, part); err != nil {
+					return err
+				}
+				continue
+			}
+			if name := part.FormName(); name != "" && part.FileName() == "" && !keys[name] {
+				if paramsFormMaxKeys > 0 && len(keys) == paramsFormMaxKeys {
+					return ErrTooManyParams
+				}
+				keys[name] = true
+			}
+			dst, err := writer.CreatePart(part.Header)
+			if err != nil {
+				return err
+			}
+			if part.FileName() == "" && paramsFormMaxValueLen > 0 {
+				err = copyFormValue(dst, part)
+			} else {
+				_, err = io.Copy(dst, part)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}()
+	pw.CloseWithError(copyErr)
+
+	result := <-parsed
+	if copyErr != nil {
+		return result.form, copyErr
+	}
+	return result.form, result.err
+}
+
+// copyFormValue copies a form value to w, applying "params.form.maxvaluelen"
+// without reading more than one byte past the limit.
+func copyFormValue(w io.Writer, part io.Reader) error {
+	value, err := io.ReadAll(io.LimitReader(part, int64(paramsFormMaxValueLen)+1))
+	if err != nil {
+		return err
+	}
+	limited, err := limitFormValue(string(value), paramsFormMaxValueLen)
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, limited); err != nil {
+		return err
+	}
+	// The rest of a truncated value.
+	_, err = io.Copy(io.Discard, part)
+	return err
+}
@@ -0,0 +1,69 @@
+package revel
+
+import (
+	"context"
+	"sync"
+)
+
+type onceContextKey struct{}
+
+// requestMemo holds the values memoized by Controller.Once for a request.
+type requestMemo struct {
+	sync.Mutex
+	values map[string]*memoValue
+	done   bool // Set once the request has been served.
+}
+
+type memoValue struct {
+	once  sync.Once
+	value interface{}
+}
+
+// withRequestMemo attaches a new memo for Controller.Once to the context of
+// the request.
+func withRequestMemo(req *Request) *requestMemo {
+	memo := &requestMemo{values: make(map[string]*memoValue)}
+	req.Request = req.WithContext(context.WithValue(req.Context(), onceContextKey{}, memo))
+	return memo
+}
+
+// clear drops the memoized values once the request has been served.
+func (memo *requestMemo) clear() {
+	memo.Lock()
+	memo.values, memo.done = nil, true
+	memo.Unlock()
+}
+
+// Once returns the value computed by fn for the given key, calling fn only
+// the first time the key is requested during the request.  It is meant for
+// values that several filters or templates need, e.g.
+//
+//	perms := c.Once("permissions", func() interface{} {
+//		return loadPermissions(c.Session["user"])
+//	}).(Permissions)
+//
+// The values are dropped when the request has been served.  Goroutines that
+// outlive the request get a freshly computed value, never one cached for (or
+// by) a later use.  Concurrent calls for the same key wait for the first one;
+// fn must not call Once with its own key.
+func (c *Controller) Once(key string, fn func() interface{}) interface{} {
+	memo, ok := c.Request.Context().Value(onceContextKey{}).(*requestMemo)
+	if !ok {
+		memo = withRequestMemo(c.Request)
+	}
+
+	memo.Lock()
+	if memo.done {
+		memo.Unlock()
+		return fn()
+	}
+	entry, ok := memo.values[key]
+	if !ok {
+		entry = &memoValue{}
+		memo.values[key] = entry
+	}
+	memo.Unlock()
+
+	entry.once.Do(func() { entry.value = fn() })
+	return entry.value
+}
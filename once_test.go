@@ -0,0 +1,53 @@
+package revel
+
+import (
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestOnce(t *testing.T) {
+	c := NewController(NewRequest(showRequest), NewResponse(httptest.NewRecorder()))
+	memo := withRequestMemo(c.Request)
+
+	var (
+		calls int
+		mutex sync.Mutex
+		wg    sync.WaitGroup
+	)
+	compute := func() interface{} {
+		mutex.Lock()
+		defer mutex.Unlock()
+		calls++
+		return calls
+	}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if value := c.Once("permissions", compute); value != 1 {
+				t.Errorf("Expected the memoized value 1, got %v", value)
+			}
+		}()
+	}
+	wg.Wait()
+	if value := c.Once("other", compute); value != 2 {
+		t.Errorf("Expected another key to be computed separately, got %v", value)
+	}
+
+	// Once the request is served, values are no longer cached.
+	memo.clear()
+	if value := c.Once("permissions", compute); value != 3 {
+		t.Errorf("Expected a fresh value after the request, got %v", value)
+	}
+	if value := c.Once("permissions", compute); value != 4 {
+		t.Errorf("Expected values not to be cached after the request, got %v", value)
+	}
+
+	// Controllers created outside of the server get a memo on first use.
+	c = NewController(NewRequest(showRequest), NewResponse(httptest.NewRecorder()))
+	c.Once("key", compute)
+	if value := c.Once("key", compute); value != 5 {
+		t.Errorf("Expected the memoized value 5, got %v", value)
+	}
+}
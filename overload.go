@@ -0,0 +1,92 @@
+package revel
+
+import (
+	"net/http"
+	"runtime"
+	"runtime/metrics"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// overloadInFlight is the number of requests being served past the
+// OverloadFilter.
+var overloadInFlight int64
+
+// overloadShedding is set while the OverloadFilter rejects requests, to log
+// only when that starts and stops.
+var overloadShedding atomic.Bool
+
+// OverloadFilter rejects requests with 503 Service Unavailable while the
+// process is overloaded, as a last line of defense.  It is overloaded when
+// any of these limits is reached (0, the default, means no limit):
+//
+//	overload.inflight   - the number of requests being served
+//	overload.goroutines - the number of goroutines
+//	overload.heap       - the bytes of heap in use by live and unswept objects
+//
+// The paths listed in "overload.allow" (comma separated, as for
+// "maintenance.allow") are always served, which should include the health
+// checks.  The Retry-After header is set to "overload.retryafter" seconds
+// (default 10, 0 to leave it out).  The limits are read on every request, so
+// they may be tuned by reloading the config.
+func OverloadFilter(c *Controller, fc []Filter) {
+	if reason := overloaded(); reason != "" && !pathListed(CurrentConfig().StringDefault("overload.allow", ""), c.Request.URL.Path) {
+		if !overloadShedding.Swap(true) {
+			WARN.Println("Overloaded, rejecting requests:", reason)
+		}
+		if retryAfter := CurrentConfig().IntDefault("overload.retryafter", 10); retryAfter > 0 {
+			c.Response.Out.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+		}
+		c.Response.Status = http.StatusServiceUnavailable
+		c.Result = c.RenderError(&Error{
+			Title:       "Service Unavailable",
+			Description: "The server is overloaded, please try again later.",
+		})
+		return
+	} else if reason == "" && overloadShedding.Swap(false) {
+		INFO.Println("No longer overloaded, accepting requests")
+	}
+
+	atomic.AddInt64(&overloadInFlight, 1)
+	defer atomic.AddInt64(&overloadInFlight, -1)
+	fc[0](c, fc[1:])
+}
+
+// overloaded returns the limit that is reached, or "" if none is.
+func overloaded() string {
+	if limit := CurrentConfig().IntDefault("overload.inflight", 0); limit > 0 && atomic.LoadInt64(&overloadInFlight) >= int64(limit) {
+		return "overload.inflight (" + strconv.Itoa(limit) + ") reached"
+	}
+	if limit := CurrentConfig().IntDefault("overload.goroutines", 0); limit > 0 && runtime.NumGoroutine() >= limit {
+		return "overload.goroutines (" + strconv.Itoa(limit) + ") reached"
+	}
+	if limit := CurrentConfig().IntDefault("overload.heap", 0); limit > 0 && heapInUse() >= uint64(limit) {
+		return "overload.heap (" + strconv.Itoa(limit) + " bytes) reached"
+	}
+	return ""
+}
+
+// heapSampleInterval is how long a reading of the heap size is reused.
+const heapSampleInterval = 100 * time.Millisecond
+
+var (
+	heapSampledAt int64 // Unix nanoseconds, accessed atomically.
+	heapSample    uint64
+)
+
+// heapInUse returns the bytes of heap occupied by objects, sampled at most
+// every heapSampleInterval.
+func heapInUse() uint64 {
+	now := time.Now().UnixNano()
+	sampledAt := atomic.LoadInt64(&heapSampledAt)
+	if now-sampledAt < int64(heapSampleInterval) || !atomic.CompareAndSwapInt64(&heapSampledAt, sampledAt, now) {
+		return atomic.LoadUint64(&heapSample)
+	}
+	sample := []metrics.Sample{{Name: "/memory/classes/heap/objects:bytes"}}
+	metrics.Read(sample)
+	if sample[0].Value.Kind() == metrics.KindUint64 {
+		atomic.StoreUint64(&heapSample, sample[0].Value.Uint64())
+	}
+	return atomic.LoadUint64(&heapSample)
+}
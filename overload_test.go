@@ -0,0 +1,72 @@
+package revel
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestOverloadFilter(t *testing.T) {
+	startFakeBookingApp()
+	defer func() {
+		for _, option := range []string{"overload.inflight", "overload.goroutines", "overload.heap"} {
+			Config.SetOption(option, "0")
+		}
+		Config.SetOption("overload.allow", "")
+	}()
+
+	run := func(path string, next Filter) (*httptest.ResponseRecorder, bool) {
+		req, _ := http.NewRequest("GET", path, nil)
+		resp := httptest.NewRecorder()
+		c := NewController(NewRequest(req), NewResponse(resp))
+		called := false
+		OverloadFilter(c, []Filter{func(c *Controller, fc []Filter) {
+			called = true
+			if next != nil {
+				next(c, fc)
+			}
+		}})
+		if c.Result != nil {
+			c.Result.Apply(c.Request, c.Response)
+		}
+		return resp, called
+	}
+
+	if _, called := run("/hotels", nil); !called {
+		t.Error("Expected requests to be served without limits")
+	}
+
+	// A request made while another one is being served.
+	Config.SetOption("overload.inflight", "1")
+	Config.SetOption("overload.allow", "/healthz")
+	var nested *httptest.ResponseRecorder
+	var nestedCalled, healthCalled bool
+	run("/hotels", func(c *Controller, fc []Filter) {
+		nested, nestedCalled = run("/hotels", nil)
+		_, healthCalled = run("/healthz", nil)
+	})
+	if nestedCalled || nested.Code != http.StatusServiceUnavailable || nested.Header().Get("Retry-After") != "10" {
+		t.Errorf("Expected a 503 with Retry-After, got %d (Retry-After %q, called: %v)", nested.Code, nested.Header().Get("Retry-After"), nestedCalled)
+	}
+	if !healthCalled {
+		t.Error("Expected the allowed path to be served when overloaded")
+	}
+	if _, called := run("/hotels", nil); !called {
+		t.Error("Expected requests to be served once the others are done")
+	}
+
+	Config.SetOption("overload.inflight", "0")
+	Config.SetOption("overload.goroutines", "1")
+	if resp, called := run("/hotels", nil); called || resp.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected the goroutine limit to be enforced, got %d", resp.Code)
+	}
+
+	Config.SetOption("overload.goroutines", "0")
+	Config.SetOption("overload.heap", "1")
+	if heapInUse() == 0 {
+		t.Skip("Heap size not available")
+	}
+	if resp, called := run("/hotels", nil); called || resp.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected the heap limit to be enforced, got %d", resp.Code)
+	}
+}
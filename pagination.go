@@ -0,0 +1,73 @@
+package revel
+
+import (
+	"math"
+	"strconv"
+)
+
+// PaginationOptions are the defaults and bounds applied by c.Pagination.
+type PaginationOptions struct {
+	Limit    int // The limit when none is given, 20 if zero.
+	MinLimit int // The smallest limit allowed, 1 if zero.
+	MaxLimit int // The largest limit allowed, 100 if zero.
+}
+
+// Pagination is the page of a list requested by the "page", "limit" and
+// "offset" params.
+type Pagination struct {
+	Page   int // The page number, starting at 1.
+	Limit  int // The number of items per page.
+	Offset int // The number of items before the page.
+}
+
+// Pagination returns the page requested by the "limit" param and either the
+// "offset" param or, if there is none, the "page" one (starting at 1), e.g.
+//
+//	p := c.Pagination(revel.PaginationOptions{Limit: 25, MaxLimit: 200})
+//	hotels, err := db.ListHotels(p.Offset, p.Limit)
+//
+// Values are never rejected: a limit outside of the bounds is clamped to
+// them, and one that is missing or invalid is replaced by the default limit,
+// as are negative offsets and pages before the first by the first page.
+func (c *Controller) Pagination(defaults PaginationOptions) Pagination {
+	if defaults.MinLimit <= 0 {
+		defaults.MinLimit = 1
+	}
+	if defaults.MaxLimit <= 0 {
+		defaults.MaxLimit = 100
+	}
+	if defaults.Limit <= 0 {
+		defaults.Limit = 20
+	}
+
+	var get func(string) string
+	if c.Params != nil {
+		get = c.Params.Get
+	} else {
+		get = func(string) string { return "" }
+	}
+
+	p := Pagination{Page: 1, Limit: defaults.Limit}
+	if limit, err := strconv.Atoi(get("limit")); err == nil {
+		p.Limit = limit
+	}
+	if p.Limit < defaults.MinLimit {
+		p.Limit = defaults.MinLimit
+	}
+	if p.Limit > defaults.MaxLimit {
+		p.Limit = defaults.MaxLimit
+	}
+
+	if offset, err := strconv.Atoi(get("offset")); err == nil {
+		if offset > 0 {
+			p.Offset = offset
+		}
+		p.Page = p.Offset/p.Limit + 1
+		return p
+	}
+	if page, err := strconv.Atoi(get("page")); err == nil && page > 1 && page-1 <= math.MaxInt32/p.Limit {
+		p.Page = page
+	}
+	p.Offset = (p.Page - 1) * p.Limit
+	return p
+}
@@ -0,0 +1,29 @@
+package revel
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestPagination(t *testing.T) {
+	defaults := PaginationOptions{Limit: 25, MaxLimit: 50}
+	for query, expected := range map[string]Pagination{
+		"":                  {Page: 1, Limit: 25, Offset: 0},
+		"page=3":            {Page: 3, Limit: 25, Offset: 50},
+		"page=2&limit=10":   {Page: 2, Limit: 10, Offset: 10},
+		"limit=500":         {Page: 1, Limit: 50, Offset: 0},
+		"limit=0":           {Page: 1, Limit: 1, Offset: 0},
+		"limit=ten&page=x":  {Page: 1, Limit: 25, Offset: 0},
+		"page=-2":           {Page: 1, Limit: 25, Offset: 0},
+		"page=99999999999":  {Page: 1, Limit: 25, Offset: 0},
+		"offset=30&page=9":  {Page: 2, Limit: 25, Offset: 30},
+		"offset=-5":         {Page: 1, Limit: 25, Offset: 0},
+		"offset=40&limit=8": {Page: 6, Limit: 8, Offset: 40},
+	} {
+		values, _ := url.ParseQuery(query)
+		c := &Controller{Params: &Params{Values: values}}
+		eq(t, query, c.Pagination(defaults), expected)
+	}
+
+	eq(t, "zero options", (&Controller{}).Pagination(PaginationOptions{}), Pagination{Page: 1, Limit: 20})
+}
@@ -4,6 +4,21 @@ import (
 	"runtime/debug"
 )
 
+// PanicHandler is notified of a panic in an action invocation, before the
+// error page is rendered.  It receives the recovered value and the raw stack
+// trace (as returned by debug.Stack()).
+type PanicHandler func(c *Controller, recovered interface{}, stack []byte)
+
+var panicHandlers []PanicHandler
+
+// RegisterPanicHandler adds a PanicHandler to be invoked by the PanicFilter.
+// Handlers are run in the order they are registered.  This is intended for
+// reporting (e.g. to an error tracking service); the rendered response is not
+// affected.
+func RegisterPanicHandler(handler PanicHandler) {
+	panicHandlers = append(panicHandlers, handler)
+}
+
 // PanicFilter wraps the action invocation in a protective defer blanket that
 // converts panics into 500 error pages.
 func PanicFilter(c *Controller, fc []Filter) {
@@ -18,6 +33,8 @@ func PanicFilter(c *Controller, fc []Filter) {
 // This function handles a panic in an action invocation.
 // It cleans up the stack trace, logs it, and displays an error page.
 func handleInvocationPanic(c *Controller, err interface{}) {
+	runPanicHandlers(c, err, debug.Stack())
+
 	error := NewErrorFromPanic(err)
 	if error == nil && DevMode {
 		// Only show the sensitive information in the debug stack trace in development mode, not production
@@ -30,3 +47,19 @@ func handleInvocationPanic(c *Controller, err interface{}) {
 	ERROR.Print(err, "\n", error.Stack)
 	c.Result = c.RenderError(error)
 }
+
+// runPanicHandlers invokes the registered panic handlers in order.
+// A panic within a handler is logged and does not prevent the remaining
+// handlers (or the error page) from running.
+func runPanicHandlers(c *Controller, err interface{}, stack []byte) {
+	for _, handler := range panicHandlers {
+		func() {
+			defer func() {
+				if handlerErr := recover(); handlerErr != nil {
+					ERROR.Println("Panic handler failed:", handlerErr)
+				}
+			}()
+			handler(c, err, stack)
+		}()
+	}
+}
@@ -0,0 +1,97 @@
+package revel
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPanicHandlersRunInOrder(t *testing.T) {
+	startFakeBookingApp()
+	defer func() { panicHandlers = nil }()
+
+	var calls []string
+	RegisterPanicHandler(func(c *Controller, recovered interface{}, stack []byte) {
+		calls = append(calls, "first:"+recovered.(string))
+		if !strings.Contains(string(stack), "TestPanicHandlersRunInOrder") {
+			t.Errorf("Expected the stack trace to reference the panicking code, got:\n%s", stack)
+		}
+	})
+	RegisterPanicHandler(func(c *Controller, recovered interface{}, stack []byte) {
+		panic("handler failure")
+	})
+	RegisterPanicHandler(func(c *Controller, recovered interface{}, stack []byte) {
+		calls = append(calls, "third:"+recovered.(string))
+	})
+
+	DevMode = true
+	defer func() { DevMode = false }()
+
+	resp := httptest.NewRecorder()
+	c := NewController(NewRequest(showRequest), NewResponse(resp))
+	PanicFilter(c, []Filter{func(c *Controller, fc []Filter) {
+		panic("boom")
+	}})
+
+	if len(calls) != 2 || calls[0] != "first:boom" || calls[1] != "third:boom" {
+		t.Errorf("Unexpected panic handler calls: %v", calls)
+	}
+	if resp.Code != 500 {
+		t.Errorf("Expected a 500 response, got %d", resp.Code)
+	}
+}
+
+func TestErrorStackFrames(t *testing.T) {
+	startFakeBookingApp()
+	defer func(basePath string) { BasePath = basePath }(BasePath)
+	BasePath = "/src/myapp"
+
+	e := &Error{Stack: `/src/myapp/app/controllers/hotels.go:191 +0x44
+github.com/revel/revel.(*Controller).Invoke(...)
+	/src/github.com/revel/revel/invoker.go:30 +0x1a
+reflect.Value.Call(...)
+	/usr/lib/go/src/reflect/value.go:380 +0xb9
+myapp/app/controllers.Hotels.List(...)
+	/src/myapp/app/controllers/hotels.go:50 +0x2c
+net/http.(*conn).serve(...)
+	/usr/lib/go/src/net/http/server.go:2000 +0x5f
+created by net/http.(*Server).Serve in goroutine 1
+	/usr/lib/go/src/net/http/server.go:3000 +0x3f
+`}
+
+	frames := e.StackFrames()
+	if len(frames) != 6 || !frames[0].IsFirst || !frames[0].IsApp || frames[1].IsApp || frames[3].IsFirst || !frames[3].IsApp {
+		t.Errorf("Unexpected stack frames: %+v", frames)
+	}
+	if frames[3].Function != "myapp/app/controllers.Hotels.List(...)" || frames[3].Location != "/src/myapp/app/controllers/hotels.go:50 +0x2c" {
+		t.Errorf("Unexpected app frame: %+v", frames[3])
+	}
+
+	Config.SetOption("errors.stack.trim", "true")
+	frames = e.StackFrames()
+	if len(frames) != 4 || frames[1].Omitted != 2 || frames[3].Omitted != 2 {
+		t.Errorf("Expected the framework frames to be trimmed, got %+v", frames)
+	}
+
+	Config.SetOption("errors.stack.depth", "2")
+	if frames = e.StackFrames(); len(frames) != 2 {
+		t.Errorf("Expected 2 frames, got %+v", frames)
+	}
+
+	// Neither revel nor the vendored packages are application code.
+	for location, expected := range map[string]bool{
+		"/src/myapp/app/models/hotel.go:10 +0x1":                       true,
+		"/src/myapp/vendor/github.com/lib/pq/conn.go:10 +0x1":          false,
+		"/src/myapp/vendor/github.com/revel/revel/invoker.go:30 +0x1":  false,
+		"/go/pkg/mod/github.com/revel/revel@v1.0.0/invoker.go:30 +0x1": false,
+		"/src/myapp2/app/controllers/app.go:10 +0x1":                   false,
+	} {
+		eq(t, location, isAppSource(location), expected)
+	}
+
+	Config.SetOption("errors.source.lines", "1")
+	e = &Error{Line: 3, SourceLines: []string{"a", "b", "c", "d", "e"}}
+	if lines := e.ContextSource(); len(lines) != 2 || lines[0].Source != "b" || !lines[1].IsError {
+		t.Errorf("Unexpected context source: %+v", lines)
+	}
+}
@@ -1,10 +1,25 @@
 package revel
 
 import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
 	"mime/multipart"
+	"net"
+	"net/http"
+	"net/netip"
 	"net/url"
 	"os"
 	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
 )
 
 // Params provides a unified view of the request params.
@@ -12,6 +27,7 @@ import (
 // - URL query string
 // - Form values
 // - File uploads
+// - JSON request body
 //
 // Warning: param maps other than Values may be nil if there were none.
 type Params struct {
@@ -25,40 +41,546 @@ type Params struct {
 	Query url.Values // Parameters from the query string, e.g. /index?limit=10
 	Form  url.Values // Parameters from the request body.
 
+	Cookies url.Values // Cookie values by name, bound by the CookieBinder
+
 	Files    map[string][]*multipart.FileHeader // Files uploaded in a multipart form
 	tmpFiles []*os.File                         // Temp files used during the request.
+
+	JSON    []byte // The raw request body, if it was sent as JSON.
+	RawBody []byte // The raw request body, if kept by its BodyParser (see ParseRawBody).
+
+	// FieldNamer overrides the DefaultFieldNamer when binding struct fields,
+	// e.g. SnakeCaseFieldName.
+	FieldNamer FieldNamer
+
+	bindErrors       []*BindError         // The params that could not be bound, see BindErrors.
+	jsonIncomplete   bool                 // The JSON body could not be read in full.
+	jsonTree         interface{}          // The decoded JSON body, see jsonPath.
+	jsonTreeOf       []byte               // The JSON body jsonTree was decoded from.
+	sniffJSON        bool                 // Bodies of unknown types may be JSON, see ParamsFilter.
+	streamMultipart  bool                 // Multipart bodies are left for ParseMultipart, see ParamsFilter.
+	multipartRequest *Request             // The request whose multipart body is left for ParseMultipart.
+	fileSinks        map[string]io.Writer // The writers of the fields streamed by ParseMultipart.
+	actionArgs       []string             // The names of the action arguments, see bindValues.
 }
 
-func ParseParams(params *Params, req *Request) {
-	params.Query = req.URL.Query()
+// ErrBodyReadTimeout is returned when the request context deadline passes
+// while the request body is still being read.
+var ErrBodyReadTimeout = errors.New("revel/params: timed out reading request body")
+
+// ErrInvalidUTF8 is returned when a query or form param is not valid UTF-8
+// and "params.utf8" is set to "reject".
+var ErrInvalidUTF8 = errors.New("revel/params: param is not valid UTF-8")
+
+// ErrIncompleteBody is returned by BindJSON when reading the JSON request
+// body failed part way, and "params.json.partial" is set to "reject".
+var ErrIncompleteBody = errors.New("revel/params: incomplete JSON body")
+
+// ErrTooManyParams is returned when the query string or form has more distinct
+// keys than "params.query.maxkeys" or "params.form.maxkeys" allow.
+var ErrTooManyParams = errors.New("revel/params: too many params")
+
+// ErrParamTooLong is returned when a form value is longer than
+// "params.form.maxvaluelen" and "params.form.toolong" is set to "reject".
+var ErrParamTooLong = errors.New("revel/params: form value too long")
+
+// ParseParams fills in params from the given request.  It returns the error
+// encountered while reading the request body, if any.  Reading the body
+// respects the request context, so a body that is still being read when the
+// context deadline passes results in ErrBodyReadTimeout.
+//
+// Bodies sent with a gzip or deflate Content-Encoding are decompressed
+// before being parsed.
+//
+// Query and form params that are not valid UTF-8 are handled according to
+// "params.utf8": "accept" (the default) keeps them as they are, "replace"
+// replaces the invalid bytes with U+FFFD and "reject" returns ErrInvalidUTF8.
+// Uploaded files are not checked.
+//
+// The query string may have at most "params.query.maxkeys" distinct keys, and
+// the form "params.form.maxkeys", or else ErrTooManyParams is returned.  They
+// are checked while they are parsed, so that the rest of a form with too many
+// keys is not read.
+//
+// Form values longer than "params.form.maxvaluelen" bytes are handled
+// according to "params.form.toolong": "reject" (the default) returns
+// ErrParamTooLong and "truncate" cuts them to the limit.  They are checked as
+// they are decoded, so that they are not buffered in full.  Uploaded files are
+// not affected.
+//
+// The bodies of requests with a method listed in "params.body.ignore" (GET,
+// HEAD and DELETE by default) are neither read nor parsed.
+func ParseParams(params *Params, req *Request) error {
+	var parseErr error
+	params.Cookies = cookieValues(req)
+	query, err := parseQueryLimited(req.URL.RawQuery, paramsQueryMaxKeys)
+	if err != nil {
+		WARN.Println("Error parsing query string:", err)
+		params.Values = transformParams(params.calcValues())
+		return err
+	}
+	params.Query = query
+
+	limitBodyByContext(req)
+	contentType := req.ContentType
+	if paramsBodyIgnored[req.Method] {
+		contentType = ""
+	} else if err := decompressBody(req); err != nil {
+		WARN.Println("Error decompressing request body:", err)
+		params.Values = transformParams(params.calcValues())
+		return err
+	}
 
 	// Parse the body depending on the content type.
-	switch req.ContentType {
+	switch contentType {
 	case "application/x-www-form-urlencoded":
 		// Typical form.
-		if err := req.ParseForm(); err != nil {
+		if req.Body == nil {
+			break
+		}
+		if form, err := parseFormLimited(req.Body, paramsFormMaxKeys); err == ErrTooManyParams || err == ErrParamTooLong {
+			parseErr = err
+		} else if err != nil {
 			WARN.Println("Error parsing request body:", err)
+			parseErr = err
 		} else {
-			params.Form = req.Form
+			params.Form = form
+			setRequestForm(req, form, params.Query)
 		}
 
 	case "multipart/form-data":
 		// Multipart form.
 		// TODO: Extract the multipart form param so app can set it.
-		if err := req.ParseMultipartForm(32 << 20 /* 32 MB */); err != nil {
+		if params.streamMultipart {
+			// Parsed by the action, see ParseMultipart.
+			params.multipartRequest = req
+		} else if err := params.parseMultipartForm(req); err == ErrTooManyParams || err == ErrParamTooLong {
+			parseErr = err
+		} else if err != nil {
 			WARN.Println("Error parsing request body:", err)
+			parseErr = err
 		} else {
 			params.Form = req.MultipartForm.Value
 			params.Files = req.MultipartForm.File
 		}
+
+	case "application/json", "text/json":
+		// JSON body.  It is kept raw and decoded on demand by the binder.
+		if err := populateParamsJSON(params, req); err != nil {
+			WARN.Println("Error reading JSON request body:", err)
+			parseErr = err
+		}
+
+	default:
+		if parser, ok := BodyParsers[contentType]; ok {
+			if err := parser(params, req); err != nil {
+				WARN.Println("Error parsing request body:", err)
+				parseErr = err
+			}
+		} else if params.sniffJSON && !paramsBodyIgnored[req.Method] && sniffJSONBody(req) {
+			if err := populateParamsJSON(params, req); err != nil {
+				WARN.Println("Error reading JSON request body:", err)
+				parseErr = err
+			}
+		}
+	}
+
+	if err := checkParamsUTF8(params); err != nil && parseErr == nil {
+		parseErr = err
+	}
+
+	params.Values = transformParams(params.calcValues())
+	return parseErr
+}
+
+// paramsUTF8 is how invalid UTF-8 in params is handled, from "params.utf8".
+var paramsUTF8 = "accept"
+
+// paramsJSONPartial is how a JSON body that could not be read in full is
+// handled, from "params.json.partial".
+var paramsJSONPartial = "reject"
+
+// paramsQueryMaxKeys and paramsFormMaxKeys are the most distinct query and
+// form keys allowed, from "params.query.maxkeys" and "params.form.maxkeys".
+// Zero means no limit.
+var paramsQueryMaxKeys, paramsFormMaxKeys int
+
+// paramsBodyIgnored are the methods whose request bodies are not parsed, from
+// "params.body.ignore".
+var paramsBodyIgnored = map[string]bool{"GET": true, "HEAD": true, "DELETE": true}
+
+// paramsFormMaxValueLen is the longest form value allowed, in bytes, from
+// "params.form.maxvaluelen", and paramsFormTooLong how longer values are
+// handled, from "params.form.toolong".  Zero means no limit.
+var (
+	paramsFormMaxValueLen int
+	paramsFormTooLong     = "reject"
+)
+
+func init() {
+	OnAppStart(func() {
+		paramsUTF8 = CurrentConfig().StringDefault("params.utf8", "accept")
+		if paramsUTF8 != "accept" && paramsUTF8 != "replace" && paramsUTF8 != "reject" {
+			panic(fmt.Errorf("params.utf8 invalid: %q", paramsUTF8))
+		}
+		paramsJSONPartial = CurrentConfig().StringDefault("params.json.partial", "reject")
+		if paramsJSONPartial != "reject" && paramsJSONPartial != "keep" {
+			panic(fmt.Errorf("params.json.partial invalid: %q", paramsJSONPartial))
+		}
+		paramsQueryMaxKeys = CurrentConfig().IntDefault("params.query.maxkeys", 0)
+		paramsFormMaxKeys = CurrentConfig().IntDefault("params.form.maxkeys", 0)
+		paramsFormMaxValueLen = CurrentConfig().IntDefault("params.form.maxvaluelen", 0)
+		paramsFormTooLong = CurrentConfig().StringDefault("params.form.toolong", "reject")
+		if paramsFormTooLong != "reject" && paramsFormTooLong != "truncate" {
+			panic(fmt.Errorf("params.form.toolong invalid: %q", paramsFormTooLong))
+		}
+		paramsBodyIgnored = make(map[string]bool)
+		for _, method := range strings.Split(CurrentConfig().StringDefault("params.body.ignore", "GET,HEAD,DELETE"), ",") {
+			if method = strings.TrimSpace(method); method != "" {
+				paramsBodyIgnored[strings.ToUpper(method)] = true
+			}
+		}
+	})
+}
+
+// checkParamsUTF8 applies "params.utf8" to the query and form params.
+func checkParamsUTF8(params *Params) error {
+	switch paramsUTF8 {
+	case "replace":
+		replaceInvalidUTF8(params.Query)
+		replaceInvalidUTF8(params.Form)
+	case "reject":
+		if !validUTF8(params.Query) || !validUTF8(params.Form) {
+			return ErrInvalidUTF8
+		}
 	}
+	return nil
+}
 
-	params.Values = params.calcValues()
+// parseQueryLimited parses the query string as url.ParseQuery does, dropping
+// malformed pairs, but gives up with ErrTooManyParams as soon as it finds more
+// than maxKeys distinct keys.  Zero means no limit.
+func parseQueryLimited(query string, maxKeys int) (url.Values, error) {
+	values := make(url.Values)
+	for query != "" {
+		var pair string
+		if i := strings.IndexByte(query, '&'); i >= 0 {
+			pair, query = query[:i], query[i+1:]
+		} else {
+			pair, query = query, ""
+		}
+		if err := addQueryPair(values, pair, maxKeys, 0); err != nil {
+			return nil, err
+		}
+	}
+	return values, nil
+}
+
+// maxFormSize is the largest urlencoded form body read, as by
+// http.Request.ParseForm.  It is also the limit of JSON and raw bodies, unless
+// the BodyLimitFilter sets one.
+const maxFormSize = 10 << 20 // 10 MB
+
+// errFormTooLarge is returned for urlencoded form bodies over maxFormSize.
+var errFormTooLarge = errors.New("revel/params: form body too large")
+
+// parseFormLimited parses an urlencoded form body as parseQueryLimited parses
+// the query string, while reading it: it stops reading as soon as it finds
+// more than maxKeys distinct keys, and applies "params.form.maxvaluelen" to
+// the values as they are decoded, so that a long value is never buffered in
+// full.
+func parseFormLimited(body io.Reader, maxKeys int) (url.Values, error) {
+	values := make(url.Values)
+	limited := &io.LimitedReader{R: body, N: maxFormSize + 1}
+	reader := bufio.NewReader(limited)
+	// An escaped byte takes at most 3 bytes, so a value longer than this
+	// decodes to more than paramsFormMaxValueLen bytes.
+	maxEncodedLen := 0
+	if paramsFormMaxValueLen > 0 {
+		maxEncodedLen = 3*paramsFormMaxValueLen + 3
+	}
+	for {
+		key, delim, _, err := readFormField(reader, "=&", 0)
+		if err != nil {
+			return nil, err
+		}
+		pair := string(key)
+		if delim == '=' {
+			value, end, tooLong, err := readFormField(reader, "&", maxEncodedLen)
+			if err != nil {
+				return nil, err
+			}
+			if tooLong {
+				if paramsFormTooLong == "reject" {
+					return nil, ErrParamTooLong
+				}
+				if end, err = skipFormField(reader, "&"); err != nil {
+					return nil, err
+				}
+				// Drop an escape cut short.
+				if i := bytes.LastIndexByte(value, '%'); i >= len(value)-2 {
+					value = value[:i]
+				}
+			}
+			pair += "=" + string(value)
+			delim = end
+		}
+		if limited.N == 0 {
+			return nil, errFormTooLarge
+		}
+		if err := addQueryPair(values, pair, maxKeys, paramsFormMaxValueLen); err != nil {
+			return nil, err
+		}
+		if delim == 0 {
+			return values, nil
+		}
+	}
+}
+
+// readFormField reads r up to the next of the delimiters, which it consumes,
+// and returns the bytes read and the delimiter, or 0 at the end of r.  If max
+// is positive, it stops after max bytes of a longer field and returns tooLong.
+func readFormField(r *bufio.Reader, delims string, max int) (field []byte, delim byte, tooLong bool, err error) {
+	for {
+		c, err := r.ReadByte()
+		if err == io.EOF {
+			return field, 0, false, nil
+		} else if err != nil {
+			return nil, 0, false, err
+		}
+		if strings.IndexByte(delims, c) >= 0 {
+			return field, c, false, nil
+		}
+		if max > 0 && len(field) == max {
+			return field, 0, true, r.UnreadByte()
+		}
+		field = append(field, c)
+	}
+}
+
+// skipFormField reads r up to the next of the delimiters, as readFormField,
+// without keeping the bytes read.
+func skipFormField(r *bufio.Reader, delims string) (byte, error) {
+	for {
+		c, err := r.ReadByte()
+		if err == io.EOF {
+			return 0, nil
+		} else if err != nil {
+			return 0, err
+		}
+		if strings.IndexByte(delims, c) >= 0 {
+			return c, nil
+		}
+	}
+}
+
+// addQueryPair adds the key and value of an urlencoded "key=value" pair to
+// values, unless it is malformed.  It returns ErrTooManyParams if the key
+// would be one more than maxKeys distinct keys, and applies
+// "params.form.toolong" to values longer than maxValueLen bytes.  Zero means
+// no limit.
+func addQueryPair(values url.Values, pair string, maxKeys, maxValueLen int) error {
+	if pair == "" || strings.Contains(pair, ";") {
+		return nil
+	}
+	key, value := pair, ""
+	if i := strings.IndexByte(pair, '='); i >= 0 {
+		key, value = pair[:i], pair[i+1:]
+	}
+	key, err := url.QueryUnescape(key)
+	if err != nil {
+		return nil
+	}
+	value, err = url.QueryUnescape(value)
+	if err != nil {
+		return nil
+	}
+	if value, err = limitFormValue(value, maxValueLen); err != nil {
+		return err
+	}
+	if _, ok := values[key]; !ok && maxKeys > 0 && len(values) == maxKeys {
+		return ErrTooManyParams
+	}
+	values[key] = append(values[key], value)
+	return nil
+}
+
+// setRequestForm sets the Form and PostForm of the request to the parsed
+// body as Request.ParseForm would, for the code that reads them (e.g. the
+// HttpMethodOverride filter).
+func setRequestForm(req *Request, form, query url.Values) {
+	req.PostForm = form
+	req.Form = make(url.Values, len(form)+len(query))
+	for key, values := range form {
+		req.Form[key] = append(req.Form[key], values...)
+	}
+	for key, values := range query {
+		req.Form[key] = append(req.Form[key], values...)
+	}
+}
+
+// limitFormValue applies "params.form.toolong" to a form value longer than
+// max bytes: it returns ErrParamTooLong, or the value truncated at the start
+// of a UTF-8 sequence, so that no invalid UTF-8 is introduced.  Zero means no
+// limit.
+func limitFormValue(value string, max int) (string, error) {
+	if max <= 0 || len(value) <= max {
+		return value, nil
+	}
+	if paramsFormTooLong == "reject" {
+		return "", ErrParamTooLong
+	}
+	n := max
+	for n > 0 && !utf8.RuneStart(value[n]) {
+		n--
+	}
+	return value[:n], nil
+}
+
+func validUTF8(values url.Values) bool {
+	for key, vals := range values {
+		if !utf8.ValidString(key) {
+			return false
+		}
+		for _, val := range vals {
+			if !utf8.ValidString(val) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func replaceInvalidUTF8(values url.Values) {
+	for key, vals := range values {
+		for i, val := range vals {
+			vals[i] = strings.ToValidUTF8(val, "\uFFFD")
+		}
+		if valid := strings.ToValidUTF8(key, "\uFFFD"); valid != key {
+			delete(values, key)
+			values[valid] = append(values[valid], vals...)
+		}
+	}
+}
+
+// populateParamsJSON reads the request body into params.JSON.  If reading
+// fails part way, whatever was read is kept but flagged as incomplete, so
+// that it is only bound when "params.json.partial" is set to "keep".
+func populateParamsJSON(params *Params, req *Request) error {
+	if req.Body == nil {
+		return nil
+	}
+	body, err := readBody(req)
+	params.JSON = body
+	params.jsonIncomplete = err != nil
+	return err
+}
+
+// readBody reads the request body, of at most the limit set by the
+// BodyLimitFilter, or maxFormSize if it set none.  A larger body is cut at
+// the limit, and ErrBodyTooLarge returned.
+func readBody(req *Request) ([]byte, error) {
+	limit := req.bodyLimit
+	if limit <= 0 {
+		limit = maxFormSize
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(req.Body, limit+1))
+	if err == nil && int64(len(body)) > limit {
+		return body[:limit], ErrBodyTooLarge
+	}
+	return body, err
+}
+
+// sniffJSONBody returns true if the request body looks like a JSON document,
+// i.e. it starts with "{" or "[" after any whitespace.  The bytes peeked at
+// are left to be read from the body.
+func sniffJSONBody(req *Request) bool {
+	if req.Body == nil {
+		return false
+	}
+	reader := bufio.NewReader(req.Body)
+	req.Body = bufferedBody{reader, req.Body}
+	for i := 1; ; i++ {
+		peeked, err := reader.Peek(i)
+		if len(peeked) < i {
+			if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+				WARN.Println("Error reading request body:", err)
+			}
+			return false
+		}
+		switch peeked[i-1] {
+		case ' ', '\t', '\r', '\n':
+			continue
+		case '{', '[':
+			return true
+		}
+		return false
+	}
+}
+
+// bindableJSON returns the JSON body to bind from, which is nil if it could
+// not be read in full and partial bodies are rejected.
+func (p *Params) bindableJSON() []byte {
+	if p.jsonIncomplete && paramsJSONPartial == "reject" {
+		return nil
+	}
+	return p.JSON
+}
+
+// BodyParser parses a request body into params.  The body it reads is limited
+// in size and time in the same way as forms and JSON.
+type BodyParser func(params *Params, req *Request) error
+
+// BodyParsers are the parsers of the request body by content type, for content
+// types other than forms and JSON.  Applications and packages (e.g.
+// revel/protobuf) may register their own.
+var BodyParsers = map[string]BodyParser{}
+
+// ParseRawBody is a BodyParser that reads the request body into
+// params.RawBody, for the content types that are decoded by the action.
+func ParseRawBody(params *Params, req *Request) error {
+	if req.Body == nil {
+		return nil
+	}
+	body, err := readBody(req)
+	if err != nil {
+		return err
+	}
+	params.RawBody = body
+	return nil
+}
+
+// contextReader aborts reading the wrapped body once its context is done.
+// The context is checked before every read, so a client trickling the body
+// in small pieces is cut off at the deadline.  (A single read blocked on the
+// network is bounded by the server's timeout.read instead.)
+type contextReader struct {
+	ctx context.Context
+	io.ReadCloser
+}
+
+func (r *contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		if err == context.DeadlineExceeded {
+			return 0, ErrBodyReadTimeout
+		}
+		return 0, err
+	}
+	return r.ReadCloser.Read(p)
+}
+
+// limitBodyByContext wraps the request body in a contextReader.
+// It composes with any byte limit already applied to the body.
+func limitBodyByContext(req *Request) {
+	if req.Body != nil {
+		req.Body = &contextReader{req.Context(), req.Body}
+	}
 }
 
 // Bind looks for the named parameter, converts it to the requested type, and
 // writes it into "dest", which must be settable.  If the value can not be
-// parsed, "dest" is set to the zero value.
+// parsed, "dest" is set to the zero value and the error is recorded, see
+// BindErrors.
 func (p *Params) Bind(dest interface{}, name string) {
 	value := reflect.ValueOf(dest)
 	if value.Kind() != reflect.Ptr {
@@ -68,7 +590,373 @@ func (p *Params) Bind(dest interface{}, name string) {
 	if !value.CanSet() {
 		panic("revel/params: non-settable variable passed to Bind: " + name)
 	}
-	value.Set(Bind(p, name, value.Type()))
+	value.Set(p.bindArg(name, value.Type()))
+}
+
+// bindArg binds the named param to the given type, as Bind, and records the
+// error if the value could not be parsed and the binder did not record one.
+func (p *Params) bindArg(name string, typ reflect.Type) reflect.Value {
+	recorded := len(p.bindErrors)
+	bound := Bind(p, name, typ)
+	if len(p.bindErrors) == recorded {
+		if err := argBindError(p, name, typ, bound); err != nil {
+			p.bindErrors = append(p.bindErrors, err)
+		}
+	}
+	for _, err := range p.bindErrors[recorded:] {
+		TRACE.Println(err)
+	}
+	return bound
+}
+
+// BindJSON decodes the JSON request body into "dest", which must be a pointer.
+// Returns an error if the request had no JSON body or it could not be decoded,
+// or ErrIncompleteBody if the body could not be read in full (see
+// "params.json.partial").  The body must hold a single JSON document: data
+// other than whitespace after it, e.g. `{"a":1}{"b":2}`, is an error.
+//
+// If "binder.json.lenient" is set, scalars are converted across numbers,
+// strings and bools to suit the fields of "dest", e.g. 123 may be bound to a
+// string field and "123" to an int field.
+//
+// Numbers are decoded as encoding/json does: exactly into json.Number fields,
+// but as float64 into interface{} values.  See DecodeJSONNumber.
+//
+// Struct fields without a name in their "json" tag are also matched by the
+// name given by the FieldNamer, e.g. UserName by "user_name" with the
+// SnakeCaseFieldName.
+func (p *Params) BindJSON(dest interface{}) error {
+	return p.decodeJSON(dest, false)
+}
+
+// DecodeJSONNumber decodes the JSON request body into "dest" as BindJSON
+// does, except that numbers decoded into interface{} values (e.g. the values
+// of a map[string]interface{}) are kept as json.Number instead of float64.
+// So their exact representation is preserved, e.g. for currency amounts,
+// where float64 rounding errors (0.1 + 0.2 != 0.3) are unacceptable, or for
+// integers beyond 2^53.  The tradeoff is that they must be converted
+// explicitly before use, with Int64, Float64 or a decimal library parsing
+// String.
+func (p *Params) DecodeJSONNumber(dest interface{}) error {
+	return p.decodeJSON(dest, true)
+}
+
+func (p *Params) decodeJSON(dest interface{}, useNumber bool) error {
+	if p.jsonIncomplete && paramsJSONPartial == "reject" {
+		return ErrIncompleteBody
+	}
+	if len(p.JSON) == 0 {
+		return errors.New("revel/params: no JSON body to bind")
+	}
+	body := renameJSONFields(p.JSON, reflect.TypeOf(dest), p.fieldNamer())
+	if bindJSONLenient {
+		return unmarshalJSONLenient(body, dest, useNumber)
+	}
+	return unmarshalJSON(body, dest, useNumber)
+}
+
+// ErrParamRequired is the BindError cause of a missing required param.
+var ErrParamRequired = errors.New("required")
+
+// ErrInvalidIP is the BindError cause of a param bound to a net.IP or
+// netip.Addr that is not an IP address.
+var ErrInvalidIP = errors.New("not an IP address")
+
+// BindError is a param that could not be bound onto a struct field or action
+// argument.
+type BindError struct {
+	Field string       // The struct field, e.g. "CheckInDate", or "" for an action argument
+	Param string       // The param name, e.g. "checkin"
+	Value string       // The value submitted
+	Err   error        // Why the value could not be parsed
+	Type  reflect.Type // The type bound to, e.g. time.Time
+}
+
+func (e *BindError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("revel/params: can not bind %s=%q to %s: %s", e.Param, e.Value, e.Type, e.Err)
+	}
+	return fmt.Sprintf("revel/params: can not bind %s=%q to field %s: %s", e.Param, e.Value, e.Field, e.Err)
+}
+
+// validationError returns the error reported to the Validation for the param.
+func (e *BindError) validationError() *ValidationError {
+	message := "Invalid value"
+	switch e.Err {
+	case ErrParamRequired:
+		message = Required{}.DefaultMessage()
+	case ErrInvalidIP:
+		message = "Must be a valid IP address"
+	}
+	return &ValidationError{Key: e.Param, Message: message}
+}
+
+// BindErrors returns the params that could not be bound by Bind so far, in
+// the order they were bound: those sent with a value that could not be
+// parsed (e.g. "page=two" for an int), which were bound to the zero value,
+// and the missing required fields of structs.  The ActionInvoker adds those
+// of the action arguments, and the action those it binds itself.
+func (p *Params) BindErrors() []*BindError {
+	return p.bindErrors
+}
+
+// cookieValues returns the values of the request cookies by name.
+func cookieValues(req *Request) url.Values {
+	cookies := req.Cookies()
+	if len(cookies) == 0 {
+		return nil
+	}
+	values := make(url.Values, len(cookies))
+	for _, cookie := range cookies {
+		values.Add(cookie.Name, cookie.Value)
+	}
+	return values
+}
+
+// argBindError returns why the action argument of the given name and type
+// was bound to the zero value although a value was sent for it, or nil.
+func argBindError(p *Params, name string, typ reflect.Type, bound reflect.Value) *BindError {
+	if _, ok := namedBinders[name]; ok {
+		return nil
+	}
+	for typ.Kind() == reflect.Ptr && bound.IsValid() && !bound.IsNil() {
+		typ, bound = typ.Elem(), bound.Elem()
+	}
+	if !bound.IsValid() || !bound.IsZero() {
+		return nil
+	}
+
+	if !hasParamIn(p.Fixed, name) && !hasParamIn(p.Route, name) {
+		if raw, found := p.jsonPath(name); found {
+			if _, err := decodeJSONPath(raw, typ, p.fieldNamer()); err != nil {
+				return &BindError{Param: name, Value: string(raw), Err: err, Type: typ}
+			}
+			return nil
+		}
+	}
+	if raw := p.Get(name); raw != "" {
+		if err := valueParseError(raw, typ); err != nil {
+			return &BindError{Param: name, Value: raw, Err: err, Type: typ}
+		}
+	}
+	return nil
+}
+
+// BindForm binds the request params onto the exported fields of the struct
+// pointed to by "dest", using the same binders as action arguments.  A field
+// is bound from the param of the same name, or of the name given in its
+// "param" tag:
+//
+//	type Search struct {
+//		Query string   `param:"q,required"`
+//		Page  int      `param:"page"`
+//		Tags  []string `param:"tags,csv"` // e.g. tags=a,b,c
+//		Admin bool     `param:"-"`        // Never bound
+//	}
+//
+// Untagged fields are named by the Params' FieldNamer, or else the
+// DefaultFieldNamer.
+//
+// Fields without a param are left untouched, as are pointer fields with an
+// empty one (e.g. "age=") if "binder.emptyasnil" is set or they are tagged
+// with the "emptynil" option, e.g. `param:"age,emptynil"`.  Fields without a
+// param are set to the value of their "default" tag instead, if they have
+// one, e.g. `default:"20"`.  The returned errors (all of
+// them *BindError) list the params that could not be parsed, whose fields are
+// set to the zero value, and the missing required params.
+func (p *Params) BindForm(dest interface{}) []error {
+	value := reflect.ValueOf(dest)
+	if value.Kind() != reflect.Ptr || value.Elem().Kind() != reflect.Struct {
+		panic("revel/params: BindForm requires a pointer to a struct")
+	}
+	value = value.Elem()
+
+	var errs []error
+	namer := p.fieldNamer()
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		name, required := paramTag(field, namer)
+		if field.PkgPath != "" || name == "" {
+			continue
+		}
+		if !p.hasParam(name) || (field.Type.Kind() == reflect.Ptr && (bindEmptyAsNil || hasParamOption(field, "emptynil")) && p.isEmptyParam(name)) {
+			if def, ok := bindDefault(field); ok && !p.hasParam(name) {
+				value.Field(i).Set(def)
+			}
+			if required {
+				errs = append(errs, &BindError{field.Name, name, "", ErrParamRequired, field.Type})
+			}
+			continue
+		}
+		bound := bindField(p, name, field)
+		value.Field(i).Set(bound)
+		if raw := p.Get(name); raw != "" && bound.IsZero() {
+			if err := valueParseError(raw, field.Type); err != nil {
+				errs = append(errs, &BindError{field.Name, name, raw, err, field.Type})
+			}
+		}
+	}
+	return errs
+}
+
+// paramTag returns the name of the param bound to the given struct field (""
+// if it is excluded with `param:"-"`), and whether it is tagged as required,
+// e.g. `param:"user_id,required"`.  Untagged fields are named by namer.
+func paramTag(field reflect.StructField, namer FieldNamer) (name string, required bool) {
+	required = hasParamOption(field, "required")
+	switch name = strings.TrimSpace(strings.Split(field.Tag.Get("param"), ",")[0]); name {
+	case "-":
+		return "", false
+	case "":
+		return namer(field), required
+	}
+	return name, required
+}
+
+// hasParamOption returns true if the "param" tag of the given struct field
+// has the given option, e.g. "csv" for `param:"tags,csv"`.
+func hasParamOption(field reflect.StructField, option string) bool {
+	for _, opt := range strings.Split(field.Tag.Get("param"), ",")[1:] {
+		if strings.TrimSpace(opt) == option {
+			return true
+		}
+	}
+	return false
+}
+
+// valueParseError returns why the given value, which was bound to the zero
+// value of typ, could not be parsed.  It returns nil if the value is valid
+// (e.g. "0" for an int).
+func valueParseError(value string, typ reflect.Type) (err error) {
+	if typ == reflect.TypeOf(time.Time{}) {
+		return errors.New("unrecognized time format")
+	}
+	if typ == reflect.TypeOf(time.Duration(0)) {
+		_, err = parseDuration(value)
+		return err
+	}
+	if typ == reflect.TypeOf(net.IP{}) && net.ParseIP(strings.TrimSpace(value)) == nil {
+		return errors.New("invalid IP address")
+	}
+	if typ == reflect.TypeOf(netip.Addr{}) {
+		_, err = netip.ParseAddr(strings.TrimSpace(value))
+		return err
+	}
+	switch typ.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		_, err = strconv.ParseInt(value, 10, 64)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		_, err = strconv.ParseUint(value, 10, 64)
+	case reflect.Float32, reflect.Float64:
+		_, err = strconv.ParseFloat(value, 64)
+	}
+	if numErr, ok := err.(*strconv.NumError); ok {
+		err = numErr.Err
+	}
+	return err
+}
+
+// BindPresent binds the request params onto the struct pointed to by "dest"
+// and returns the names of the fields that were present in the request.
+// Fields that were not submitted are left untouched, which makes it suitable
+// for applying PATCH requests onto an existing value.
+//
+// For JSON bodies, the top-level keys of the document are considered (matched
+// to fields in the same way as encoding/json).  Otherwise, a field is present
+// if a param of the same name (or sub-key, e.g. Name.First) was submitted.
+func (p *Params) BindPresent(dest interface{}) map[string]bool {
+	value := reflect.ValueOf(dest)
+	if value.Kind() != reflect.Ptr || value.Elem().Kind() != reflect.Struct {
+		panic("revel/params: BindPresent requires a pointer to a struct")
+	}
+	value = value.Elem()
+	present := make(map[string]bool)
+
+	if body := p.bindableJSON(); len(body) > 0 {
+		var keys map[string]json.RawMessage
+		if err := json.Unmarshal(body, &keys); err != nil {
+			WARN.Println("revel/params: BindPresent could not decode JSON body:", err)
+			return present
+		}
+		if err := json.Unmarshal(body, dest); err != nil {
+			WARN.Println("revel/params: BindPresent could not bind JSON body:", err)
+		}
+		for key := range keys {
+			if field, ok := jsonFieldName(value.Type(), key); ok {
+				present[field] = true
+			}
+		}
+		return present
+	}
+
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		if field.PkgPath != "" || !p.hasParam(field.Name) {
+			continue
+		}
+		value.Field(i).Set(Bind(p, field.Name, field.Type))
+		present[field.Name] = true
+	}
+	return present
+}
+
+// jsonFieldName returns the name of the struct field that encoding/json
+// would decode the given key into.
+func jsonFieldName(typ reflect.Type, key string) (string, bool) {
+	var folded string
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		name := field.Name
+		if tag := strings.Split(field.Tag.Get("json"), ",")[0]; tag == "-" {
+			continue
+		} else if tag != "" {
+			name = tag
+		}
+		if name == key {
+			return field.Name, true
+		}
+		if folded == "" && strings.EqualFold(name, key) {
+			folded = field.Name
+		}
+	}
+	return folded, folded != ""
+}
+
+// ParamTransform rewrites a raw query, form or route param value before it is
+// bound, e.g. to trim whitespace or map "null" to the empty string.  JSON
+// bodies are not transformed.
+type ParamTransform func(name, value string) string
+
+var paramTransforms []ParamTransform
+
+// RegisterParamTransform adds a ParamTransform applied by ParseParams.
+// Transforms are run in the order they are registered, each receiving the
+// output of the previous one.
+func RegisterParamTransform(transform ParamTransform) {
+	paramTransforms = append(paramTransforms, transform)
+}
+
+// transformParams returns a copy of values with the registered transforms
+// applied, or values itself if there are none.
+func transformParams(values url.Values) url.Values {
+	if len(paramTransforms) == 0 {
+		return values
+	}
+	transformed := make(url.Values, len(values))
+	for name, vals := range values {
+		tvals := make([]string, len(vals))
+		for i, val := range vals {
+			for _, transform := range paramTransforms {
+				val = transform(name, val)
+			}
+			tvals[i] = val
+		}
+		transformed[name] = tvals
+	}
+	return transformed
 }
 
 // calcValues returns a unified view of the component param maps.
@@ -109,8 +997,55 @@ func (p *Params) calcValues() url.Values {
 	return values
 }
 
+// ParamsFilter parses the request params into c.Params.  Bodies of a content
+// type it does not recognize (and that has no BodyParser) are treated as JSON
+// if they start with "{" or "[" and "params.json.sniff" is set, for clients
+// that send JSON as e.g. text/plain.  It may be set per controller or action,
+// e.g. "params.json.sniff.Webhooks.Receive = true".
+//
+// Multipart bodies are left for the action to parse with Params.ParseMultipart
+// if "params.multipart.stream" is set, likewise per controller or action, so
+// that it may stream the uploaded files (see Params.StreamFileTo).
 func ParamsFilter(c *Controller, fc []Filter) {
-	ParseParams(c.Params, c.Request)
+	sniff := CurrentConfig().BoolDefault("params.json.sniff", false)
+	stream := CurrentConfig().BoolDefault("params.multipart.stream", false)
+	if c.Name != "" {
+		sniff = CurrentConfig().BoolDefault("params.json.sniff."+c.Name, sniff)
+		sniff = CurrentConfig().BoolDefault("params.json.sniff."+c.Action, sniff)
+		stream = CurrentConfig().BoolDefault("params.multipart.stream."+c.Name, stream)
+		stream = CurrentConfig().BoolDefault("params.multipart.stream."+c.Action, stream)
+	}
+	c.Params.sniffJSON = sniff
+	c.Params.streamMultipart = stream
+	if err := ParseParams(c.Params, c.Request); errors.Is(err, ErrBodyReadTimeout) {
+		c.Response.Status = http.StatusRequestTimeout
+		c.Result = c.RenderError(&Error{
+			Title:       "Request Timeout",
+			Description: err.Error(),
+		})
+		return
+	} else if err != nil && c.Request.bodyTooLarge(err) {
+		c.Response.Status = http.StatusRequestEntityTooLarge
+		c.Result = c.RenderError(&Error{
+			Title:       "Request Entity Too Large",
+			Description: err.Error(),
+		})
+		return
+	} else if errors.Is(err, ErrInvalidUTF8) || errors.Is(err, ErrTooManyParams) || errors.Is(err, ErrParamTooLong) {
+		c.Response.Status = http.StatusBadRequest
+		c.Result = c.RenderError(&Error{
+			Title:       "Bad Request",
+			Description: err.Error(),
+		})
+		return
+	} else if errors.Is(err, ErrUnsupportedContentEncoding) {
+		c.Response.Status = http.StatusUnsupportedMediaType
+		c.Result = c.RenderError(&Error{
+			Title:       "Unsupported Media Type",
+			Description: err.Error(),
+		})
+		return
+	}
 
 	// Clean up from the request.
 	defer func() {
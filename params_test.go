@@ -2,12 +2,20 @@ package revel
 
 import (
 	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"mime/multipart"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 )
 
 // Params: Testing Multipart forms
@@ -120,6 +128,820 @@ func TestMultipartForm(t *testing.T) {
 	}
 }
 
+func TestMultipartStream(t *testing.T) {
+	startFakeBookingApp()
+
+	var file2, file3 bytes.Buffer
+	var files map[string][]fh
+	var parseErr, secondErr error
+	c := NewController(NewRequest(getMultipartRequest()), NewResponse(httptest.NewRecorder()))
+	c.Name, c.Action = "Hotels", "Hotels.Book"
+	Config.SetOption("params.multipart.stream.Hotels.Book", "true")
+	defer Config.SetOption("params.multipart.stream.Hotels.Book", "false")
+	ParamsFilter(c, []Filter{func(c *Controller, _ []Filter) {
+		if len(c.Params.Form) != 0 || len(c.Params.Files) != 0 {
+			t.Errorf("Expected the body to be left unparsed, got %v %v", c.Params.Form, c.Params.Files)
+		}
+		c.Params.StreamFileTo("file2[]", &file2)
+		c.Params.StreamFileTo("file3[0]", &file3)
+		c.Params.StreamFileTo("text1", &file3) // Not a file, parsed as usual.
+		parseErr = c.Params.ParseMultipart()
+		secondErr = c.Params.ParseMultipart()
+
+		files = make(map[string][]fh)
+		for key, fileHeaders := range c.Params.Files {
+			for _, fileHeader := range fileHeaders {
+				file, _ := fileHeader.Open()
+				content, _ := ioutil.ReadAll(file)
+				files[key] = append(files[key], fh{fileHeader.Filename, content})
+			}
+		}
+	}})
+
+	if parseErr != nil {
+		t.Fatal(parseErr)
+	}
+	if secondErr != ErrMultipartParsed {
+		t.Errorf("Expected ErrMultipartParsed parsing twice, got %v", secondErr)
+	}
+	eq(t, "streamed files", file2.String(), "content2xyz")
+	eq(t, "streamed indexed file", file3.String(), "content3")
+	if !reflect.DeepEqual(expectedValues, map[string][]string(c.Params.Values)) {
+		t.Errorf("Param values: (expected) %v != %v (actual)", expectedValues, c.Params.Values)
+	}
+	expected := map[string][]fh{
+		"file1":    expectedFiles["file1"],
+		"file3[1]": expectedFiles["file3[1]"],
+	}
+	if !reflect.DeepEqual(expected, files) {
+		t.Errorf("Param files: (expected) %v != %v (actual)", expected, files)
+	}
+
+	c = NewController(NewRequest(getMultipartRequest()), NewResponse(httptest.NewRecorder()))
+	c.Name, c.Action = "Hotels", "Hotels.Show"
+	ParamsFilter(c, NilChain)
+	if len(c.Params.Files) != 4 {
+		t.Errorf("Expected the body to be parsed for other actions, got %v", c.Params.Files)
+	}
+	if err := c.Params.ParseMultipart(); err != ErrMultipartParsed {
+		t.Errorf("Expected ErrMultipartParsed, got %v", err)
+	}
+}
+
+// Multipart text fields are bound in the same way as urlencoded ones.
+func TestMultipartFormBindParity(t *testing.T) {
+	fields := url.Values{
+		"a.Id":           {"3"},
+		"a.Name":         {"rob"},
+		"a.B.Extra":      {"nested"},
+		"ids[]":          {"1", "2"},
+		"tags[]":         {"x", "y"},
+		"filter[status]": {"open"},
+		"q":              {"hotel"},
+	}
+
+	urlencoded, _ := http.NewRequest("POST", "/hotels?page=2", strings.NewReader(fields.Encode()))
+	urlencoded.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	for name, values := range fields {
+		for _, value := range values {
+			writer.WriteField(name, value)
+		}
+	}
+	file, _ := writer.CreateFormFile("upload", "test.txt")
+	file.Write([]byte("content"))
+	writer.Close()
+	multipartReq, _ := http.NewRequest("POST", "/hotels?page=2", &body)
+	multipartReq.Header.Set("Content-Type", writer.FormDataContentType())
+
+	type search struct {
+		Query  string            `param:"q"`
+		Page   int               `param:"page"`
+		Tags   []string          `param:"tags"`
+		Filter map[string]string `param:"filter"`
+	}
+	type bound struct {
+		values url.Values
+		a      A
+		ids    []int
+		tags   []string
+		filter map[string]string
+		form   search
+	}
+	bind := func(req *http.Request) bound {
+		params := &Params{}
+		if err := ParseParams(params, NewRequest(req)); err != nil {
+			t.Fatal(err)
+		}
+		var b bound
+		b.values = params.Values
+		params.Bind(&b.a, "a")
+		params.Bind(&b.ids, "ids")
+		params.Bind(&b.tags, "tags")
+		params.Bind(&b.filter, "filter")
+		if errs := params.BindForm(&b.form); len(errs) != 0 {
+			t.Errorf("Unexpected bind errors: %v", errs)
+		}
+		return b
+	}
+
+	expected, actual := bind(urlencoded), bind(multipartReq)
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("Multipart fields bound differently:\n urlencoded %+v\n multipart  %+v", expected, actual)
+	}
+	if expected.a.Id != 3 || expected.a.Name != "rob" || expected.a.B.Extra != "nested" ||
+		len(expected.ids) != 2 || expected.ids[1] != 2 || expected.filter["status"] != "open" ||
+		expected.form.Query != "hotel" || expected.form.Page != 2 || len(expected.form.Tags) != 2 {
+		t.Errorf("Unexpected bound values: %+v", expected)
+	}
+	if page := expected.values["page"]; len(page) != 1 {
+		t.Errorf("Expected the query string to be merged once, got %v", page)
+	}
+}
+
+func TestParamsBodyReadTimeout(t *testing.T) {
+	startFakeBookingApp()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	<-ctx.Done()
+
+	newRequest := func() *Request {
+		req, _ := http.NewRequest("POST", "/hotels/3", strings.NewReader("a=1&b=2"))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		return NewRequest(req.WithContext(ctx))
+	}
+
+	if err := ParseParams(&Params{}, newRequest()); err != ErrBodyReadTimeout {
+		t.Errorf("Expected ErrBodyReadTimeout, got %v", err)
+	}
+
+	resp := httptest.NewRecorder()
+	c := NewController(newRequest(), NewResponse(resp))
+	ParamsFilter(c, []Filter{func(c *Controller, fc []Filter) {
+		t.Error("Expected the filter chain to stop on a body read timeout")
+	}})
+	if c.Response.Status != http.StatusRequestTimeout {
+		t.Errorf("Expected status %d, got %d", http.StatusRequestTimeout, c.Response.Status)
+	}
+}
+
+func TestJSONBody(t *testing.T) {
+	req, _ := http.NewRequest("POST", "/hotels/3", strings.NewReader(`{"Id":3,"Name":"rob"}`))
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	params := &Params{}
+	if err := ParseParams(params, NewRequest(req)); err != nil {
+		t.Fatal(err)
+	}
+
+	var a A
+	if err := params.BindJSON(&a); err != nil {
+		t.Fatal(err)
+	}
+	if a.Id != 3 || a.Name != "rob" {
+		t.Errorf("Failed to bind JSON body: %+v", a)
+	}
+}
+
+func TestJSONBodyTrailingData(t *testing.T) {
+	defer func() { bindJSONLenient = false }()
+	for _, lenient := range []bool{false, true} {
+		bindJSONLenient = lenient
+		for _, body := range []string{`{"Id":1}{"Id":2}`, `{"Id":1} x`, `[1] 2`} {
+			params := &Params{JSON: []byte(body)}
+			var a A
+			if err := params.BindJSON(&a); err == nil {
+				t.Errorf("Expected trailing data to be rejected by BindJSON (lenient %v): %q", lenient, body)
+			}
+			var doc interface{}
+			if err := params.DecodeJSONNumber(&doc); err == nil {
+				t.Errorf("Expected trailing data to be rejected by DecodeJSONNumber (lenient %v): %q", lenient, body)
+			}
+		}
+		params := &Params{JSON: []byte(" {\"Id\":1} \n\t")}
+		var a A
+		if err := params.BindJSON(&a); err != nil || a.Id != 1 {
+			t.Errorf("Expected trailing whitespace to be allowed (lenient %v), got %+v, %v", lenient, a, err)
+		}
+	}
+}
+
+func TestParamsBodyIgnored(t *testing.T) {
+	defer func(ignored map[string]bool) { paramsBodyIgnored = ignored }(paramsBodyIgnored)
+
+	parse := func(method string) (*Params, *http.Request) {
+		req, _ := http.NewRequest(method, "/hotels/3?q=rob", strings.NewReader(`{"Id":3}`))
+		req.Header.Set("Content-Type", "application/json")
+		params := &Params{}
+		if err := ParseParams(params, NewRequest(req)); err != nil {
+			t.Fatal(err)
+		}
+		return params, req
+	}
+
+	for _, method := range []string{"GET", "HEAD", "DELETE"} {
+		params, req := parse(method)
+		if params.JSON != nil || params.Get("q") != "rob" {
+			t.Errorf("%s: expected only the query to be parsed, got %q %v", method, params.JSON, params.Values)
+		}
+		if body, _ := ioutil.ReadAll(req.Body); string(body) != `{"Id":3}` {
+			t.Errorf("%s: expected the body to be left unread, got %q", method, body)
+		}
+	}
+	if params, _ := parse("POST"); string(params.JSON) != `{"Id":3}` {
+		t.Errorf("Expected the POST body to be parsed, got %q", params.JSON)
+	}
+
+	paramsBodyIgnored = map[string]bool{}
+	if params, _ := parse("GET"); string(params.JSON) != `{"Id":3}` {
+		t.Errorf("Expected the GET body to be parsed when configured, got %q", params.JSON)
+	}
+}
+
+func TestParamsBodyIgnoredParser(t *testing.T) {
+	defer delete(BodyParsers, "text/csv")
+	var parsed []string
+	BodyParsers["text/csv"] = func(params *Params, req *Request) error {
+		parsed = append(parsed, req.Method)
+		return nil
+	}
+
+	for _, method := range []string{"GET", "POST"} {
+		req, _ := http.NewRequest(method, "/hotels", strings.NewReader("id\n3\n"))
+		req.Header.Set("Content-Type", "text/csv")
+		if err := ParseParams(&Params{}, NewRequest(req)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(parsed) != 1 || parsed[0] != "POST" {
+		t.Errorf("Expected the body parser to be skipped for GET, got %v", parsed)
+	}
+}
+
+func TestParamsJSONSniff(t *testing.T) {
+	startFakeBookingApp()
+
+	parse := func(action, contentType, body string) (*Controller, string) {
+		req, _ := http.NewRequest("POST", "/hotels", strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		c := NewController(NewRequest(req), NewResponse(httptest.NewRecorder()))
+		c.Name, c.Action = "Hotels", action
+		ParamsFilter(c, NilChain)
+		rest, _ := ioutil.ReadAll(req.Body)
+		return c, string(rest)
+	}
+
+	if c, rest := parse("Hotels.Book", "text/plain", `{"Id":3}`); c.Params.JSON != nil || rest != `{"Id":3}` {
+		t.Errorf("Expected no sniffing by default, got %q (unread %q)", c.Params.JSON, rest)
+	}
+
+	Config.SetOption("params.json.sniff.Hotels.Book", "true")
+	defer Config.SetOption("params.json.sniff.Hotels.Book", "false")
+	for _, body := range []string{`{"Id":3}`, " \n\t[1, 2]"} {
+		if c, _ := parse("Hotels.Book", "text/plain", body); string(c.Params.JSON) != body {
+			t.Errorf("Expected %q to be sniffed as JSON, got %q", body, c.Params.JSON)
+		}
+	}
+	for _, body := range []string{"Id=3", "", "   "} {
+		if c, rest := parse("Hotels.Book", "text/plain", body); c.Params.JSON != nil || rest != body {
+			t.Errorf("Expected %q not to be sniffed as JSON, got %q (unread %q)", body, c.Params.JSON, rest)
+		}
+	}
+	if c, _ := parse("Hotels.Book", "application/x-www-form-urlencoded", `{"Id":3}`); c.Params.JSON != nil {
+		t.Errorf("Expected a recognized content type not to be sniffed, got %q", c.Params.JSON)
+	}
+	if c, _ := parse("Hotels.Show", "text/plain", `{"Id":3}`); c.Params.JSON != nil {
+		t.Errorf("Expected sniffing only for the configured action, got %q", c.Params.JSON)
+	}
+}
+
+// truncatedReader returns its data, then fails as a dropped connection would.
+type truncatedReader struct {
+	data []byte
+}
+
+func (r *truncatedReader) Read(p []byte) (int, error) {
+	if len(r.data) == 0 {
+		return 0, io.ErrUnexpectedEOF
+	}
+	n := copy(p, r.data)
+	r.data = r.data[n:]
+	return n, nil
+}
+
+func TestJSONBodyTruncated(t *testing.T) {
+	defer func() { paramsJSONPartial = "reject" }()
+
+	parse := func() (*Params, error) {
+		body := &truncatedReader{[]byte(`{"Id":3,"Name":"ro`)}
+		req, _ := http.NewRequest("POST", "/hotels/3", body)
+		req.Header.Set("Content-Type", "application/json")
+		params := &Params{}
+		return params, ParseParams(params, NewRequest(req))
+	}
+
+	params, err := parse()
+	if err != io.ErrUnexpectedEOF {
+		t.Errorf("Expected the read error, got %v", err)
+	}
+	var a A
+	if err := params.BindJSON(&a); err != ErrIncompleteBody {
+		t.Errorf("Expected ErrIncompleteBody, got %v", err)
+	}
+	var id int
+	params.Bind(&id, "Id")
+	if id != 0 {
+		t.Errorf("Expected nothing to be bound from the truncated body, got %d", id)
+	}
+
+	// Kept partial bodies are decoded as they are.
+	paramsJSONPartial = "keep"
+	params, _ = parse()
+	if err := params.BindJSON(&a); err == nil || err == ErrIncompleteBody {
+		t.Errorf("Expected an unmarshal error, got %v", err)
+	}
+	if string(params.JSON) != `{"Id":3,"Name":"ro` {
+		t.Errorf("Expected the partial body to be kept, got %q", params.JSON)
+	}
+}
+
+func TestJSONBodyLenient(t *testing.T) {
+	defer func() { bindJSONLenient = false }()
+
+	type booking struct {
+		Id      string
+		Nights  int
+		Paid    bool
+		Price   *float64
+		Tags    []string
+		Extras  map[string]int
+		Created time.Time
+	}
+	params := &Params{JSON: []byte(`{"id":123,"nights":"3","paid":"true","price":"99.5",` +
+		`"tags":[1,true,"x"],"extras":{"beds":"2"},"created":"2020-01-02T00:00:00Z"}`)}
+
+	var b booking
+	if err := params.BindJSON(&b); err == nil {
+		t.Errorf("Expected mismatched types to be rejected by default, got %+v", b)
+	}
+
+	bindJSONLenient = true
+	b = booking{}
+	if err := params.BindJSON(&b); err != nil {
+		t.Fatal(err)
+	}
+	if b.Id != "123" || b.Nights != 3 || !b.Paid || b.Price == nil || *b.Price != 99.5 ||
+		!reflect.DeepEqual(b.Tags, []string{"1", "true", "x"}) || b.Extras["beds"] != 2 || b.Created.Year() != 2020 {
+		t.Errorf("Failed to bind JSON body leniently: %+v", b)
+	}
+
+	// Strings that are not numbers are still rejected.
+	params.JSON = []byte(`{"nights":"three"}`)
+	if err := params.BindJSON(&b); err == nil {
+		t.Error("Expected a non-numeric string to be rejected")
+	}
+	params.JSON = []byte(`{"nights":"NaN"}`)
+	if err := params.BindJSON(&b); err == nil {
+		t.Error("Expected NaN to be rejected")
+	}
+}
+
+func TestDecodeJSONNumber(t *testing.T) {
+	params := &Params{JSON: []byte(`{"amount":0.10000000000000000001,"id":9007199254740993}`)}
+
+	var doc map[string]interface{}
+	if err := params.BindJSON(&doc); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := doc["amount"].(float64); !ok {
+		t.Errorf("Expected BindJSON to decode numbers as float64, got %T", doc["amount"])
+	}
+
+	doc = nil
+	if err := params.DecodeJSONNumber(&doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc["amount"] != json.Number("0.10000000000000000001") || doc["id"] != json.Number("9007199254740993") {
+		t.Errorf("Expected the numbers to be kept exact, got %v", doc)
+	}
+
+	var payment struct {
+		Amount json.Number
+	}
+	if err := params.BindJSON(&payment); err != nil || payment.Amount != "0.10000000000000000001" {
+		t.Errorf("Expected the json.Number field to be exact, got %q (%v)", payment.Amount, err)
+	}
+
+	params.JSON = []byte(`{"amount":1} x`)
+	if err := params.DecodeJSONNumber(&doc); err == nil {
+		t.Error("Expected trailing data to be rejected")
+	}
+}
+
+func TestParamTransforms(t *testing.T) {
+	defer func(transforms []ParamTransform) { paramTransforms = transforms }(paramTransforms)
+	RegisterParamTransform(func(name, value string) string { return strings.TrimSpace(value) })
+	RegisterParamTransform(func(name, value string) string {
+		if value == "null" {
+			return ""
+		}
+		return value
+	})
+
+	req, _ := http.NewRequest("POST", "/hotels/3?q=+rob+", strings.NewReader("stars=+null&name=bob"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	params := &Params{Route: url.Values{"id": {" 3 "}}}
+	if err := ParseParams(params, NewRequest(req)); err != nil {
+		t.Fatal(err)
+	}
+	var id, stars int
+	params.Bind(&id, "id")
+	params.Bind(&stars, "stars")
+	if id != 3 || stars != 0 || params.Get("q") != "rob" || params.Get("name") != "bob" {
+		t.Errorf("Unexpected transformed params: %v", params.Values)
+	}
+	if params.Query.Get("q") != " rob " {
+		t.Errorf("Expected the raw query to be kept, got %q", params.Query.Get("q"))
+	}
+
+	// JSON bodies are left alone.
+	req, _ = http.NewRequest("POST", "/hotels/3", strings.NewReader(`{"Id":3,"Name":" rob "}`))
+	req.Header.Set("Content-Type", "application/json")
+	params = &Params{}
+	if err := ParseParams(params, NewRequest(req)); err != nil {
+		t.Fatal(err)
+	}
+	var a A
+	if err := params.BindJSON(&a); err != nil || a.Name != " rob " {
+		t.Errorf("Expected the JSON body to be untransformed, got %+v (%v)", a, err)
+	}
+}
+
+func TestParamsInvalidUTF8(t *testing.T) {
+	startFakeBookingApp()
+	defer func() { paramsUTF8 = "accept" }()
+
+	parse := func() (*Params, error) {
+		req, _ := http.NewRequest("POST", "/hotels?q=caf%E9", strings.NewReader("name=ok&city=L%FFon"))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		params := &Params{}
+		return params, ParseParams(params, NewRequest(req))
+	}
+
+	if params, err := parse(); err != nil || params.Get("q") != "caf\xe9" {
+		t.Errorf("Expected invalid UTF-8 to be accepted, got %q (%v)", params.Get("q"), err)
+	}
+
+	paramsUTF8 = "replace"
+	if params, err := parse(); err != nil || params.Get("q") != "caf\uFFFD" || params.Get("city") != "L\uFFFDon" || params.Get("name") != "ok" {
+		t.Errorf("Expected invalid UTF-8 to be replaced, got %v (%v)", params.Values, err)
+	}
+
+	paramsUTF8 = "reject"
+	if _, err := parse(); err != ErrInvalidUTF8 {
+		t.Errorf("Expected ErrInvalidUTF8, got %v", err)
+	}
+	req, _ := http.NewRequest("GET", "/hotels?q=caf%E9", nil)
+	c := NewController(NewRequest(req), NewResponse(httptest.NewRecorder()))
+	ParamsFilter(c, NilChain)
+	if c.Response.Status != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, c.Response.Status)
+	}
+}
+
+func TestParamsMaxKeys(t *testing.T) {
+	defer func() { paramsQueryMaxKeys, paramsFormMaxKeys = 0, 0 }()
+	paramsQueryMaxKeys, paramsFormMaxKeys = 2, 1
+
+	parse := func(query, form string) (*Params, error) {
+		req, _ := http.NewRequest("POST", "/hotels?"+query, strings.NewReader(form))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		params := &Params{}
+		return params, ParseParams(params, NewRequest(req))
+	}
+
+	// Repeated keys count once.
+	if params, err := parse("a=1&b=2&a=3&b=4", "name=bob"); err != nil || len(params.Query["a"]) != 2 || params.Get("name") != "bob" {
+		t.Errorf("Expected the params to be parsed, got %v (%v)", params.Values, err)
+	}
+	if _, err := parse("a=1&b=2&c=3", ""); err != ErrTooManyParams {
+		t.Errorf("Expected ErrTooManyParams for the query, got %v", err)
+	}
+	if _, err := parse("", "name=bob&city=Lyon"); err != ErrTooManyParams {
+		t.Errorf("Expected ErrTooManyParams for the form, got %v", err)
+	}
+
+	// The rest of the form is not read.
+	body := strings.NewReader("name=bob&city=Lyon&bio=" + strings.Repeat("x", 1<<20))
+	req, _ := http.NewRequest("POST", "/hotels", body)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if err := ParseParams(&Params{}, NewRequest(req)); err != ErrTooManyParams || body.Len() < 1<<19 {
+		t.Errorf("Expected the form to be given up early, got %v with %d bytes left", err, body.Len())
+	}
+
+	var multipartBody bytes.Buffer
+	writer := multipart.NewWriter(&multipartBody)
+	writer.WriteField("name", "bob")
+	writer.WriteField("city", "Lyon")
+	writer.Close()
+	req, _ = http.NewRequest("POST", "/hotels", &multipartBody)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	if err := ParseParams(&Params{}, NewRequest(req)); err != ErrTooManyParams {
+		t.Errorf("Expected ErrTooManyParams for the multipart form, got %v", err)
+	}
+
+	req, _ = http.NewRequest("GET", "/hotels?a=1&b=2&c=3", nil)
+	c := NewController(NewRequest(req), NewResponse(httptest.NewRecorder()))
+	ParamsFilter(c, NilChain)
+	if c.Response.Status != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, c.Response.Status)
+	}
+}
+
+func TestParamsFormMaxValueLen(t *testing.T) {
+	defer func() { paramsFormMaxValueLen, paramsFormTooLong = 0, "reject" }()
+	paramsFormMaxValueLen = 4
+
+	parse := func(form string) (*Params, error) {
+		req, _ := http.NewRequest("POST", "/hotels", strings.NewReader(form))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		params := &Params{}
+		return params, ParseParams(params, NewRequest(req))
+	}
+
+	if params, err := parse("name=rob&city=Lyon"); err != nil || params.Get("city") != "Lyon" {
+		t.Errorf("Expected values within the limit to be kept, got %v (%v)", params.Values, err)
+	}
+	if _, err := parse("name=rob&bio=" + strings.Repeat("x", 1024)); err != ErrParamTooLong {
+		t.Errorf("Expected ErrParamTooLong, got %v", err)
+	}
+
+	// Values are not truncated in the middle of a character.
+	paramsFormTooLong = "truncate"
+	params, err := parse("name=rob&bio=" + strings.Repeat("x", 1024) + "&city=Orl%C3%A9ans")
+	if err != nil || params.Get("name") != "rob" || params.Get("bio") != "xxxx" || params.Get("city") != "Orl" {
+		t.Errorf("Expected the long values to be truncated, got %v (%v)", params.Values, err)
+	}
+	params, err = parse("bio=" + strings.Repeat("%C3%A9", 10) + "&name=rob")
+	if err != nil || params.Get("bio") != "éé" || params.Get("name") != "rob" {
+		t.Errorf("Expected the long escaped value to be truncated, got %v (%v)", params.Values, err)
+	}
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	writer.WriteField("bio", strings.Repeat("x", 1024))
+	writer.WriteField("city", "Orléans")
+	writer.Close()
+	req, _ := http.NewRequest("POST", "/hotels", &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	params = &Params{}
+	if err := ParseParams(params, NewRequest(req)); err != nil || params.Get("bio") != "xxxx" || params.Get("city") != "Orl" {
+		t.Errorf("Expected the long multipart values to be truncated, got %v (%v)", params.Values, err)
+	}
+
+	// A rejected value is not read to its end.
+	paramsFormTooLong = "reject"
+	form := strings.NewReader("name=rob&bio=" + strings.Repeat("x", 1<<20))
+	req, _ = http.NewRequest("POST", "/hotels", form)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if err := ParseParams(&Params{}, NewRequest(req)); err != ErrParamTooLong || form.Len() < 1<<19 {
+		t.Errorf("Expected the form to be given up early, got %v with %d bytes left", err, form.Len())
+	}
+}
+
+func TestParseQueryLimited(t *testing.T) {
+	for _, query := range []string{"", "a=1&b=%20x&a=2", "a&=b&&c=%zz&d=1;e=2&f=+"} {
+		expected, _ := url.ParseQuery(query)
+		if values, err := parseQueryLimited(query, 0); err != nil || !reflect.DeepEqual(values, expected) {
+			t.Errorf("%q: expected %v, got %v (%v)", query, expected, values, err)
+		}
+		if values, err := parseFormLimited(strings.NewReader(query), 0); err != nil || !reflect.DeepEqual(values, expected) {
+			t.Errorf("%q: expected the form %v, got %v (%v)", query, expected, values, err)
+		}
+	}
+}
+
+func TestRawBodyParser(t *testing.T) {
+	startFakeBookingApp()
+	BodyParsers["application/x-raw"] = ParseRawBody
+	defer delete(BodyParsers, "application/x-raw")
+
+	req, _ := http.NewRequest("POST", "/hotels/3", strings.NewReader("\x0a\x03rob"))
+	req.Header.Set("Content-Type", "application/x-raw")
+	params := &Params{}
+	if err := ParseParams(params, NewRequest(req)); err != nil {
+		t.Fatal(err)
+	}
+	eq(t, "raw body", string(params.RawBody), "\x0a\x03rob")
+
+	// The body size is limited as for any other body.
+	Config.SetOption("http.maxdecompressedsize", "4")
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	w.Write([]byte("\x0a\x06robert"))
+	w.Close()
+	req, _ = http.NewRequest("POST", "/hotels/3", &buf)
+	req.Header.Set("Content-Type", "application/x-raw")
+	req.Header.Set("Content-Encoding", "gzip")
+	r := NewRequest(req)
+	if err := ParseParams(&Params{}, r); !r.bodyTooLarge(err) {
+		t.Errorf("Expected the raw body to be too large, got %v", err)
+	}
+}
+
+func TestApplyMergePatch(t *testing.T) {
+	type address struct {
+		City    string `json:"city"`
+		Country string `json:"country"`
+	}
+	type hotel struct {
+		Name    string                 `json:"name"`
+		Stars   int                    `json:"stars"`
+		Tags    []string               `json:"tags"`
+		Address *address               `json:"address"`
+		Prices  map[string]int         `json:"prices"`
+		Extra   map[string]interface{} `json:"extra"`
+		Notes   string                 `json:"-"`
+	}
+
+	model := hotel{
+		Name:    "Hilton",
+		Stars:   4,
+		Tags:    []string{"pool", "spa"},
+		Address: &address{City: "Paris", Country: "FR"},
+		Prices:  map[string]int{"single": 100, "double": 150},
+		Extra:   map[string]interface{}{"wifi": true, "parking": map[string]interface{}{"spots": 10.0, "paid": true}},
+		Notes:   "kept",
+	}
+	params := &Params{JSON: []byte(`{
+		"stars": 5,
+		"tags": ["gym"],
+		"address": {"city": "Lyon"},
+		"prices": {"single": null, "suite": 300},
+		"extra": {"wifi": null, "parking": {"paid": null}, "pets": {"dogs": true, "cats": null}},
+		"notes": "ignored",
+		"unknown": 1
+	}`)}
+	if err := params.ApplyMergePatch(&model); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := hotel{
+		Name:    "Hilton",
+		Stars:   5,
+		Tags:    []string{"gym"},
+		Address: &address{City: "Lyon", Country: "FR"},
+		Prices:  map[string]int{"double": 150, "suite": 300},
+		Extra:   map[string]interface{}{"parking": map[string]interface{}{"spots": 10.0}, "pets": map[string]interface{}{"dogs": true}},
+		Notes:   "kept",
+	}
+	if !reflect.DeepEqual(model, expected) {
+		t.Errorf("Unexpected merge result:\n%+v\nexpected:\n%+v", model, expected)
+	}
+
+	// A null member zeroes a struct field.
+	params = &Params{JSON: []byte(`{"address": null, "tags": null}`)}
+	if err := params.ApplyMergePatch(&model); err != nil || model.Address != nil || model.Tags != nil {
+		t.Errorf("Expected address and tags to be removed, got %+v (%v)", model, err)
+	}
+
+	if err := params.ApplyMergePatch(model); err == nil {
+		t.Error("Expected an error for a non-pointer target")
+	}
+	if err := (&Params{JSON: []byte(`{"stars": "five"}`)}).ApplyMergePatch(&model); err == nil {
+		t.Error("Expected an error for a mistyped member")
+	}
+
+	// A patch that fails leaves the value, and those it points to, as they were.
+	model = hotel{Name: "Hilton", Address: &address{City: "Paris"}, Prices: map[string]int{"single": 100}}
+	params = &Params{JSON: []byte(`{"name": "Ibis", "address": {"city": "Lyon"}, "prices": {"single": 50, "double": "x"}, "stars": "five"}`)}
+	if err := params.ApplyMergePatch(&model); err == nil {
+		t.Error("Expected an error for mistyped members")
+	}
+	expected = hotel{Name: "Hilton", Address: &address{City: "Paris"}, Prices: map[string]int{"single": 100}}
+	if !reflect.DeepEqual(model, expected) {
+		t.Errorf("Expected the failed patch not to be applied, got %+v", model)
+	}
+}
+
+func TestCompressedBody(t *testing.T) {
+	startFakeBookingApp()
+
+	gzipped := func(body string) *bytes.Buffer {
+		var buf bytes.Buffer
+		w := gzip.NewWriter(&buf)
+		w.Write([]byte(body))
+		w.Close()
+		return &buf
+	}
+
+	req, _ := http.NewRequest("POST", "/hotels/3", gzipped("name=rob&stars=5"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Content-Encoding", "gzip")
+	params := &Params{}
+	if err := ParseParams(params, NewRequest(req)); err != nil {
+		t.Fatal(err)
+	}
+	if params.Get("name") != "rob" || params.Get("stars") != "5" {
+		t.Errorf("Failed to parse gzipped form: %v", params.Values)
+	}
+
+	// Bodies that expand past the limit are rejected.
+	Config.SetOption("http.maxdecompressedsize", "1024")
+	req, _ = http.NewRequest("POST", "/hotels/3", gzipped(`{"Name":"`+strings.Repeat("a", 4096)+`"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-Encoding", "gzip")
+	r := NewRequest(req)
+	if err := ParseParams(&Params{}, r); !r.bodyTooLarge(err) {
+		t.Errorf("Expected the decompressed body to be too large, got %v", err)
+	}
+
+	req, _ = http.NewRequest("POST", "/hotels/3", strings.NewReader("name=rob"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Content-Encoding", "br")
+	if err := ParseParams(&Params{}, NewRequest(req)); err != ErrUnsupportedContentEncoding {
+		t.Errorf("Expected ErrUnsupportedContentEncoding, got %v", err)
+	}
+}
+
+func TestBindPresent(t *testing.T) {
+	type patch struct {
+		Id    int
+		Name  string `json:"name"`
+		Email string `json:"email,omitempty"`
+		Extra B
+	}
+
+	// JSON bodies only overwrite the keys that were sent.
+	params := &Params{JSON: []byte(`{"name":"bob","EMAIL":"bob@example.com"}`)}
+	model := patch{Id: 3, Name: "rob", Email: "rob@example.com"}
+	changed := params.BindPresent(&model)
+	if !reflect.DeepEqual(changed, map[string]bool{"Name": true, "Email": true}) {
+		t.Errorf("Unexpected changed fields from JSON: %v", changed)
+	}
+	if model.Id != 3 || model.Name != "bob" || model.Email != "bob@example.com" {
+		t.Errorf("Unexpected model after JSON patch: %+v", model)
+	}
+
+	// Form params
+	params = &Params{Values: url.Values{"Name": {"bill"}, "Extra.Extra": {"x"}}}
+	model = patch{Id: 3, Name: "rob", Email: "rob@example.com"}
+	changed = params.BindPresent(&model)
+	if !reflect.DeepEqual(changed, map[string]bool{"Name": true, "Extra": true}) {
+		t.Errorf("Unexpected changed fields from form: %v", changed)
+	}
+	if model.Id != 3 || model.Name != "bill" || model.Email != "rob@example.com" || model.Extra.Extra != "x" {
+		t.Errorf("Unexpected model after form patch: %+v", model)
+	}
+}
+
+func TestBindForm(t *testing.T) {
+	type search struct {
+		Query    string `param:"q"`
+		Page     int    `param:"page"`
+		PerPage  uint
+		MinPrice float64 `param:"min_price"`
+		Since    time.Time
+		Sort     string `param:"sort,required"`
+		Admin    bool   `param:"-"`
+		Extra    B
+		internal string
+	}
+
+	params := &Params{Values: url.Values{
+		"q":           {"hotel"},
+		"page":        {"two"},
+		"PerPage":     {"0"},
+		"min_price":   {"12.5"},
+		"Since":       {"yesterday"},
+		"Admin":       {"true"},
+		"Extra.Extra": {"x"},
+	}}
+	model := search{Page: 1, internal: "kept"}
+	errs := params.BindForm(&model)
+
+	if model.Query != "hotel" || model.PerPage != 0 || model.MinPrice != 12.5 || model.Extra.Extra != "x" {
+		t.Errorf("Unexpected bound model: %+v", model)
+	}
+	if model.Page != 0 || !model.Since.IsZero() {
+		t.Errorf("Expected the malformed fields to be zeroed: %+v", model)
+	}
+	if model.Admin || model.internal != "kept" {
+		t.Errorf("Expected excluded and unexported fields to be left alone: %+v", model)
+	}
+
+	if len(errs) != 3 {
+		t.Fatalf("Expected 3 bind errors, got %v", errs)
+	}
+	for i, expected := range []BindError{{Field: "Page", Param: "page", Value: "two"}, {Field: "Since", Param: "Since", Value: "yesterday"}, {Field: "Sort", Param: "sort", Err: ErrParamRequired}} {
+		err, ok := errs[i].(*BindError)
+		if !ok || err.Field != expected.Field || err.Param != expected.Param || err.Value != expected.Value || err.Err == nil || (expected.Err != nil && err.Err != expected.Err) {
+			t.Errorf("Unexpected bind error %d: %v", i, errs[i])
+		}
+	}
+}
+
 func TestBind(t *testing.T) {
 	params := Params{
 		Values: url.Values{
@@ -164,6 +986,33 @@ func TestResolveAcceptLanguage(t *testing.T) {
 	}
 }
 
+func TestPreferredLanguage(t *testing.T) {
+	startFakeBookingApp()
+	Config.SetOption(defaultLanguageOption, "fr")
+
+	supported := []string{"en", "en-US", "nl-NL", "fr"}
+	for header, expected := range map[string]string{
+		"en-US,en;q=0.8":            "en-US",
+		"en-GB,de;q=0.9":            "en",
+		"nl, en;q=0.5":              "nl-NL",
+		"NL-be;q=0.9,de":            "nl-NL",
+		"de,*;q=0.1":                "en",
+		"en;q=0,de":                 "fr",
+		"":                          "fr",
+		"da, en-gb;q=0.8, en;q=0.7": "en",
+	} {
+		c := NewController(NewRequest(buildHttpRequestWithAcceptLanguage(header)), nil)
+		if actual := c.PreferredLanguage(supported); actual != expected {
+			t.Errorf("Expected %q to prefer %s, got %s", header, expected, actual)
+		}
+	}
+
+	c := NewController(NewRequest(buildHttpRequestWithAcceptLanguage("de")), nil)
+	if actual := c.PreferredLanguage([]string{"es", "it"}); actual != "es" {
+		t.Errorf("Expected the first supported language, got %s", actual)
+	}
+}
+
 func BenchmarkResolveAcceptLanguage(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		request := buildHttpRequestWithAcceptLanguage("en-GB,en;q=0.8,nl;q=0.6,fr;q=0.5,de-DE;q=0.4,no-NO;q=0.4,ru;q=0.2")
@@ -0,0 +1,72 @@
+package revel
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+// precompressedEncodings are the encodings of pre-compressed files, in order
+// of preference, with the extension added to the file name.
+var precompressedEncodings = []struct {
+	encoding, ext string
+}{
+	{"br", ".br"},
+	{"gzip", ".gz"},
+}
+
+// openPrecompressed opens the pre-compressed variant of the named file that
+// the request accepts, e.g. "app.css.br" for "app.css", and returns it with
+// its encoding.  The file is nil if the request accepts none of the variants.
+// It returns false if the file has no variants at all.
+func openPrecompressed(req *Request, name string) (*os.File, string, bool) {
+	info, err := os.Stat(name)
+	if err != nil {
+		return nil, "", false
+	}
+
+	accept := req.Header.Get("Accept-Encoding")
+	found := false
+	for _, variant := range precompressedEncodings {
+		variantInfo, err := os.Stat(name + variant.ext)
+		if err != nil || variantInfo.IsDir() {
+			continue
+		}
+		found = true
+		// A variant older than the file is out of date.
+		if variantInfo.ModTime().Before(info.ModTime()) || !acceptsEncoding(accept, variant.encoding) {
+			continue
+		}
+		if file, err := os.Open(name + variant.ext); err == nil {
+			return file, variant.encoding, true
+		}
+	}
+	return nil, "", found
+}
+
+// acceptsEncoding returns true if the given Accept-Encoding header allows the
+// encoding, by name or with "*", with a non-zero quality.
+func acceptsEncoding(accept, encoding string) bool {
+	accepted := false
+	for _, part := range strings.Split(accept, ",") {
+		params := strings.Split(part, ";")
+		name := strings.ToLower(strings.TrimSpace(params[0]))
+		if name != encoding && name != "*" {
+			continue
+		}
+		q := 1.0
+		for _, param := range params[1:] {
+			if param = strings.TrimSpace(param); strings.HasPrefix(param, "q=") {
+				if value, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					q = value
+				}
+			}
+		}
+		if name == encoding {
+			// An explicit quality overrides "*".
+			return q > 0
+		}
+		accepted = q > 0
+	}
+	return accepted
+}
@@ -0,0 +1,60 @@
+// Package protobuf renders and binds protobuf messages in the binary wire
+// format.  Importing it registers the application/x-protobuf and
+// application/protobuf request bodies with revel.BodyParsers:
+//
+//	import "github.com/revel/revel/protobuf"
+//
+//	func (c Api) Update() revel.Result {
+//	  var msg pb.Hotel
+//	  if err := protobuf.Bind(c.Params, &msg); err != nil {
+//	    return c.RenderError(err)
+//	  }
+//	  return protobuf.Render(c.Controller, &msg)
+//	}
+package protobuf
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/revel/revel"
+	"google.golang.org/protobuf/proto"
+)
+
+func init() {
+	revel.BodyParsers["application/x-protobuf"] = revel.ParseRawBody
+	revel.BodyParsers["application/protobuf"] = revel.ParseRawBody
+}
+
+// Result writes a protobuf message in the binary wire format.
+type Result struct {
+	msg proto.Message
+}
+
+// Render renders a protobuf message, as application/x-protobuf.
+func Render(c *revel.Controller, msg proto.Message) revel.Result {
+	if c.Response.Status == 0 {
+		c.Response.Status = http.StatusOK
+	}
+	return Result{msg}
+}
+
+func (r Result) Apply(req *revel.Request, resp *revel.Response) {
+	b, err := proto.Marshal(r.msg)
+	if err != nil {
+		revel.ErrorResult{Error: err}.Apply(req, resp)
+		return
+	}
+
+	resp.WriteHeader(http.StatusOK, "application/x-protobuf")
+	resp.Out.Write(b)
+}
+
+// Bind decodes the protobuf request body into msg.  Returns an error if the
+// request had no protobuf body or it could not be decoded.
+func Bind(params *revel.Params, msg proto.Message) error {
+	if params.RawBody == nil {
+		return errors.New("revel/protobuf: no protobuf body to bind")
+	}
+	return proto.Unmarshal(params.RawBody, msg)
+}
@@ -0,0 +1,50 @@
+package protobuf
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/revel/config"
+	"github.com/revel/revel"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestBind(t *testing.T) {
+	revel.Config = config.NewContext()
+
+	req, _ := http.NewRequest("POST", "/hotels/3", strings.NewReader("\x0a\x03rob"))
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	params := &revel.Params{}
+	if err := revel.ParseParams(params, revel.NewRequest(req)); err != nil {
+		t.Fatal(err)
+	}
+	var msg wrapperspb.StringValue
+	if err := Bind(params, &msg); err != nil || msg.GetValue() != "rob" {
+		t.Errorf("Failed to bind protobuf body: %q (%v)", msg.GetValue(), err)
+	}
+
+	if err := Bind(&revel.Params{}, &msg); err == nil {
+		t.Error("Expected an error without a protobuf body")
+	}
+}
+
+func TestRender(t *testing.T) {
+	revel.Config = config.NewContext()
+
+	req, _ := http.NewRequest("GET", "/hotels/3", nil)
+	resp := httptest.NewRecorder()
+	c := revel.NewController(revel.NewRequest(req), revel.NewResponse(resp))
+	Render(c, wrapperspb.String("rob")).Apply(c.Request, c.Response)
+
+	if resp.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", resp.Code)
+	}
+	if contentType := resp.Header().Get("Content-Type"); contentType != "application/x-protobuf" {
+		t.Errorf("Expected application/x-protobuf, got %q", contentType)
+	}
+	if body := resp.Body.String(); body != "\x0a\x03rob" {
+		t.Errorf("Expected the encoded message, got %q", body)
+	}
+}
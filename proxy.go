@@ -0,0 +1,108 @@
+package revel
+
+import (
+	"io"
+	"net/http"
+	"strings"
+)
+
+// ProxyOptions control how RenderProxy forwards an upstream response.
+type ProxyOptions struct {
+	// Headers are the upstream headers forwarded, e.g. "Content-Type" and
+	// "Cache-Control".  All of them are forwarded if empty.  Hop-by-hop
+	// headers (e.g. Connection, Transfer-Encoding) are never forwarded.
+	Headers []string
+
+	// Status, if set, replaces the upstream status code.
+	Status int
+}
+
+// hopByHopHeaders are the headers that only apply to a single connection
+// (RFC 7230, section 6.1), in addition to those listed in Connection.
+var hopByHopHeaders = []string{
+	"Connection",
+	"Proxy-Connection",
+	"Keep-Alive",
+	"Proxy-Authenticate",
+	"Proxy-Authorization",
+	"Te",
+	"Trailer",
+	"Transfer-Encoding",
+	"Upgrade",
+}
+
+// RenderProxyResult forwards an upstream response: its status, headers and
+// body.  The body is streamed rather than buffered, and flushed as it is
+// received when the upstream did not send a Content-Length (e.g. an event
+// stream).  The upstream body is closed once it has been forwarded.
+type RenderProxyResult struct {
+	upstream *http.Response
+	options  ProxyOptions
+}
+
+func (r RenderProxyResult) Apply(req *Request, resp *Response) {
+	defer r.upstream.Body.Close()
+
+	header := resp.Out.Header()
+	for name, values := range proxiedHeaders(r.upstream.Header, r.options.Headers) {
+		header[name] = values
+	}
+	resp.Status = r.upstream.StatusCode
+	if r.options.Status != 0 {
+		resp.Status = r.options.Status
+	}
+	resp.Out.WriteHeader(resp.Status)
+	resp.headerWritten = true
+	if req.Method == "HEAD" {
+		return
+	}
+
+	flush := r.upstream.ContentLength < 0
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := r.upstream.Body.Read(buf)
+		if n > 0 {
+			if _, werr := resp.Out.Write(buf[:n]); werr != nil {
+				TRACE.Println("Error writing proxied response, aborting:", werr)
+				return
+			}
+			if flush {
+				resp.Flush()
+			}
+		}
+		if err == io.EOF {
+			return
+		} else if err != nil {
+			WARN.Println("Error reading upstream response:", err)
+			return
+		}
+	}
+}
+
+// proxiedHeaders returns the upstream headers to forward: those listed in
+// names, or all of them if there are none, but the hop-by-hop headers.
+func proxiedHeaders(upstream http.Header, names []string) http.Header {
+	hopByHop := make(map[string]bool)
+	for _, name := range hopByHopHeaders {
+		hopByHop[name] = true
+	}
+	for _, value := range upstream["Connection"] {
+		for _, name := range strings.Split(value, ",") {
+			hopByHop[http.CanonicalHeaderKey(strings.TrimSpace(name))] = true
+		}
+	}
+
+	if len(names) == 0 {
+		for name := range upstream {
+			names = append(names, name)
+		}
+	}
+	header := make(http.Header)
+	for _, name := range names {
+		name = http.CanonicalHeaderKey(name)
+		if values, ok := upstream[name]; ok && !hopByHop[name] {
+			header[name] = append([]string(nil), values...)
+		}
+	}
+	return header
+}
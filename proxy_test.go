@@ -0,0 +1,51 @@
+package revel
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRenderProxy(t *testing.T) {
+	startFakeBookingApp()
+
+	proxy := func(opts ProxyOptions) *httptest.ResponseRecorder {
+		upstream := &http.Response{
+			StatusCode: http.StatusCreated,
+			Header: http.Header{
+				"Content-Type":      {"application/json"},
+				"Cache-Control":     {"max-age=60"},
+				"Connection":        {"keep-alive, X-Upstream-Hop"},
+				"X-Upstream-Hop":    {"1"},
+				"Transfer-Encoding": {"chunked"},
+				"X-Request-Id":      {"abc"},
+			},
+			Body:          ioutil.NopCloser(strings.NewReader(`{"id":3}`)),
+			ContentLength: -1,
+		}
+		resp := httptest.NewRecorder()
+		c := NewController(NewRequest(showRequest), NewResponse(resp))
+		c.RenderProxy(upstream, opts).Apply(c.Request, c.Response)
+		return resp
+	}
+
+	resp := proxy(ProxyOptions{})
+	eq(t, "status", resp.Code, http.StatusCreated)
+	eq(t, "body", resp.Body.String(), `{"id":3}`)
+	eq(t, "Content-Type", resp.Header().Get("Content-Type"), "application/json")
+	eq(t, "X-Request-Id", resp.Header().Get("X-Request-Id"), "abc")
+	for _, name := range []string{"Connection", "X-Upstream-Hop", "Transfer-Encoding"} {
+		if value := resp.Header().Get(name); value != "" {
+			t.Errorf("Expected the hop-by-hop header %s to be stripped, got %q", name, value)
+		}
+	}
+
+	resp = proxy(ProxyOptions{Headers: []string{"content-type", "Connection"}, Status: http.StatusOK})
+	eq(t, "rewritten status", resp.Code, http.StatusOK)
+	eq(t, "filtered Content-Type", resp.Header().Get("Content-Type"), "application/json")
+	if len(resp.Header()) != 1 {
+		t.Errorf("Expected only the listed headers to be forwarded, got %v", resp.Header())
+	}
+}
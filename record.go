@@ -0,0 +1,89 @@
+package revel
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+)
+
+// RecordedResponse is a response rendered in memory, whose headers and body
+// may be inspected or modified before it is written out.  It is itself a
+// Result, which writes the recorded response.
+type RecordedResponse struct {
+	Code int          // The status written, 0 if none was.
+	Body bytes.Buffer // The body written.
+
+	header http.Header
+}
+
+// RecordResult renders the result into a RecordedResponse, in the same way as
+// it would be rendered for the request (e.g. honoring c.Response.Status), so
+// that it may be post-processed before being sent:
+//
+//	recorded, err := c.RecordResult(c.Render())
+//	if err != nil {
+//	  return c.RenderError(err)
+//	}
+//	html := inlineCriticalCSS(recorded.Body.String())
+//	recorded.Body.Reset()
+//	recorded.Body.WriteString(html)
+//	recorded.Header().Del("Content-Length")
+//	return recorded
+//
+// It returns an error if rendering the result panics.
+func (c *Controller) RecordResult(r Result) (recorded *RecordedResponse, err error) {
+	recorded = &RecordedResponse{}
+	resp := &Response{
+		Status:      c.Response.Status,
+		ContentType: c.Response.ContentType,
+		Out:         recorded,
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			recorded, err = nil, fmt.Errorf("revel: panic rendering %T: %v", r, p)
+		}
+	}()
+	r.Apply(c.Request, resp)
+	return recorded, nil
+}
+
+func (r *RecordedResponse) Header() http.Header {
+	if r.header == nil {
+		r.header = make(http.Header)
+	}
+	return r.header
+}
+
+func (r *RecordedResponse) WriteHeader(status int) {
+	if r.Code == 0 {
+		r.Code = status
+	}
+}
+
+func (r *RecordedResponse) Write(b []byte) (int, error) {
+	r.WriteHeader(http.StatusOK)
+	return r.Body.Write(b)
+}
+
+// Apply writes the recorded response.
+func (r *RecordedResponse) Apply(req *Request, resp *Response) {
+	r.replay(resp, true)
+}
+
+// replay writes the recorded headers, optionally without cookies, status and
+// body.
+func (r *RecordedResponse) replay(resp *Response, cookies bool) {
+	header := resp.Out.Header()
+	for name, values := range r.header {
+		if name == "Set-Cookie" && !cookies {
+			continue
+		}
+		header[name] = append([]string(nil), values...)
+	}
+	status := r.Code
+	if status == 0 {
+		status = http.StatusOK
+	}
+	resp.Out.WriteHeader(status)
+	resp.Out.Write(r.Body.Bytes())
+}
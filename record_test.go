@@ -0,0 +1,47 @@
+package revel
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type panickingResult struct{}
+
+func (panickingResult) Apply(req *Request, resp *Response) {
+	panic("boom")
+}
+
+func TestRecordResult(t *testing.T) {
+	startFakeBookingApp()
+
+	req, _ := http.NewRequest("GET", "/hotels/1", nil)
+	resp := httptest.NewRecorder()
+	c := NewController(NewRequest(req), NewResponse(resp))
+	c.Response.Status = http.StatusCreated
+
+	recorded, err := c.RecordResult(c.RenderHtml("<p>rob</p>"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recorded.Code != http.StatusCreated || recorded.Header().Get("Content-Type") != "text/html; charset=utf-8" {
+		t.Errorf("Expected the result to be rendered as for the request, got %d %v", recorded.Code, recorded.Header())
+	}
+	if resp.Body.Len() != 0 || resp.Header().Get("Content-Type") != "" {
+		t.Errorf("Expected nothing to be written to the response yet, got %v %q", resp.Header(), resp.Body.String())
+	}
+
+	html := strings.Replace(recorded.Body.String(), "rob", "bob", 1)
+	recorded.Body.Reset()
+	recorded.Body.WriteString(html)
+	recorded.Header().Set("X-Processed", "1")
+	recorded.Apply(c.Request, c.Response)
+	if resp.Code != http.StatusCreated || resp.Body.String() != "<p>bob</p>" || resp.Header().Get("X-Processed") != "1" {
+		t.Errorf("Expected the modified response, got %d %v %q", resp.Code, resp.Header(), resp.Body.String())
+	}
+
+	if _, err := c.RecordResult(panickingResult{}); err == nil {
+		t.Error("Expected a panicking result to return an error")
+	}
+}
@@ -0,0 +1,182 @@
+package revel
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/revel/config"
+)
+
+// ConfigReloadHook re-applies settings after the config is reloaded.  It
+// receives the names of the options whose values changed (or that were added
+// or removed), and may return an error to abandon the reload.
+type ConfigReloadHook func(changed map[string]bool) error
+
+var (
+	configReloadHooks []ConfigReloadHook
+	configReloadLock  sync.Mutex
+
+	// configLock guards Config while ReloadConfig replaces it.
+	configLock sync.RWMutex
+)
+
+// CurrentConfig returns Config, as last reloaded by ReloadConfig.  Code that
+// runs while requests are served (filters, actions, jobs) must read the config
+// through it: reading Config there races with a reload.
+func CurrentConfig() *config.Context {
+	configLock.RLock()
+	defer configLock.RUnlock()
+	return Config
+}
+
+func setConfig(c *config.Context) {
+	configLock.Lock()
+	Config = c
+	configLock.Unlock()
+}
+
+// restartOptions are the options, or prefixes of options (ending in "."),
+// that only take effect on restart.  A reload keeps their previous values.
+var restartOptions = []string{
+	"http.addr", "http.port", "http.ssl", "http.sslcert", "http.sslkey",
+	"mode.dev", "app.name", "app.root", "app.secret", "app.secrets",
+	"cookie.", "module.", "server.",
+}
+
+// OnConfigReload registers a hook to re-apply settings when the config is
+// reloaded, in the order they are registered, e.g. for feature flags:
+//
+//	revel.OnConfigReload(func(changed map[string]bool) error {
+//		features.Load(revel.CurrentConfig())
+//		return nil
+//	})
+//
+// Settings that are read from CurrentConfig on every request take effect
+// without a hook.
+func OnConfigReload(hook ConfigReloadHook) {
+	configReloadHooks = append(configReloadHooks, hook)
+}
+
+// ReloadConfig reloads app.conf (and its overlays) and re-applies the
+// settings that may change while running, through the ConfigReloadHooks.  The
+// log outputs, concurrency limits and maintenance mode are reloaded by Revel.
+// Options listed in restartOptions (e.g. http.port) keep their previous values
+// until restart, and a warning is logged when they are changed.
+//
+// The reload is all or nothing: if the config fails to load, or a hook
+// returns an error, the previous config is restored (and re-applied by the
+// hooks that already ran) and the error is returned.
+//
+// It is called when the process receives SIGHUP.
+func ReloadConfig() error {
+	configReloadLock.Lock()
+	defer configReloadLock.Unlock()
+
+	mode := RunMode
+	if mode == "" {
+		mode = config.DEFAULT_SECTION
+	}
+	newConfig, err := loadConfig(ConfPaths, mode)
+	if err != nil {
+		return fmt.Errorf("failed to reload app.conf: %s", err)
+	}
+	if !newConfig.HasSection(mode) {
+		return fmt.Errorf("failed to reload app.conf: no mode found: %s", mode)
+	}
+	newConfig.SetSection(mode)
+
+	changed := changedOptions(Config, newConfig)
+	for option := range changed {
+		if !isRestartOption(option) {
+			continue
+		}
+		WARN.Printf("Config option %s changed, which takes effect on restart", option)
+		if value, found := Config.String(option); found {
+			newConfig.SetOption(option, value)
+		}
+		delete(changed, option)
+	}
+	if len(changed) == 0 {
+		INFO.Println("Reloaded config: no changes")
+		return nil
+	}
+
+	oldConfig := Config
+	setConfig(newConfig)
+	for i, hook := range configReloadHooks {
+		if err := hook(changed); err != nil {
+			setConfig(oldConfig)
+			for _, hook := range configReloadHooks[:i+1] {
+				if err := hook(changed); err != nil {
+					ERROR.Println("Failed to restore the previous config:", err)
+				}
+			}
+			return fmt.Errorf("failed to reload app.conf, kept the previous config: %s", err)
+		}
+	}
+
+	names := make([]string, 0, len(changed))
+	for option := range changed {
+		names = append(names, option)
+	}
+	sort.Strings(names)
+	INFO.Println("Reloaded config, changed:", strings.Join(names, ", "))
+	return nil
+}
+
+// changedOptions returns the options of the current section (or the default
+// one) whose values differ between the two configs.
+func changedOptions(oldConfig, newConfig *config.Context) map[string]bool {
+	changed := make(map[string]bool)
+	for _, ctx := range []*config.Context{oldConfig, newConfig} {
+		for _, option := range ctx.Options("") {
+			oldValue, oldFound := oldConfig.String(option)
+			newValue, newFound := newConfig.String(option)
+			if oldFound != newFound || oldValue != newValue {
+				changed[option] = true
+			}
+		}
+	}
+	return changed
+}
+
+func isRestartOption(option string) bool {
+	for _, restart := range restartOptions {
+		if option == restart || strings.HasSuffix(restart, ".") && strings.HasPrefix(option, restart) {
+			return true
+		}
+	}
+	return false
+}
+
+// handleReloadSignal reloads the config whenever the process receives
+// SIGHUP.
+func handleReloadSignal() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	go func() {
+		for range signals {
+			INFO.Println("Received SIGHUP, reloading config")
+			if err := ReloadConfig(); err != nil {
+				ERROR.Println(err)
+			}
+		}
+	}()
+}
+
+func init() {
+	OnConfigReload(func(changed map[string]bool) error {
+		for option := range changed {
+			if strings.HasPrefix(option, "log.") {
+				reconfigureLoggers()
+				break
+			}
+		}
+		return nil
+	})
+}
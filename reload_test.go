@@ -0,0 +1,75 @@
+package revel
+
+import (
+	"errors"
+	"io/ioutil"
+	"log"
+	"path/filepath"
+	"testing"
+
+	"github.com/revel/config"
+)
+
+func TestReloadConfig(t *testing.T) {
+	startFakeBookingApp()
+	dir := t.TempDir()
+	writeConf := func(content string) {
+		if err := ioutil.WriteFile(filepath.Join(dir, "app.conf"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	defer func(conf *config.Context, paths []string, mode string, hooks []ConfigReloadHook) {
+		Config, ConfPaths, RunMode, configReloadHooks = conf, paths, mode, hooks
+	}(Config, ConfPaths, RunMode, configReloadHooks)
+
+	writeConf("[prod]\nhttp.port = 9000\nfeature.search = false\nconcurrency.limit = 2\n")
+	ConfPaths, RunMode = []string{dir}, "prod"
+	Config, _ = loadConfig(ConfPaths, RunMode)
+	Config.SetSection(RunMode)
+
+	var changed map[string]bool
+	var hookErr error
+	OnConfigReload(func(c map[string]bool) error {
+		changed = c
+		return hookErr
+	})
+
+	writeConf("[prod]\nhttp.port = 9001\nfeature.search = true\nfeature.export = true\nconcurrency.limit = 2\n")
+	if err := ReloadConfig(); err != nil {
+		t.Fatal(err)
+	}
+	if len(changed) != 2 || !changed["feature.search"] || !changed["feature.export"] {
+		t.Errorf("Expected the feature options to have changed, got %v", changed)
+	}
+	eq(t, "feature.search", Config.BoolDefault("feature.search", false), true)
+	eq(t, "restart option", Config.IntDefault("http.port", 0), 9000)
+
+	// A failed hook restores the previous config.
+	hookErr = errors.New("invalid feature flags")
+	writeConf("[prod]\nhttp.port = 9000\nfeature.search = false\nconcurrency.limit = 2\n")
+	if err := ReloadConfig(); err == nil {
+		t.Error("Expected the failed hook to fail the reload")
+	}
+	eq(t, "restored feature.search", Config.BoolDefault("feature.search", false), true)
+
+	// So does a config that does not load.
+	hookErr = nil
+	writeConf("[dev]\nfeature.search = false\n")
+	if err := ReloadConfig(); err == nil {
+		t.Error("Expected the invalid config to fail the reload")
+	}
+	eq(t, "kept feature.search", Config.BoolDefault("feature.search", false), true)
+
+	// The loggers in use by requests are reconfigured in place.
+	defer func(logger *log.Logger) { WARN = logger }(WARN)
+	logger := log.New(ioutil.Discard, "", 0)
+	WARN = logger
+	writeConf("[prod]\nhttp.port = 9000\nfeature.search = true\nlog.warn.prefix = RELOADED:\n")
+	if err := ReloadConfig(); err != nil {
+		t.Fatal(err)
+	}
+	if WARN != logger || WARN.Prefix() != "RELOADED:" {
+		t.Errorf("Expected the WARN logger to be reconfigured in place, got prefix %q", WARN.Prefix())
+	}
+	eq(t, "current config", CurrentConfig(), Config)
+}
@@ -0,0 +1,81 @@
+package revel
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// headerFormats are the patterns that the values of required headers must
+// match, by canonical header name.
+// They may be specified in config as "headers.format.<Header>".
+var headerFormats map[string]*regexp.Regexp
+
+func init() {
+	OnAppStart(func() {
+		headerFormats = configHeaderFormats()
+	})
+}
+
+func configHeaderFormats() map[string]*regexp.Regexp {
+	const prefix = "headers.format."
+	formats := make(map[string]*regexp.Regexp)
+	for _, option := range CurrentConfig().Options(prefix) {
+		pattern := CurrentConfig().StringDefault(option, "")
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			panic(fmt.Errorf("%s invalid: %s", option, err))
+		}
+		formats[http.CanonicalHeaderKey(option[len(prefix):])] = re
+	}
+	return formats
+}
+
+// RequiredHeadersFilter rejects requests with 400 Bad Request when one of the
+// headers listed in "headers.required" (comma separated) is missing or, if a
+// "headers.format.<Header>" pattern is configured, does not match it:
+//
+//	headers.required = X-Api-Version, X-Tenant-Id
+//	headers.format.X-Api-Version = ^[0-9]+$
+//
+// The list may be overridden per controller or action, with
+// "headers.required.<Controller>" or "headers.required.<Controller.Action>"
+// (the most specific wins), e.g. "headers.required.Health =" to require
+// nothing for the Health controller.
+//
+// It must run after the RouterFilter.
+func RequiredHeadersFilter(c *Controller, fc []Filter) {
+	required := CurrentConfig().StringDefault("headers.required", "")
+	if c.Name != "" {
+		required = CurrentConfig().StringDefault("headers.required."+c.Name, required)
+		required = CurrentConfig().StringDefault("headers.required."+c.Action, required)
+	}
+	for _, name := range strings.Split(required, ",") {
+		if name = strings.TrimSpace(name); name == "" {
+			continue
+		}
+		if msg := checkRequiredHeader(c.Request, name); msg != "" {
+			c.Response.Status = http.StatusBadRequest
+			c.Result = c.RenderError(&Error{
+				Title:       "Bad Request",
+				Description: msg,
+			})
+			return
+		}
+	}
+	fc[0](c, fc[1:])
+}
+
+// checkRequiredHeader returns why the given header of the request is not
+// acceptable, or "" if it is.
+func checkRequiredHeader(req *Request, name string) string {
+	value := strings.TrimSpace(req.Header.Get(name))
+	if value == "" {
+		return "The required header " + name + " is missing"
+	}
+	if re := headerFormats[http.CanonicalHeaderKey(name)]; re != nil && !re.MatchString(value) {
+		return fmt.Sprintf("The header %s has an invalid value %q (expected %s)", name, value, re)
+	}
+	return ""
+}
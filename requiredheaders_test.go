@@ -0,0 +1,61 @@
+package revel
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequiredHeadersFilter(t *testing.T) {
+	startFakeBookingApp()
+	defer func() { headerFormats = nil }()
+
+	run := func(action string, header http.Header) (*httptest.ResponseRecorder, bool) {
+		req, _ := http.NewRequest("GET", "/hotels", nil)
+		for name, values := range header {
+			req.Header[name] = values
+		}
+		resp := httptest.NewRecorder()
+		c := NewController(NewRequest(req), NewResponse(resp))
+		c.Name, c.Action = "Hotels", action
+		called := false
+		RequiredHeadersFilter(c, []Filter{func(c *Controller, fc []Filter) { called = true }})
+		if c.Result != nil {
+			c.Result.Apply(c.Request, c.Response)
+		}
+		return resp, called
+	}
+
+	if _, called := run("Hotels.Index", nil); !called {
+		t.Error("Expected no headers to be required by default")
+	}
+
+	Config.SetOption("headers.required", "X-Api-Version, X-Tenant-Id")
+	Config.SetOption("headers.format.x-api-version", "^[0-9]+$")
+	defer Config.SetOption("headers.required", "")
+	headerFormats = configHeaderFormats()
+
+	valid := http.Header{"X-Api-Version": {"2"}, "X-Tenant-Id": {"acme"}}
+	if _, called := run("Hotels.Index", valid); !called {
+		t.Error("Expected a request with the required headers to be served")
+	}
+	for _, header := range []http.Header{
+		{"X-Api-Version": {"2"}},
+		{"X-Api-Version": {"v2"}, "X-Tenant-Id": {"acme"}},
+		{"X-Api-Version": {"2"}, "X-Tenant-Id": {" "}},
+	} {
+		if resp, called := run("Hotels.Index", header); called || resp.Code != http.StatusBadRequest {
+			t.Errorf("Expected %v to be rejected with 400, got %d (called: %v)", header, resp.Code, called)
+		}
+	}
+
+	// Per controller and action.
+	Config.SetOption("headers.required.Hotels", "")
+	Config.SetOption("headers.required.Hotels.Book", "X-Tenant-Id")
+	if _, called := run("Hotels.Index", nil); !called {
+		t.Error("Expected the controller override to apply")
+	}
+	if _, called := run("Hotels.Book", nil); called {
+		t.Error("Expected the action override to apply")
+	}
+}
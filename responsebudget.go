@@ -0,0 +1,167 @@
+package revel
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// ErrResponseBudgetExceeded is returned by writes to a response once it is
+// larger than its budget, under the "abort" policy.
+var ErrResponseBudgetExceeded = errors.New("revel: response larger than its budget")
+
+// ResponseSizeHook receives the number of body bytes written for a request
+// by the ResponseBudgetFilter, e.g. for billing or metrics, and whether the
+// response exceeded its budget.  It is called once the result is rendered.
+type ResponseSizeHook func(c *Controller, size int64, exceeded bool)
+
+var responseSizeHooks []ResponseSizeHook
+
+// RegisterResponseSizeHook adds a ResponseSizeHook run by the
+// ResponseBudgetFilter, in the order they are registered.
+func RegisterResponseSizeHook(hook ResponseSizeHook) {
+	responseSizeHooks = append(responseSizeHooks, hook)
+}
+
+// ResponseBudgetFilter counts the bytes of the response body and enforces the
+// budget configured in "response.budget" (bytes, default 0 for none), which
+// may be overridden per controller or action, with
+// "response.budget.<Controller>" or "response.budget.<Controller.Action>"
+// (the most specific wins).  When a response exceeds its budget, the
+// "response.budget.policy" decides what happens:
+//
+//	abort - the rest of the body is not sent and the connection is closed, so
+//	        the client sees an incomplete response (the default)
+//	log   - the response is sent anyway, and a warning is logged
+//
+// The final size is passed to the registered ResponseSizeHooks.  Since the
+// status and headers may already be sent when the budget is exceeded, an
+// aborted response can not be turned into an error page.
+//
+// It must run after the RouterFilter.
+func ResponseBudgetFilter(c *Controller, fc []Filter) {
+	budget := int64(CurrentConfig().IntDefault("response.budget", 0))
+	if c.Name != "" {
+		budget = int64(CurrentConfig().IntDefault("response.budget."+c.Name, int(budget)))
+		budget = int64(CurrentConfig().IntDefault("response.budget."+c.Action, int(budget)))
+	}
+	if budget <= 0 && len(responseSizeHooks) == 0 {
+		fc[0](c, fc[1:])
+		return
+	}
+
+	fc[0](c, fc[1:])
+
+	abort := !strings.EqualFold(CurrentConfig().StringDefault("response.budget.policy", "abort"), "log")
+	counter := &budgetResponseWriter{budget: budget, abort: abort}
+	if c.Result == nil {
+		counter.report(c)
+		return
+	}
+	c.Result = responseBudgetResult{c.Result, c, counter}
+}
+
+// responseBudgetResult counts the response body written by the wrapped result.
+type responseBudgetResult struct {
+	Result
+	c       *Controller
+	counter *budgetResponseWriter
+}
+
+func (r responseBudgetResult) Apply(req *Request, resp *Response) {
+	out := resp.Out
+	r.counter.ResponseWriter = out
+	resp.Out = r.counter
+	defer func() {
+		resp.Out = out
+	}()
+	r.Result.Apply(req, resp)
+
+	r.counter.report(r.c)
+	if r.counter.exceeded && r.counter.abort {
+		resp.aborted = true
+	}
+}
+
+func (r responseBudgetResult) Unwrap() Result {
+	return r.Result
+}
+
+// budgetResponseWriter counts the bytes written to it and, under the "abort"
+// policy, refuses those beyond the budget.
+type budgetResponseWriter struct {
+	http.ResponseWriter
+	budget   int64 // 0 for none
+	abort    bool
+	written  int64
+	exceeded bool
+}
+
+func (w *budgetResponseWriter) Write(b []byte) (int, error) {
+	if w.budget > 0 && (w.exceeded || w.written+int64(len(b)) > w.budget) {
+		w.exceeded = true
+		if w.abort {
+			return 0, ErrResponseBudgetExceeded
+		}
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.written += int64(n)
+	return n, err
+}
+
+func (w *budgetResponseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *budgetResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *budgetResponseWriter) ReadFrom(r io.Reader) (int64, error) {
+	if w.budget <= 0 || !w.abort {
+		n, err := readFromWriter(w.ResponseWriter, r)
+		w.written += n
+		if w.budget > 0 && w.written > w.budget {
+			w.exceeded = true
+		}
+		return n, err
+	}
+	if w.exceeded {
+		return 0, ErrResponseBudgetExceeded
+	}
+	// A limited file is still sent with sendfile.
+	n, err := readFromWriter(w.ResponseWriter, io.LimitReader(r, w.budget-w.written))
+	w.written += n
+	if err != nil {
+		return n, err
+	}
+	if _, err := io.ReadFull(r, make([]byte, 1)); err == nil {
+		w.exceeded = true
+		return n, ErrResponseBudgetExceeded
+	}
+	return n, nil
+}
+
+func (w *budgetResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return hijackWriter(w.ResponseWriter)
+}
+
+func (w *budgetResponseWriter) CloseNotify() <-chan bool {
+	return closeNotifyWriter(w.ResponseWriter)
+}
+
+// report logs an exceeded budget and runs the ResponseSizeHooks.
+func (w *budgetResponseWriter) report(c *Controller) {
+	if w.exceeded {
+		WARN.Printf("Response to %s %s (%s) exceeded its budget of %d bytes (%d bytes written)",
+			c.Request.Method, c.Request.URL.Path, c.Action, w.budget, w.written)
+	}
+	for _, hook := range responseSizeHooks {
+		hook(c, w.written, w.exceeded)
+	}
+}
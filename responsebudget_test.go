@@ -0,0 +1,65 @@
+package revel
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestResponseBudgetFilter(t *testing.T) {
+	startFakeBookingApp()
+	defer func() { responseSizeHooks = nil }()
+
+	var size int64
+	var exceeded bool
+	RegisterResponseSizeHook(func(c *Controller, n int64, over bool) {
+		size, exceeded = n, over
+	})
+
+	run := func(action, body string) (*httptest.ResponseRecorder, *Response) {
+		resp := httptest.NewRecorder()
+		c := NewController(NewRequest(showRequest), NewResponse(resp))
+		c.Name, c.Action = "Hotels", action
+		ResponseBudgetFilter(c, []Filter{func(c *Controller, fc []Filter) {
+			c.Result = c.RenderText("%s", body)
+		}})
+		c.Result.Apply(c.Request, c.Response)
+		return resp, c.Response
+	}
+
+	resp, response := run("Hotels.Show", "hello")
+	eq(t, "body without a budget", resp.Body.String(), "hello")
+	eq(t, "size", size, int64(5))
+	eq(t, "exceeded", exceeded, false)
+
+	Config.SetOption("response.budget", "8")
+	Config.SetOption("response.budget.Hotels.Index", "0")
+	defer func() {
+		Config.SetOption("response.budget", "0")
+		Config.SetOption("response.budget.Hotels.Index", "0")
+		Config.SetOption("response.budget.policy", "abort")
+	}()
+
+	resp, response = run("Hotels.Show", "hello")
+	eq(t, "body within the budget", resp.Body.String(), "hello")
+	eq(t, "aborted within the budget", response.aborted, false)
+
+	resp, response = run("Hotels.Show", strings.Repeat("x", 20))
+	eq(t, "aborted body", resp.Body.String(), "")
+	eq(t, "aborted", response.aborted, true)
+	eq(t, "aborted size", size, int64(0))
+	eq(t, "aborted exceeded", exceeded, true)
+
+	resp, response = run("Hotels.Index", strings.Repeat("x", 20))
+	eq(t, "action override", resp.Body.Len(), 20)
+	eq(t, "action override aborted", response.aborted, false)
+
+	Config.SetOption("response.budget.policy", "log")
+	resp, response = run("Hotels.Show", strings.Repeat("x", 20))
+	eq(t, "logged body", resp.Body.Len(), 20)
+	eq(t, "logged aborted", response.aborted, false)
+	eq(t, "logged size", size, int64(20))
+	eq(t, "logged exceeded", exceeded, true)
+	eq(t, "status", resp.Code, http.StatusOK)
+}
@@ -0,0 +1,38 @@
+package revel
+
+// ResultHook transforms the Result of an action before it is rendered, e.g.
+// to add links to JSON payloads or wrap them in an envelope:
+//
+//	revel.RegisterResultHook(func(c *revel.Controller, result revel.Result) revel.Result {
+//	  if r, ok := result.(revel.RenderJsonResult); ok {
+//	    return r.WithPayload(Envelope{Data: r.Payload(), Links: links(c)})
+//	  }
+//	  return result
+//	})
+type ResultHook func(c *Controller, result Result) Result
+
+var resultHooks []ResultHook
+
+// RegisterResultHook adds a ResultHook run by the ResultHookFilter.  Hooks
+// are run in the order they are registered, each receiving the Result
+// returned by the previous one.
+func RegisterResultHook(hook ResultHook) {
+	resultHooks = append(resultHooks, hook)
+}
+
+// ResultHookFilter runs the registered ResultHooks on the Result of the
+// action or interceptors (i.e. after the AFTER interceptors), before it is
+// rendered.  Requests that did not produce a Result are left alone.
+//
+// The hooks may be skipped for some actions by removing the filter:
+//
+//	revel.FilterAction(App.Health).Remove(revel.ResultHookFilter)
+func ResultHookFilter(c *Controller, fc []Filter) {
+	fc[0](c, fc[1:])
+	for _, hook := range resultHooks {
+		if c.Result == nil {
+			return
+		}
+		c.Result = hook(c, c.Result)
+	}
+}
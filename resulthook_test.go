@@ -0,0 +1,53 @@
+package revel
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestResultHookFilter(t *testing.T) {
+	startFakeBookingApp()
+	defer func(hooks []ResultHook) { resultHooks = hooks }(resultHooks)
+
+	type envelope struct {
+		Data  interface{}       `json:"data"`
+		Links map[string]string `json:"links"`
+	}
+	calls := 0
+	RegisterResultHook(func(c *Controller, result Result) Result {
+		calls++
+		if r, ok := result.(RenderJsonResult); ok {
+			return r.WithPayload(envelope{r.Payload(), map[string]string{"self": c.Request.URL.Path}})
+		}
+		return result
+	})
+	RegisterResultHook(func(c *Controller, result Result) Result {
+		if _, ok := result.(RenderJsonResult); !ok {
+			t.Errorf("Expected the result of the previous hook, got %T", result)
+		}
+		return result
+	})
+
+	run := func(action func(c *Controller)) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/hotels/1", nil)
+		resp := httptest.NewRecorder()
+		c := NewController(NewRequest(req), NewResponse(resp))
+		ResultHookFilter(c, []Filter{func(c *Controller, fc []Filter) { action(c) }})
+		if c.Result != nil {
+			c.Result.Apply(c.Request, c.Response)
+		}
+		return resp
+	}
+
+	resp := run(func(c *Controller) { c.Result = c.RenderJson(map[string]int{"id": 1}) })
+	if body := resp.Body.String(); body != `{"data":{"id":1},"links":{"self":"/hotels/1"}}` {
+		t.Errorf("Expected the payload in an envelope, got %s", body)
+	}
+
+	calls = 0
+	run(func(c *Controller) {})
+	if calls != 0 {
+		t.Error("Expected the hooks not to run without a result")
+	}
+}
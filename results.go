@@ -2,13 +2,12 @@ package revel
 
 import (
 	"bytes"
-	"encoding/json"
-	"encoding/xml"
 	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
+	"os"
 	"reflect"
 	"strconv"
 	"strings"
@@ -17,10 +16,55 @@ import (
 	"golang.org/x/net/websocket"
 )
 
+// Result is what an action returns: it renders the response to the request.
+// Apply is called once, after the action and the filters wrapping it have
+// run.  It must write the header (resp.WriteHeader) before the body, and
+// should honor a status set by the action in resp.Status, which WriteHeader
+// does.
+//
+// Applications and modules may define their own results using only the
+// Response methods: WriteHeader, Header, Write and Flush.  For example:
+//
+//	type CSVResult struct {
+//		Rows [][]string
+//	}
+//
+//	func (r CSVResult) Apply(req *revel.Request, resp *revel.Response) {
+//		resp.Header().Set("Content-Disposition", `attachment; filename="export.csv"`)
+//		resp.WriteHeader(http.StatusOK, "text/csv; charset=utf-8")
+//		csv.NewWriter(resp).WriteAll(r.Rows)
+//	}
+//
+// which an action returns like any other result:
+//
+//	func (c App) Export() revel.Result {
+//		return CSVResult{rows}
+//	}
 type Result interface {
 	Apply(req *Request, resp *Response)
 }
 
+// ResultFunc adapts an ordinary function to a Result.
+type ResultFunc func(req *Request, resp *Response)
+
+func (f ResultFunc) Apply(req *Request, resp *Response) {
+	f(req, resp)
+}
+
+// UnwrapResult returns the result wrapped by filters that finalize the
+// response as it is written (e.g. the SetCookieFilter), so that it may be
+// inspected by type.  Wrapper results return the result they wrap from an
+// Unwrap() Result method.
+func UnwrapResult(result Result) Result {
+	for {
+		wrapper, ok := result.(interface{ Unwrap() Result })
+		if !ok {
+			return result
+		}
+		result = wrapper.Unwrap()
+	}
+}
+
 // This result handles all kinds of error codes (500, 404, ..).
 // It renders the relevant error page (errors/CODE.format, e.g. errors/500.json).
 // If RunMode is "dev", this results in a friendly error page.
@@ -29,6 +73,25 @@ type ErrorResult struct {
 	Error      error
 }
 
+// errorTemplate returns the template rendering the errors of the given status
+// in the format and locale of the request, and its path: the first found of
+// "errors/<status>.<locale>.<format>" for the locales of the request's
+// fallback chain (e.g. "errors/404.pt-BR.html", then "errors/404.pt.html"),
+// or else "errors/<status>.<format>".
+func errorTemplate(req *Request, status int) (Template, string, error) {
+	if req.Locale != "" {
+		for _, locale := range localeFallbacks(req.Locale) {
+			templatePath := fmt.Sprintf("errors/%d.%s.%s", status, locale, req.Format)
+			if tmpl, err := MainTemplateLoader.Template(templatePath); tmpl != nil && err == nil {
+				return tmpl, templatePath, nil
+			}
+		}
+	}
+	templatePath := fmt.Sprintf("errors/%d.%s", status, req.Format)
+	tmpl, err := MainTemplateLoader.Template(templatePath)
+	return tmpl, templatePath, err
+}
+
 func (r ErrorResult) Apply(req *Request, resp *Response) {
 	format := req.Format
 	status := resp.Status
@@ -42,9 +105,7 @@ func (r ErrorResult) Apply(req *Request, resp *Response) {
 	}
 
 	// Get the error template.
-	var err error
-	templatePath := fmt.Sprintf("errors/%d.%s", status, format)
-	tmpl, err := MainTemplateLoader.Template(templatePath)
+	tmpl, templatePath, err := errorTemplate(req, status)
 
 	// This func shows a plaintext error message, in case the template rendering
 	// doesn't work.
@@ -132,7 +193,7 @@ func (r *RenderTemplateResult) Apply(req *Request, resp *Response) {
 		}
 	}()
 
-	chunked := Config.BoolDefault("results.chunked", false)
+	chunked := CurrentConfig().BoolDefault("results.chunked", false)
 
 	// If it's a HEAD request, throw away the bytes.
 	out := io.Writer(resp.Out)
@@ -144,7 +205,7 @@ func (r *RenderTemplateResult) Apply(req *Request, resp *Response) {
 	// (In a dev mode, always render to a temporary buffer first to avoid having
 	// error pages distorted by HTML already written)
 	if chunked && !DevMode {
-		resp.WriteHeader(http.StatusOK, "text/html; charset=utf-8")
+		resp.WriteHeader(http.StatusOK, textContentType("text/html"))
 		r.render(req, resp, out)
 		return
 	}
@@ -163,7 +224,7 @@ func (r *RenderTemplateResult) Apply(req *Request, resp *Response) {
 	//
 	// This is safe unless white-space: pre; is used in css for formatting.
 	// Since there is no way to detect that, you will have to keep trimming off in these cases.
-	if Config.BoolDefault("results.trim.html", false) {
+	if CurrentConfig().BoolDefault("results.trim.html", false) {
 		var b2 bytes.Buffer
 		// Allocate length of original buffer, so we can write everything without allocating again
 		b2.Grow(b.Len())
@@ -201,7 +262,7 @@ func (r *RenderTemplateResult) Apply(req *Request, resp *Response) {
 	if !chunked {
 		resp.Out.Header().Set("Content-Length", strconv.Itoa(b.Len()))
 	}
-	resp.WriteHeader(http.StatusOK, "text/html; charset=utf-8")
+	resp.WriteHeader(http.StatusOK, textContentType("text/html"))
 	b.WriteTo(out)
 }
 
@@ -238,60 +299,220 @@ type RenderHtmlResult struct {
 }
 
 func (r RenderHtmlResult) Apply(req *Request, resp *Response) {
-	resp.WriteHeader(http.StatusOK, "text/html; charset=utf-8")
+	resp.WriteHeader(http.StatusOK, textContentType("text/html"))
 	resp.Out.Write([]byte(r.html))
 }
 
 type RenderJsonResult struct {
-	obj      interface{}
-	callback string
+	obj          interface{}
+	callback     string
+	options      *JsonOptions
+	lastModified time.Time
+	contentType  string
+}
+
+// textContentType returns the Content-Type of a text-based result of the
+// given media type, with the charset from "results.charset" (utf-8 by
+// default).  An empty charset leaves the parameter out.
+func textContentType(mediaType string) string {
+	if charset := CurrentConfig().StringDefault("results.charset", "utf-8"); charset != "" {
+		return mediaType + "; charset=" + charset
+	}
+	return mediaType
+}
+
+// jsonContentType returns the Content-Type of JSON results, from
+// "results.json.contenttype".
+func jsonContentType() string {
+	return CurrentConfig().StringDefault("results.json.contenttype", textContentType("application/json"))
+}
+
+// WithOptions returns a copy of the result that is encoded with the given
+// options instead of the configured defaults.
+func (r RenderJsonResult) WithOptions(opts JsonOptions) RenderJsonResult {
+	r.options = &opts
+	return r
+}
+
+// Payload returns the value rendered as JSON.
+func (r RenderJsonResult) Payload() interface{} {
+	return r.obj
+}
+
+// WithPayload returns a copy of the result that renders the given value
+// instead, e.g. the original payload wrapped in an envelope.
+func (r RenderJsonResult) WithPayload(obj interface{}) RenderJsonResult {
+	r.obj = obj
+	return r
+}
+
+// WithContentType returns a copy of the result that is sent with the given
+// Content-Type, e.g. "application/json; charset=utf-8", instead of the
+// configured "results.json.contenttype".
+func (r RenderJsonResult) WithContentType(contentType string) RenderJsonResult {
+	r.contentType = contentType
+	return r
+}
+
+// LastModified returns a copy of the result that is sent with a
+// Last-Modified header of the given time, e.g. the updated-at time of the
+// resource.  GET and HEAD requests with an If-Modified-Since header that is
+// not older are answered with 304 Not Modified, without encoding the payload.
+func (r RenderJsonResult) LastModified(t time.Time) RenderJsonResult {
+	r.lastModified = t
+	return r
 }
 
 func (r RenderJsonResult) Apply(req *Request, resp *Response) {
-	var b []byte
-	var err error
-	if Config.BoolDefault("results.pretty", false) {
-		b, err = json.MarshalIndent(r.obj, "", "  ")
-	} else {
-		b, err = json.Marshal(r.obj)
+	if !r.lastModified.IsZero() {
+		resp.Out.Header().Set("Last-Modified", r.lastModified.UTC().Format(http.TimeFormat))
+		if notModifiedSince(req, resp, r.lastModified) {
+			resp.Status = http.StatusNotModified
+			resp.Out.WriteHeader(resp.Status)
+			resp.headerWritten = true
+			return
+		}
+	}
+
+	opts := DefaultJsonOptions()
+	if r.options != nil {
+		opts = *r.options
 	}
 
+	b, err := encodeJson(r.obj, opts)
 	if err != nil {
 		ErrorResult{Error: err}.Apply(req, resp)
 		return
 	}
 
+	if r.contentType != "" {
+		resp.ContentType = r.contentType
+	}
+
 	if r.callback == "" {
-		resp.WriteHeader(http.StatusOK, "application/json; charset=utf-8")
+		resp.WriteHeader(http.StatusOK, jsonContentType())
 		resp.Out.Write(b)
 		return
 	}
 
-	resp.WriteHeader(http.StatusOK, "application/javascript; charset=utf-8")
+	resp.WriteHeader(http.StatusOK, textContentType("application/javascript"))
 	resp.Out.Write([]byte(r.callback + "("))
 	resp.Out.Write(b)
 	resp.Out.Write([]byte(");"))
 }
 
+// notModifiedSince returns true if the request is a conditional GET or HEAD
+// whose If-Modified-Since is not older than lastModified, so that it may be
+// answered with 304 Not Modified.  As per RFC 7232, If-Modified-Since is
+// ignored if the request has an If-None-Match header.
+func notModifiedSince(req *Request, resp *Response, lastModified time.Time) bool {
+	if (req.Method != "GET" && req.Method != "HEAD") || (resp.Status != 0 && resp.Status != http.StatusOK) {
+		return false
+	}
+	if req.Header.Get("If-None-Match") != "" {
+		return false
+	}
+	since, err := http.ParseTime(req.Header.Get("If-Modified-Since"))
+	if err != nil {
+		return false
+	}
+	// HTTP dates have a resolution of one second.
+	return !lastModified.Truncate(time.Second).After(since)
+}
+
+// RenderJsonStreamResult writes the values received from a channel as the
+// elements of a JSON array, without buffering the whole array in memory.
+type RenderJsonStreamResult struct {
+	ch <-chan interface{}
+}
+
+const (
+	jsonStreamFlushCount    = 64
+	jsonStreamFlushInterval = 100 * time.Millisecond
+)
+
+func (r RenderJsonStreamResult) Apply(req *Request, resp *Response) {
+	// Whatever is left in the channel is discarded when the stream is
+	// aborted, so that the producer is not blocked forever.
+	aborted := true
+	defer func() {
+		if aborted {
+			go func() {
+				for range r.ch {
+				}
+			}()
+		}
+	}()
+
+	opts := DefaultJsonOptions()
+	opts.Indent = ""
+
+	// The stream lasts as long as the producer, not the write timeout.
+	resp.SetWriteDeadline(time.Time{})
+	resp.WriteHeader(http.StatusOK, jsonContentType())
+	if _, err := resp.Out.Write([]byte("[")); err != nil {
+		return
+	}
+
+	ticker := time.NewTicker(jsonStreamFlushInterval)
+	defer ticker.Stop()
+
+	ctx := req.Context()
+	written, pending := 0, 0
+	for ctx.Err() == nil {
+		select {
+		case <-ctx.Done():
+		case <-ticker.C:
+			if pending > 0 {
+				resp.Flush()
+				pending = 0
+			}
+		case obj, ok := <-r.ch:
+			if !ok {
+				aborted = false
+				resp.Out.Write([]byte("]"))
+				return
+			}
+
+			b, err := encodeJson(obj, opts)
+			if err != nil {
+				// The status has already been sent, so all that can be done
+				// is to end the response.  It is left without the closing
+				// bracket so that clients see the array as incomplete.
+				ERROR.Println("Error encoding JSON stream element:", err)
+				return
+			}
+			if written > 0 {
+				b = append([]byte(","), b...)
+			}
+			if _, err := resp.Out.Write(b); err != nil {
+				TRACE.Println("Error writing JSON stream, aborting:", err)
+				return
+			}
+
+			written++
+			pending++
+			if pending >= jsonStreamFlushCount {
+				resp.Flush()
+				pending = 0
+			}
+		}
+	}
+	TRACE.Println("Client disconnected, aborting JSON stream:", ctx.Err())
+}
+
 type RenderXmlResult struct {
 	obj interface{}
 }
 
 func (r RenderXmlResult) Apply(req *Request, resp *Response) {
-	var b []byte
-	var err error
-	if Config.BoolDefault("results.pretty", false) {
-		b, err = xml.MarshalIndent(r.obj, "", "  ")
-	} else {
-		b, err = xml.Marshal(r.obj)
-	}
-
+	b, err := encodeXml(r.obj)
 	if err != nil {
 		ErrorResult{Error: err}.Apply(req, resp)
 		return
 	}
 
-	resp.WriteHeader(http.StatusOK, "application/xml; charset=utf-8")
+	resp.WriteHeader(http.StatusOK, textContentType("application/xml"))
 	resp.Out.Write(b)
 }
 
@@ -300,7 +521,7 @@ type RenderTextResult struct {
 }
 
 func (r RenderTextResult) Apply(req *Request, resp *Response) {
-	resp.WriteHeader(http.StatusOK, "text/plain; charset=utf-8")
+	resp.WriteHeader(http.StatusOK, textContentType("text/plain"))
 	resp.Out.Write([]byte(r.text))
 }
 
@@ -312,36 +533,72 @@ var (
 )
 
 type BinaryResult struct {
-	Reader   io.Reader
-	Name     string
-	Length   int64
-	Delivery ContentDisposition
-	ModTime  time.Time
+	Reader      io.Reader
+	Name        string
+	Length      int64
+	Delivery    ContentDisposition
+	ModTime     time.Time
+	ContentType string // Guessed from the Name if empty.
+}
+
+// WithDisposition sets whether the content of a BinaryResult, as returned by
+// RenderFile, RenderBinary and RenderDownload, is displayed inline or
+// downloaded as an attachment and, unless filename is empty, the name it is
+// sent as.  Other results are returned as they are.  For example, to let the
+// same action preview or download a PDF:
+//
+//	delivery := revel.Inline
+//	if c.Params.Get("download") == "1" {
+//		delivery = revel.Attachment
+//	}
+//	return revel.WithDisposition(c.RenderFile(file, revel.Inline), delivery, "invoice.pdf")
+func WithDisposition(result Result, delivery ContentDisposition, filename string) Result {
+	if binary, ok := result.(*BinaryResult); ok {
+		return binary.Disposition(delivery, filename)
+	}
+	return result
+}
+
+// Disposition sets the delivery and, unless filename is empty, the name of
+// the BinaryResult, as WithDisposition does.
+func (r *BinaryResult) Disposition(delivery ContentDisposition, filename string) *BinaryResult {
+	r.Delivery = delivery
+	if filename != "" {
+		r.Name = filename
+	}
+	return r
 }
 
 func (r *BinaryResult) Apply(req *Request, resp *Response) {
-	disposition := string(r.Delivery)
-	if r.Name != "" {
-		disposition += fmt.Sprintf(`; filename="%s"`, r.Name)
+	resp.Out.Header().Set("Content-Disposition", contentDisposition(r.Delivery, r.Name))
+
+	contentType := r.ContentType
+	if contentType == "" {
+		contentType = resp.ContentType
+	}
+	if contentType == "" {
+		contentType = ContentTypeByFilename(r.Name)
+	}
+
+	// Files on disk are sent by http.ServeContent with a validator, so that
+	// they may be cached, and with sendfile where the OS and writer allow it.
+	if file, ok := r.Reader.(*os.File); ok {
+		if info, err := file.Stat(); err == nil && info.Mode().IsRegular() && resp.Out.Header().Get("Etag") == "" {
+			resp.Out.Header().Set("Etag", fmt.Sprintf(`W/"%x-%x"`, info.Size(), info.ModTime().UnixNano()))
+		}
 	}
-	resp.Out.Header().Set("Content-Disposition", disposition)
 
 	// If we have a ReadSeeker, delegate to http.ServeContent
 	if rs, ok := r.Reader.(io.ReadSeeker); ok {
 		// http.ServeContent doesn't know about response.ContentType, so we set the respective header.
-		if resp.ContentType != "" {
-			resp.Out.Header().Set("Content-Type", resp.ContentType)
-		} else {
-			contentType := ContentTypeByFilename(r.Name)
-			resp.Out.Header().Set("Content-Type", contentType)
-		}
+		resp.Out.Header().Set("Content-Type", contentType)
 		http.ServeContent(resp.Out, req.Request, r.Name, r.ModTime, rs)
 	} else {
 		// Else, do a simple io.Copy.
 		if r.Length != -1 {
 			resp.Out.Header().Set("Content-Length", strconv.FormatInt(r.Length, 10))
 		}
-		resp.WriteHeader(http.StatusOK, ContentTypeByFilename(r.Name))
+		resp.WriteHeader(http.StatusOK, contentType)
 		io.Copy(resp.Out, r.Reader)
 	}
 
@@ -351,6 +608,49 @@ func (r *BinaryResult) Apply(req *Request, resp *Response) {
 	}
 }
 
+// contentDisposition returns the Content-Disposition header value for the
+// given file name.  Names that are not plain ASCII are sent RFC 5987 encoded
+// (as filename*), after an ASCII approximation for older clients.
+func contentDisposition(delivery ContentDisposition, name string) string {
+	disposition := string(delivery)
+	if name == "" {
+		return disposition
+	}
+
+	var (
+		fallback []rune
+		encoded  string
+		plain    = true
+	)
+	for _, r := range name {
+		if r < ' ' || r > '~' || r == '"' || r == '\\' {
+			plain = false
+			r = '_'
+		}
+		fallback = append(fallback, r)
+	}
+	disposition += fmt.Sprintf(`; filename="%s"`, string(fallback))
+	if plain {
+		return disposition
+	}
+
+	for _, b := range []byte(name) {
+		if isRFC5987AttrChar(b) {
+			encoded += string(b)
+		} else {
+			encoded += fmt.Sprintf("%%%02X", b)
+		}
+	}
+	return disposition + "; filename*=UTF-8''" + encoded
+}
+
+// isRFC5987AttrChar returns true for the characters that need no
+// percent-encoding in an RFC 5987 ext-value.
+func isRFC5987AttrChar(b byte) bool {
+	return 'a' <= b && b <= 'z' || 'A' <= b && b <= 'Z' || '0' <= b && b <= '9' ||
+		strings.IndexByte("!#$&+-.^_`|~", b) != -1
+}
+
 type RedirectToUrlResult struct {
 	url string
 }
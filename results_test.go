@@ -1,9 +1,15 @@
 package revel
 
 import (
+	"context"
+	"io/ioutil"
+	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 )
 
 // Test that the render response is as expected.
@@ -19,6 +25,202 @@ func TestBenchmarkRender(t *testing.T) {
 	}
 }
 
+func TestRenderTemplatePart(t *testing.T) {
+	startFakeBookingApp()
+
+	render := func(header http.Header, block string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/hotels/3", nil)
+		req.Header = header
+		resp := httptest.NewRecorder()
+		c := NewController(NewRequest(req), NewResponse(resp))
+		c.SetAction("Hotels", "Show")
+		c.RenderArgs["hotel"] = &Hotel{3, "A Hotel", "300 Main St.", "New York", "NY", "10010", "USA", 300}
+		c.RenderTemplateOrPart("hotels/show.html", block).Apply(c.Request, c.Response)
+		return resp
+	}
+
+	resp := render(http.Header{}, "hotel")
+	if body := resp.Body.String(); !strings.Contains(body, "<html>") || !strings.Contains(body, "300 Main St.") {
+		t.Errorf("Expected the whole page on navigation, got:\n%s", body)
+	}
+	eq(t, "Vary", resp.Header().Get("Vary"), "HX-Request")
+
+	resp = render(http.Header{"Hx-Request": {"true"}}, "hotel")
+	if body := resp.Body.String(); strings.Contains(body, "<html>") || !strings.Contains(body, "300 Main St.") {
+		t.Errorf("Expected only the hotel block for HTMX, got:\n%s", body)
+	}
+
+	resp = render(http.Header{"Hx-Request": {"true"}, "Hx-History-Restore-Request": {"true"}}, "hotel")
+	if !strings.Contains(resp.Body.String(), "<html>") {
+		t.Errorf("Expected the whole page for a history restore, got:\n%s", resp.Body)
+	}
+
+	resp = render(http.Header{"Hx-Request": {"true"}}, "missing")
+	eq(t, "missing block status", resp.Code, http.StatusInternalServerError)
+}
+
+func TestRenderJsonOptions(t *testing.T) {
+	startFakeBookingApp()
+
+	type item struct {
+		Id    int64  `json:"id"`
+		Count uint64 `json:"count,omitempty"`
+		Small int    `json:"small"`
+		Name  string
+		Tags  []int64 `json:"tags"`
+	}
+	obj := item{Id: 9007199254740993, Small: 1, Name: "<b>", Tags: []int64{1}}
+
+	render := func(result Result) string {
+		resp := httptest.NewRecorder()
+		c := NewController(NewRequest(showRequest), NewResponse(resp))
+		result.Apply(c.Request, c.Response)
+		return resp.Body.String()
+	}
+
+	c := NewController(NewRequest(showRequest), NewResponse(httptest.NewRecorder()))
+	body := render(c.RenderJson(obj))
+	eq(t, "default", body, `{"id":9007199254740993,"small":1,"Name":"\u003cb\u003e","tags":[1]}`)
+
+	body = render(c.RenderJson(obj).(RenderJsonResult).WithOptions(JsonOptions{Int64AsString: true}))
+	eq(t, "with options", body, `{"id":"9007199254740993","small":"1","Name":"<b>","tags":["1"]}`)
+
+	type embedded struct {
+		Id int64 `json:"id"`
+	}
+	type outer struct {
+		embedded
+		Quoted  int64            `json:"quoted,string"`
+		Text    textInt64        `json:"text"`
+		Marshal *pointerMarshal  `json:"marshal"`
+		Map     map[int64]uint64 `json:"map"`
+		Any     interface{}      `json:"any,omitempty"`
+	}
+	obj2 := outer{embedded{1}, 2, 3, &pointerMarshal{4}, map[int64]uint64{5: 6}, nil}
+	body = render(c.RenderJson(obj2).(RenderJsonResult).WithOptions(JsonOptions{Int64AsString: true}))
+	eq(t, "encoding/json rules", body, `{"id":"1","quoted":"2","text":"text","marshal":"marshal","map":{"5":"6"}}`)
+
+	Config.SetOption("results.json.int64asstring", "true")
+	Config.SetOption("results.pretty", "true")
+	body = render(c.RenderJson(map[string]int64{"id": 2}))
+	eq(t, "configured", body, "{\n  \"id\": \"2\"\n}")
+}
+
+type textInt64 int64
+
+func (textInt64) MarshalText() ([]byte, error) { return []byte("text"), nil }
+
+type pointerMarshal struct{ V int64 }
+
+func (*pointerMarshal) MarshalJSON() ([]byte, error) { return []byte(`"marshal"`), nil }
+
+func TestRenderJsonLastModified(t *testing.T) {
+	startFakeBookingApp()
+
+	updated := time.Date(2016, 3, 1, 12, 30, 15, 500, time.UTC)
+	render := func(method, ifModifiedSince, ifNoneMatch string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, "/hotels/3", nil)
+		if ifModifiedSince != "" {
+			req.Header.Set("If-Modified-Since", ifModifiedSince)
+		}
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		resp := httptest.NewRecorder()
+		c := NewController(NewRequest(req), NewResponse(resp))
+		c.RenderJson(map[string]int{"id": 3}).(RenderJsonResult).LastModified(updated).Apply(c.Request, c.Response)
+		return resp
+	}
+
+	resp := render("GET", "", "")
+	eq(t, "status", resp.Code, http.StatusOK)
+	eq(t, "Last-Modified", resp.Header().Get("Last-Modified"), "Tue, 01 Mar 2016 12:30:15 GMT")
+	eq(t, "body", resp.Body.String(), `{"id":3}`)
+
+	for _, since := range []string{"Tue, 01 Mar 2016 12:30:15 GMT", "Wed, 02 Mar 2016 00:00:00 GMT"} {
+		resp = render("GET", since, "")
+		if resp.Code != http.StatusNotModified || resp.Body.Len() != 0 {
+			t.Errorf("If-Modified-Since %s: expected 304 without a body, got %d %q", since, resp.Code, resp.Body.String())
+		}
+	}
+	for _, tc := range []struct{ method, since, noneMatch string }{
+		{"GET", "Tue, 01 Mar 2016 12:30:14 GMT", ""},
+		{"GET", "yesterday", ""},
+		{"GET", "Tue, 01 Mar 2016 12:30:15 GMT", `"abc"`},
+		{"POST", "Tue, 01 Mar 2016 12:30:15 GMT", ""},
+	} {
+		if resp = render(tc.method, tc.since, tc.noneMatch); resp.Code != http.StatusOK {
+			t.Errorf("%s If-Modified-Since %s (If-None-Match %s): expected 200, got %d", tc.method, tc.since, tc.noneMatch, resp.Code)
+		}
+	}
+}
+
+func TestRenderContentType(t *testing.T) {
+	startFakeBookingApp()
+
+	render := func(result Result) string {
+		resp := httptest.NewRecorder()
+		c := NewController(NewRequest(showRequest), NewResponse(resp))
+		result.Apply(c.Request, c.Response)
+		return resp.Header().Get("Content-Type")
+	}
+
+	c := NewController(NewRequest(showRequest), NewResponse(httptest.NewRecorder()))
+	eq(t, "json", render(c.RenderJson(1)), "application/json; charset=utf-8")
+	eq(t, "text", render(c.RenderText("hi")), "text/plain; charset=utf-8")
+	eq(t, "per result", render(c.RenderJson(1).(RenderJsonResult).WithContentType("application/vnd.api+json")), "application/vnd.api+json")
+
+	Config.SetOption("results.charset", "iso-8859-1")
+	eq(t, "charset", render(c.RenderText("hi")), "text/plain; charset=iso-8859-1")
+	eq(t, "json charset", render(c.RenderJson(1)), "application/json; charset=iso-8859-1")
+
+	Config.SetOption("results.json.contenttype", "application/json")
+	eq(t, "configured json", render(c.RenderJson(1)), "application/json")
+}
+
+func TestRenderJsonStream(t *testing.T) {
+	startFakeBookingApp()
+
+	ch := make(chan interface{})
+	go func() {
+		for i := 0; i < 3; i++ {
+			ch <- map[string]int{"id": i}
+		}
+		close(ch)
+	}()
+
+	resp := httptest.NewRecorder()
+	c := NewController(NewRequest(showRequest), NewResponse(resp))
+	c.RenderJsonStream(ch).Apply(c.Request, c.Response)
+	eq(t, "body", resp.Body.String(), `[{"id":0},{"id":1},{"id":2}]`)
+	eq(t, "content type", resp.Header().Get("Content-Type"), "application/json; charset=utf-8")
+
+	// A disconnected client aborts the stream without blocking the producer.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	ch = make(chan interface{})
+	done := make(chan bool)
+	go func() {
+		for i := 0; i < 3; i++ {
+			ch <- i
+		}
+		close(ch)
+		close(done)
+	}()
+
+	resp = httptest.NewRecorder()
+	c = NewController(NewRequest(showRequest.WithContext(ctx)), NewResponse(resp))
+	c.RenderJsonStream(ch).Apply(c.Request, c.Response)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Error("Expected the producer to be drained after the client disconnected")
+	}
+	if strings.HasSuffix(resp.Body.String(), "]") {
+		t.Errorf("Expected an incomplete array, got %s", resp.Body)
+	}
+}
+
 func BenchmarkRenderChunked(b *testing.B) {
 	startFakeBookingApp()
 	resp := httptest.NewRecorder()
@@ -48,3 +250,295 @@ func BenchmarkRenderNotChunked(b *testing.B) {
 		hotels.Show(3).Apply(c.Request, c.Response)
 	}
 }
+
+func TestRenderDownload(t *testing.T) {
+	startFakeBookingApp()
+
+	download := func(name, contentType, rangeHeader string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/reports/latest", nil)
+		if rangeHeader != "" {
+			req.Header.Set("Range", rangeHeader)
+		}
+		resp := httptest.NewRecorder()
+		c := NewController(NewRequest(req), NewResponse(resp))
+		c.RenderDownload(strings.NewReader("id,name\n1,rob\n"), name, contentType).Apply(c.Request, c.Response)
+		return resp
+	}
+
+	resp := download("report.csv", "text/csv", "")
+	eq(t, "status", resp.Code, http.StatusOK)
+	eq(t, "body", resp.Body.String(), "id,name\n1,rob\n")
+	eq(t, "Content-Type", resp.Header().Get("Content-Type"), "text/csv")
+	eq(t, "Content-Length", resp.Header().Get("Content-Length"), "14")
+	eq(t, "Content-Disposition", resp.Header().Get("Content-Disposition"), `attachment; filename="report.csv"`)
+
+	resp = download("Über \"report\".csv", "", "bytes=3-6")
+	eq(t, "range status", resp.Code, http.StatusPartialContent)
+	eq(t, "range body", resp.Body.String(), "name")
+	eq(t, "guessed Content-Type", resp.Header().Get("Content-Type"), ContentTypeByFilename("report.csv"))
+	eq(t, "encoded Content-Disposition", resp.Header().Get("Content-Disposition"),
+		`attachment; filename="_ber _report_.csv"; filename*=UTF-8''%C3%9Cber%20%22report%22.csv`)
+}
+
+func TestRenderFileName(t *testing.T) {
+	startFakeBookingApp()
+	dir, err := ioutil.TempDir("", "revel")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "movie.txt")
+	ioutil.WriteFile(filename, []byte("0123456789"), 0644)
+
+	render := func(filename string, header http.Header) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/movies/1", nil)
+		for name, values := range header {
+			req.Header[name] = values
+		}
+		resp := httptest.NewRecorder()
+		c := NewController(NewRequest(req), NewResponse(resp))
+		c.RenderFileName(filename, Inline).Apply(c.Request, c.Response)
+		return resp
+	}
+
+	resp := render(filename, nil)
+	etag := resp.Header().Get("Etag")
+	if resp.Code != http.StatusOK || resp.Body.String() != "0123456789" || !strings.HasPrefix(etag, `W/"`) {
+		t.Errorf("Expected the file with an ETag, got %d %q %v", resp.Code, resp.Body.String(), resp.Header())
+	}
+	resp = render(filename, http.Header{"Range": {"bytes=2-4"}})
+	eq(t, "range status", resp.Code, http.StatusPartialContent)
+	eq(t, "range body", resp.Body.String(), "234")
+	resp = render(filename, http.Header{"If-None-Match": {etag}})
+	eq(t, "conditional status", resp.Code, http.StatusNotModified)
+
+	for _, missing := range []string{filepath.Join(dir, "missing.txt"), dir} {
+		if resp = render(missing, nil); resp.Code != http.StatusNotFound {
+			t.Errorf("Expected %s to be not found, got %d", missing, resp.Code)
+		}
+	}
+}
+
+func TestRenderFilePrecompressed(t *testing.T) {
+	startFakeBookingApp()
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "app.css")
+	ioutil.WriteFile(filename, []byte("body {}"), 0644)
+	ioutil.WriteFile(filename+".gz", []byte("gzipped"), 0644)
+	ioutil.WriteFile(filename+".br", []byte("brotli"), 0644)
+
+	render := func(acceptEncoding string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/public/app.css", nil)
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+		resp := httptest.NewRecorder()
+		c := NewController(NewRequest(req), NewResponse(resp))
+		c.RenderFileName(filename, Inline).Apply(c.Request, c.Response)
+		return resp
+	}
+
+	if resp := render("gzip, br"); resp.Body.String() != "body {}" || resp.Header().Get("Content-Encoding") != "" {
+		t.Errorf("Expected the uncompressed file unless configured, got %q", resp.Body.String())
+	}
+
+	Config.SetOption("results.precompressed", "true")
+	defer Config.SetOption("results.precompressed", "false")
+	for acceptEncoding, expected := range map[string]string{
+		"gzip, deflate, br":  "brotli",
+		"gzip":               "gzipped",
+		"br;q=0, *":          "gzipped",
+		"identity":           "body {}",
+		"":                   "body {}",
+		"*;q=0.5, gzip;q=0":  "brotli",
+		"deflate, br;q=0.00": "body {}",
+	} {
+		resp := render(acceptEncoding)
+		eq(t, acceptEncoding+" body", resp.Body.String(), expected)
+		eq(t, acceptEncoding+" Content-Type", resp.Header().Get("Content-Type"), ContentTypeByFilename("app.css"))
+		eq(t, acceptEncoding+" Vary", resp.Header().Get("Vary"), "Accept-Encoding")
+		encoding := map[string]string{"brotli": "br", "gzipped": "gzip"}[expected]
+		eq(t, acceptEncoding+" Content-Encoding", resp.Header().Get("Content-Encoding"), encoding)
+	}
+
+	// Out of date variants are not used.
+	old := time.Now().Add(-time.Hour)
+	os.Chtimes(filename+".br", old, old)
+	eq(t, "outdated variant", render("br, gzip").Body.String(), "gzipped")
+}
+
+func TestBinaryResultDisposition(t *testing.T) {
+	startFakeBookingApp()
+
+	render := func(download bool) *httptest.ResponseRecorder {
+		resp := httptest.NewRecorder()
+		c := NewController(NewRequest(showRequest), NewResponse(resp))
+		delivery := Inline
+		if download {
+			delivery = Attachment
+		}
+		result := c.RenderBinary(strings.NewReader("%PDF-1.4"), "tmp123", Inline, time.Now())
+		WithDisposition(result, delivery, "invoice.pdf").Apply(c.Request, c.Response)
+		return resp
+	}
+
+	resp := render(false)
+	eq(t, "inline", resp.Header().Get("Content-Disposition"), `inline; filename="invoice.pdf"`)
+	eq(t, "Content-Type", resp.Header().Get("Content-Type"), "application/pdf")
+	eq(t, "attachment", render(true).Header().Get("Content-Disposition"), `attachment; filename="invoice.pdf"`)
+
+	result := (&BinaryResult{Name: "report.csv", Delivery: Attachment}).Disposition(Inline, "")
+	eq(t, "name kept", result.Name, "report.csv")
+
+	text := RenderTextResult{"text"}
+	eq(t, "other results", WithDisposition(text, Attachment, "a.txt"), Result(text))
+}
+
+func TestCustomResult(t *testing.T) {
+	resp := httptest.NewRecorder()
+	c := NewController(NewRequest(showRequest), NewResponse(resp))
+	c.Response.Status = http.StatusCreated
+
+	var result Result = ResultFunc(func(req *Request, resp *Response) {
+		resp.Header().Set("X-Rows", "2")
+		resp.Write([]byte("id,name\n"))
+		resp.Write([]byte("1,rob\n"))
+		resp.Flush()
+	})
+	result.Apply(c.Request, c.Response)
+
+	eq(t, "status", resp.Code, http.StatusCreated)
+	eq(t, "header", resp.Header().Get("X-Rows"), "2")
+	eq(t, "Content-Type", resp.Header().Get("Content-Type"), "text/plain; charset=utf-8")
+	eq(t, "body", resp.Body.String(), "id,name\n1,rob\n")
+	eq(t, "flushed", resp.Flushed, true)
+}
+
+type CSVAudit struct {
+	Created time.Time `csv:"created"`
+}
+
+type csvLevel int
+
+func (l csvLevel) MarshalText() ([]byte, error) {
+	return []byte(strings.Repeat("*", int(l))), nil
+}
+
+func TestRenderCSV(t *testing.T) {
+	startFakeBookingApp()
+
+	type booking struct {
+		Id      int    `csv:"id"`
+		Name    string `csv:"name"`
+		Notes   string
+		Stars   csvLevel `csv:"stars"`
+		Price   *float64 `csv:"price"`
+		Secret  string   `csv:"-"`
+		private string
+		CSVAudit
+	}
+	price := 99.5
+	created := time.Date(2016, 2, 1, 12, 0, 0, 0, time.UTC)
+	rows := []*booking{
+		{Id: 1, Name: "Rob", Notes: `Says "hi", twice`, Stars: 3, Price: &price, Secret: "x", CSVAudit: CSVAudit{created}},
+		{Id: 2, Name: "Multi\nline", Notes: "a;b"},
+	}
+
+	render := func(result Result) *httptest.ResponseRecorder {
+		resp := httptest.NewRecorder()
+		c := NewController(NewRequest(showRequest), NewResponse(resp))
+		result.Apply(c.Request, c.Response)
+		return resp
+	}
+
+	c := NewController(NewRequest(showRequest), NewResponse(httptest.NewRecorder()))
+	resp := render(c.RenderCSV(rows))
+	eq(t, "Content-Type", resp.Header().Get("Content-Type"), "text/csv; charset=utf-8")
+	eq(t, "Content-Disposition", resp.Header().Get("Content-Disposition"), "")
+	eq(t, "body", resp.Body.String(), "id,name,Notes,stars,price,created\r\n"+
+		"1,Rob,\"Says \"\"hi\"\", twice\",***,99.5,2016-02-01T12:00:00Z\r\n"+
+		"2,\"Multi\r\nline\",a;b,,,0001-01-01T00:00:00Z\r\n")
+
+	resp = render(c.RenderCSV([]booking{}).WithOptions(CSVOptions{Comma: ';', Filename: "bookings.csv"}))
+	eq(t, "download", resp.Header().Get("Content-Disposition"), `attachment; filename="bookings.csv"`)
+	eq(t, "empty body", resp.Body.String(), "id;name;Notes;stars;price;created\r\n")
+
+	resp = render(c.RenderCSV([]int{1, 2}))
+	eq(t, "invalid rows", resp.Code, http.StatusInternalServerError)
+}
+
+func TestRenderNegotiated(t *testing.T) {
+	startFakeBookingApp()
+
+	defer func(registered map[string]Serializer, contentTypes []string) {
+		serializers, serializerContentTypes = registered, contentTypes
+	}(serializers, serializerContentTypes)
+	serializers = map[string]Serializer{"application/json": serializers["application/json"], "application/xml": serializers["application/xml"]}
+	serializerContentTypes = []string{"application/json", "application/xml"}
+	RegisterSerializer("Application/YAML", func(obj interface{}) ([]byte, error) {
+		return []byte("name: " + obj.(*Hotel).Name + "\n"), nil
+	})
+
+	hotel := &Hotel{3, "A Hotel", "300 Main St.", "New York", "NY", "10010", "USA", 300}
+	render := func(accept string, negotiated bool) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/hotels/3", nil)
+		req.Header.Set("Accept", accept)
+		resp := httptest.NewRecorder()
+		c := NewController(NewRequest(req), NewResponse(resp))
+		if negotiated {
+			c.RenderNegotiated(hotel).Apply(c.Request, c.Response)
+		} else {
+			c.Serialize(hotel).Apply(c.Request, c.Response)
+		}
+		return resp
+	}
+
+	for accept, expected := range map[string]string{
+		"":                                    "application/json; charset=utf-8",
+		"*/*":                                 "application/json; charset=utf-8",
+		"application/yaml":                    "application/yaml",
+		"text/html, application/xml;q=0.9":    "application/xml; charset=utf-8",
+		"application/json;q=0.5, */*;q=0.1":   "application/json; charset=utf-8",
+		"application/json;q=0, application/*": "application/xml; charset=utf-8",
+	} {
+		resp := render(accept, true)
+		eq(t, "Content-Type for "+accept, resp.Header().Get("Content-Type"), expected)
+		eq(t, "Vary for "+accept, resp.Header().Get("Vary"), "Accept")
+	}
+	eq(t, "yaml body", render("application/yaml", true).Body.String(), "name: A Hotel\n")
+	if body := render("application/xml", true).Body.String(); !strings.Contains(body, "<Name>A Hotel</Name>") {
+		t.Errorf("Unexpected XML body: %s", body)
+	}
+
+	resp := render("text/html", true)
+	eq(t, "not acceptable", resp.Code, http.StatusNotAcceptable)
+	if !strings.Contains(resp.Body.String(), "application/yaml") {
+		t.Errorf("Expected the available types to be listed: %s", resp.Body)
+	}
+
+	resp = render("text/html", false)
+	eq(t, "serialize default", resp.Header().Get("Content-Type"), "application/json; charset=utf-8")
+	eq(t, "serialize status", resp.Code, http.StatusOK)
+}
+
+func TestLocalizedErrorTemplate(t *testing.T) {
+	startFakeBookingApp()
+
+	render := func(locale, format string) string {
+		req, _ := http.NewRequest("GET", "/missing", nil)
+		resp := httptest.NewRecorder()
+		c := NewController(NewRequest(req), NewResponse(resp))
+		c.Request.Locale, c.Request.Format = locale, format
+		c.NotFound("No such page").Apply(c.Request, c.Response)
+		eq(t, "status", resp.Code, http.StatusNotFound)
+		return resp.Body.String()
+	}
+
+	if body := render("fr-CA", "html"); !strings.Contains(body, "Page introuvable") {
+		t.Errorf("Expected the French 404 page for fr-CA, got %s", body)
+	}
+	if body := render("de", "html"); strings.Contains(body, "Page introuvable") || !strings.Contains(body, "No such page") {
+		t.Errorf("Expected the default 404 page for de, got %s", body)
+	}
+	if body := render("fr", "json"); !strings.Contains(body, `"No such page"`) {
+		t.Errorf("Expected the JSON 404 page, got %s", body)
+	}
+}
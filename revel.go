@@ -38,9 +38,9 @@ var (
 	ImportPath string // e.g. "corp/sample"
 	SourcePath string // e.g. "/Users/robfig/gocode/src"
 
-	Config  *config.Context
-	RunMode string // Application-defined (by default, "dev" or "prod")
-	DevMode bool   // if true, RunMode is a development mode.
+	Config  *config.Context // Replaced by ReloadConfig: read it with CurrentConfig while serving.
+	RunMode string          // Application-defined (by default, "dev" or "prod")
+	DevMode bool            // if true, RunMode is a development mode.
 
 	// Revel installation details
 	RevelPath string // e.g. "/Users/robfig/gocode/src/revel"
@@ -105,8 +105,8 @@ var (
 	Initialized bool
 
 	// Private
-	secretKey []byte // Key used to sign cookies. An empty key disables signing.
-	packaged  bool   // If true, this is running from a pre-built package.
+	secretKeys [][]byte // Keys used to verify cookies, the first also to sign them. None disables signing.
+	packaged   bool     // If true, this is running from a pre-built package.
 )
 
 func init() {
@@ -168,17 +168,18 @@ func Init(mode, importPath, srcPath string) {
 		path.Join(RevelPath, "templates"),
 	}
 
-	// Load app.conf
+	// If empty string is passed as the mode, treat it as "DEFAULT"
+	if mode == "" {
+		mode = config.DEFAULT_SECTION
+	}
+
+	// Load app.conf and its overlays
 	var err error
-	Config, err = config.LoadContext("app.conf", ConfPaths)
+	Config, err = loadConfig(ConfPaths, mode)
 	if err != nil || Config == nil {
 		log.Fatalln("Failed to load app.conf:", err)
 	}
 	// Ensure that the selected runmode appears in app.conf.
-	// If empty string is passed as the mode, treat it as "DEFAULT"
-	if mode == "" {
-		mode = config.DEFAULT_SECTION
-	}
 	if !Config.HasSection(mode) {
 		log.Fatalln("app.conf: No mode found:", mode)
 	}
@@ -206,12 +207,22 @@ func Init(mode, importPath, srcPath string) {
 	CookieDomain = Config.StringDefault("cookie.domain", "")
 	CookieSecure = Config.BoolDefault("cookie.secure", !DevMode)
 	TemplateDelims = Config.StringDefault("template.delimiters", "")
-	if secretStr := Config.StringDefault("app.secret", ""); secretStr != "" {
-		secretKey = []byte(secretStr)
+	secretKeys = parseSecretKeys(Config.StringDefault("app.secrets", ""))
+	if secretStr := Config.StringDefault("app.secret", ""); len(secretKeys) == 0 && secretStr != "" {
+		secretKeys = [][]byte{[]byte(secretStr)}
 	}
 
-	// Configure logging
-	if !Config.BoolDefault("log.colorize", true) {
+	configureLoggers()
+
+	loadModules()
+
+	Initialized = true
+	INFO.Printf("Initialized Revel v%s (%s) for %s", Version, BuildDate, MinimumGoVersion)
+}
+
+// configureLoggers creates the loggers from the log.* directives in app.conf.
+func configureLoggers() {
+	if !CurrentConfig().BoolDefault("log.colorize", true) {
 		gocolorize.SetPlain(true)
 	}
 
@@ -223,11 +234,6 @@ func Init(mode, importPath, srcPath string) {
 	// Revel request access logger, not exposed from package.
 	// However output settings can be controlled from app.conf
 	requestLog = getLogger("request")
-
-	loadModules()
-
-	Initialized = true
-	INFO.Printf("Initialized Revel v%s (%s) for %s", Version, BuildDate, MinimumGoVersion)
 }
 
 // Create a logger using log.* directives in app.conf plus the current settings
@@ -236,7 +242,7 @@ func getLogger(name string) *log.Logger {
 	var logger *log.Logger
 
 	// Create a logger with the requested output. (default to stderr)
-	output := Config.StringDefault("log."+name+".output", "stderr")
+	output := CurrentConfig().StringDefault("log."+name+".output", "stderr")
 	var newlog revelLogs
 
 	switch output {
@@ -271,12 +277,12 @@ func getLogger(name string) *log.Logger {
 	}
 
 	// Set the prefix / flags.
-	flags, found := Config.Int("log." + name + ".flags")
+	flags, found := CurrentConfig().Int("log." + name + ".flags")
 	if found {
 		logger.SetFlags(flags)
 	}
 
-	prefix, found := Config.String("log." + name + ".prefix")
+	prefix, found := CurrentConfig().String("log." + name + ".prefix")
 	if found {
 		logger.SetPrefix(prefix)
 	}
@@ -284,6 +290,32 @@ func getLogger(name string) *log.Logger {
 	return logger
 }
 
+// reconfigureLoggers applies the log.* directives to the existing loggers in
+// place, as they are in use by the running requests.  A logger shared by
+// several levels is configured once, by the first of them.
+func reconfigureLoggers() {
+	if !CurrentConfig().BoolDefault("log.colorize", true) {
+		gocolorize.SetPlain(true)
+	}
+
+	done := make(map[*log.Logger]bool)
+	for _, l := range []struct {
+		name   string
+		logger *log.Logger
+	}{
+		{"trace", TRACE}, {"info", INFO}, {"warn", WARN}, {"error", ERROR}, {"request", requestLog},
+	} {
+		if done[l.logger] {
+			continue
+		}
+		done[l.logger] = true
+		configured := getLogger(l.name)
+		l.logger.SetOutput(configured.Writer())
+		l.logger.SetFlags(configured.Flags())
+		l.logger.SetPrefix(configured.Prefix())
+	}
+}
+
 func newLogger(wr io.Writer) *log.Logger {
 	return log.New(wr, "", INFO.Flags())
 }
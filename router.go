@@ -1,6 +1,7 @@
 package revel
 
 import (
+	"context"
 	"encoding/csv"
 	"fmt"
 	"io"
@@ -28,11 +29,14 @@ type Route struct {
 }
 
 type RouteMatch struct {
+	Method         string // e.g. GET, or * (the method of the route)
 	Action         string // e.g. 404
 	ControllerName string // e.g. Application
 	MethodName     string // e.g. ShowApp
 	FixedParams    []string
 	Params         map[string][]string // e.g. {id: 123}
+	Path           string              // e.g. /app/:id
+	Redirect       string              // e.g. /app/123/ (set by the trailing slash policy)
 }
 
 type arg struct {
@@ -88,8 +92,23 @@ type Router struct {
 	Routes []*Route
 	Tree   *pathtree.Node
 	path   string // path to the routes file
+
+	// TrailingSlash is the policy applied when the request path and the
+	// matched route differ only by a trailing slash:
+	//   "ignore"   - match either form (the default).
+	//   "strict"   - only match the form given in the routes file.
+	//   "redirect" - redirect to the form given in the routes file
+	//                (301 for GET and HEAD, 308 otherwise).
+	// Set from "router.trailingslash".
+	TrailingSlash string
 }
 
+const (
+	TRAILING_SLASH_IGNORE   = "ignore"
+	TRAILING_SLASH_STRICT   = "strict"
+	TRAILING_SLASH_REDIRECT = "redirect"
+)
+
 var notFound = &RouteMatch{Action: "404"}
 
 func (router *Router) Route(req *http.Request) *RouteMatch {
@@ -99,11 +118,32 @@ func (router *Router) Route(req *http.Request) *RouteMatch {
 	}
 
 	leaf, expansions := router.Tree.Find(treePath(req.Method, req.URL.Path))
+	if leaf == nil && router.TrailingSlash != TRAILING_SLASH_STRICT && req.URL.Path != "/" {
+		// Look for the route under the other trailing slash form.
+		alternatePath := req.URL.Path + "/"
+		if strings.HasSuffix(req.URL.Path, "/") {
+			alternatePath = strings.TrimSuffix(req.URL.Path, "/")
+		}
+		leaf, expansions = router.Tree.Find(treePath(req.Method, alternatePath))
+	}
 	if leaf == nil {
 		return nil
 	}
 	route := leaf.Value.(*Route)
 
+	// Apply the trailing slash policy.
+	if canonicalPath, differs := route.canonicalPath(req.URL.Path); differs {
+		switch router.TrailingSlash {
+		case TRAILING_SLASH_STRICT:
+			return nil
+		case TRAILING_SLASH_REDIRECT:
+			if req.URL.RawQuery != "" {
+				canonicalPath += "?" + req.URL.RawQuery
+			}
+			return &RouteMatch{Redirect: canonicalPath}
+		}
+	}
+
 	// Create a map of the route parameters.
 	var params url.Values
 	if len(expansions) > 0 {
@@ -128,11 +168,31 @@ func (router *Router) Route(req *http.Request) *RouteMatch {
 	}
 
 	return &RouteMatch{
+		Method:         route.Method,
 		ControllerName: controllerName,
 		MethodName:     methodName,
 		Params:         params,
 		FixedParams:    route.FixedParams,
+		Path:           route.Path,
+	}
+}
+
+// canonicalPath returns the request path in the trailing slash form used by
+// the route, and whether that differs from the given path.
+// Routes ending in a "*" wildcard capture the rest of the path, so the
+// request path is always canonical for them.
+func (route *Route) canonicalPath(requestPath string) (string, bool) {
+	if requestPath == "/" || route.Path == "/" || strings.Contains(route.Path, "/*") {
+		return requestPath, false
+	}
+	routeSlash := strings.HasSuffix(route.Path, "/")
+	if strings.HasSuffix(requestPath, "/") == routeSlash {
+		return requestPath, false
 	}
+	if routeSlash {
+		return requestPath + "/", true
+	}
+	return strings.TrimSuffix(requestPath, "/"), true
 }
 
 // Refresh re-reads the routes file and re-calculates the routing table.
@@ -413,7 +473,7 @@ func (router *Router) Reverse(action string, argValues map[string]string) *Actio
 			Star:   star,
 			Action: action,
 			Args:   argValues,
-			Host:   "TODO",
+			Host:   canonicalHost(),
 		}
 	}
 	ERROR.Println("Failed to find reverse route:", action, argValues)
@@ -423,8 +483,9 @@ func (router *Router) Reverse(action string, argValues map[string]string) *Actio
 func init() {
 	OnAppStart(func() {
 		MainRouter = NewRouter(path.Join(BasePath, "conf", "routes"))
+		MainRouter.TrailingSlash = CurrentConfig().StringDefault("router.trailingslash", TRAILING_SLASH_IGNORE)
 		err := MainRouter.Refresh()
-		if MainWatcher != nil && Config.BoolDefault("watch.routes", true) {
+		if MainWatcher != nil && CurrentConfig().BoolDefault("watch.routes", true) {
 			MainWatcher.Listen(MainRouter, MainRouter.path)
 		} else if err != nil {
 			// Not in dev mode and Route loading failed, we should crash.
@@ -433,6 +494,31 @@ func init() {
 	})
 }
 
+type matchedRouteKey struct{}
+
+// MatchedRoute describes the route matched by a request, by its pattern
+// rather than the concrete path, e.g. for low-cardinality metric labels and
+// per-route logging.
+type MatchedRoute struct {
+	Method string // The method of the route, e.g. "GET", or "*"
+	Path   string // The path pattern of the route, e.g. "/hotels/:id"
+	Action string // The action invoked, e.g. "Hotels.Show"
+}
+
+// MatchedRouteFromContext returns the route matched by the request whose
+// context is given, as stored by the RouterFilter, or nil if the request was
+// not routed (yet).
+func MatchedRouteFromContext(ctx context.Context) *MatchedRoute {
+	route, _ := ctx.Value(matchedRouteKey{}).(*MatchedRoute)
+	return route
+}
+
+// MatchedRoute returns the route matched by the request, or nil if it was not
+// routed (yet).  Filters must run after the RouterFilter to see it.
+func (c *Controller) MatchedRoute() *MatchedRoute {
+	return MatchedRouteFromContext(c.Request.Context())
+}
+
 func RouterFilter(c *Controller, fc []Filter) {
 	// Figure out the Controller/Action
 	var route *RouteMatch = MainRouter.Route(c.Request.Request)
@@ -441,6 +527,18 @@ func RouterFilter(c *Controller, fc []Filter) {
 		return
 	}
 
+	// The trailing slash policy may redirect to the canonical URL.
+	// Non-GET requests get a 308, so that the method and body are preserved.
+	if route.Redirect != "" {
+		if method := c.Request.Method; method == "GET" || method == "HEAD" {
+			c.Response.Status = http.StatusMovedPermanently
+		} else {
+			c.Response.Status = http.StatusPermanentRedirect
+		}
+		c.Result = c.Redirect(route.Redirect)
+		return
+	}
+
 	// The route may want to explicitly return a 404.
 	if route.Action == "404" {
 		c.Result = c.NotFound("(intentionally)")
@@ -454,7 +552,10 @@ func RouterFilter(c *Controller, fc []Filter) {
 	}
 
 	// Add the route and fixed params to the Request Params.
+	c.Route = route
 	c.Params.Route = route.Params
+	c.Request.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), matchedRouteKey{},
+		&MatchedRoute{Method: route.Method, Path: route.Path, Action: c.Action}))
 
 	// Add the fixed parameters mapped by name.
 	// TODO: Pre-calculate this mapping.
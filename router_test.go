@@ -3,6 +3,7 @@ package revel
 import (
 	"fmt"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"strings"
 	"testing"
@@ -277,6 +278,81 @@ func TestRouteMatches(t *testing.T) {
 	}
 }
 
+func TestRouteTrailingSlashPolicy(t *testing.T) {
+	router := NewRouter("")
+	router.Routes, _ = parseRoutes("", "", TEST_ROUTES, false)
+	router.updateTree()
+
+	testCases := []struct {
+		policy, method, path, redirect string
+		found                          bool
+	}{
+		{TRAILING_SLASH_IGNORE, "GET", "/test", "", true},
+		{TRAILING_SLASH_IGNORE, "GET", "/test/", "", true},
+		{TRAILING_SLASH_STRICT, "GET", "/test", "", false},
+		{TRAILING_SLASH_STRICT, "GET", "/test/", "", true},
+		{TRAILING_SLASH_STRICT, "POST", "/app/123/", "", false},
+		{TRAILING_SLASH_REDIRECT, "GET", "/test", "/test/", true},
+		{TRAILING_SLASH_REDIRECT, "GET", "/test/", "", true},
+		{TRAILING_SLASH_REDIRECT, "POST", "/app/123/", "/app/123", true},
+		{TRAILING_SLASH_REDIRECT, "GET", "/public/css/", "", true},
+	}
+	for _, tc := range testCases {
+		router.TrailingSlash = tc.policy
+		req := &http.Request{Method: tc.method, URL: &url.URL{Path: tc.path}}
+		match := router.Route(req)
+		if !eq(t, tc.policy+" "+tc.method+" "+tc.path+" found", match != nil, tc.found) || match == nil {
+			continue
+		}
+		eq(t, tc.policy+" "+tc.method+" "+tc.path+" redirect", match.Redirect, tc.redirect)
+	}
+}
+
+func TestRouterFilterTrailingSlashRedirect(t *testing.T) {
+	startFakeBookingApp()
+	MainRouter.TrailingSlash = TRAILING_SLASH_REDIRECT
+	defer func() { MainRouter.TrailingSlash = TRAILING_SLASH_IGNORE }()
+
+	testCases := []struct {
+		method, url, location string
+		status                int
+	}{
+		{"GET", "/hotels/?page=2", "/hotels?page=2", http.StatusMovedPermanently},
+		{"POST", "/Hotels/Index/", "/Hotels/Index", http.StatusPermanentRedirect},
+	}
+	for _, tc := range testCases {
+		req, _ := http.NewRequest(tc.method, tc.url, nil)
+		resp := httptest.NewRecorder()
+		c := NewController(NewRequest(req), NewResponse(resp))
+		RouterFilter(c, NilChain)
+		c.Result.Apply(c.Request, c.Response)
+		eq(t, tc.method+" status", resp.Code, tc.status)
+		eq(t, tc.method+" location", resp.Header().Get("Location"), tc.location)
+	}
+}
+
+func TestRouterFilterMatchedRoute(t *testing.T) {
+	startFakeBookingApp()
+
+	resp := httptest.NewRecorder()
+	c := NewController(NewRequest(showRequest), NewResponse(resp))
+	if route := c.MatchedRoute(); route != nil {
+		t.Errorf("Expected no matched route before routing, got %v", route)
+	}
+
+	var fromContext *MatchedRoute
+	RouterFilter(c, []Filter{func(c *Controller, fc []Filter) {
+		fromContext = MatchedRouteFromContext(c.Request.Context())
+	}})
+	expected := MatchedRoute{Method: "GET", Path: "/hotels/:id", Action: "Hotels.Show"}
+	if route := c.MatchedRoute(); route == nil || *route != expected {
+		t.Errorf("Expected the matched route %v, got %v", expected, route)
+	}
+	if fromContext == nil || *fromContext != expected {
+		t.Errorf("Expected the matched route in the request context, got %v", fromContext)
+	}
+}
+
 // Reverse Routing
 
 type ReverseRouteArgs struct {
@@ -0,0 +1,83 @@
+package revel
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// SecureFilter enforces HTTPS and sets security headers, as configured in
+// app.conf.  Each feature is off unless configured:
+//
+//	secure.redirect          - redirect HTTP requests to HTTPS (default false)
+//	secure.hsts.maxage       - send Strict-Transport-Security on HTTPS responses, with this max-age in seconds (default 0, off)
+//	secure.hsts.subdomains   - add includeSubDomains to it (default false)
+//	secure.hsts.preload      - add preload to it (default false)
+//	secure.nosniff           - send X-Content-Type-Options: nosniff (default false)
+//	secure.frameoptions      - the X-Frame-Options value, e.g. SAMEORIGIN (default none)
+//	secure.csp               - the Content-Security-Policy value (default none)
+//
+// Behind a TLS-terminating proxy, a request is HTTPS if the proxy says so in
+// X-Forwarded-Proto.  The header is only believed from trusted proxies: those
+// in "server.trustedproxies" if set, or else any if "app.behind.proxy" is set.
+func SecureFilter(c *Controller, fc []Filter) {
+	secure := isSecureRequest(c.Request.Request)
+	if !secure && CurrentConfig().BoolDefault("secure.redirect", false) {
+		url := *c.Request.URL
+		url.Scheme, url.Host = "https", c.Request.Host
+		if method := c.Request.Method; method == "GET" || method == "HEAD" {
+			c.Response.Status = http.StatusMovedPermanently
+		} else {
+			c.Response.Status = http.StatusPermanentRedirect
+		}
+		c.Result = c.Redirect(url.String())
+		return
+	}
+
+	header := c.Response.Out.Header()
+	if maxAge := CurrentConfig().IntDefault("secure.hsts.maxage", 0); secure && maxAge > 0 {
+		hsts := "max-age=" + strconv.Itoa(maxAge)
+		if CurrentConfig().BoolDefault("secure.hsts.subdomains", false) {
+			hsts += "; includeSubDomains"
+		}
+		if CurrentConfig().BoolDefault("secure.hsts.preload", false) {
+			hsts += "; preload"
+		}
+		header.Set("Strict-Transport-Security", hsts)
+	}
+	if CurrentConfig().BoolDefault("secure.nosniff", false) {
+		header.Set("X-Content-Type-Options", "nosniff")
+	}
+	if frameOptions := CurrentConfig().StringDefault("secure.frameoptions", ""); frameOptions != "" {
+		header.Set("X-Frame-Options", frameOptions)
+	}
+	if csp := CurrentConfig().StringDefault("secure.csp", ""); csp != "" {
+		header.Set("Content-Security-Policy", csp)
+	}
+
+	fc[0](c, fc[1:])
+}
+
+// isSecureRequest returns true if the request was made over HTTPS, either to
+// this server or to a trusted proxy in front of it.
+func isSecureRequest(r *http.Request) bool {
+	if r.TLS != nil {
+		return true
+	}
+	if !isFromTrustedProxy(r) {
+		return false
+	}
+	proto := strings.TrimSpace(strings.Split(r.Header.Get("X-Forwarded-Proto"), ",")[0])
+	return strings.EqualFold(proto, "https")
+}
+
+// isFromTrustedProxy returns true if the forwarding headers of the request
+// may be believed (see ClientIP).
+func isFromTrustedProxy(r *http.Request) bool {
+	if len(trustedProxies) > 0 {
+		host, _, _ := net.SplitHostPort(r.RemoteAddr)
+		return isTrustedProxy(host)
+	}
+	return CurrentConfig().BoolDefault("app.behind.proxy", false)
+}
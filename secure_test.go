@@ -0,0 +1,76 @@
+package revel
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSecureFilter(t *testing.T) {
+	startFakeBookingApp()
+
+	run := func(method, target string, setup func(*http.Request)) (*httptest.ResponseRecorder, bool) {
+		req, _ := http.NewRequest(method, target, nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		if setup != nil {
+			setup(req)
+		}
+		resp := httptest.NewRecorder()
+		c := NewController(NewRequest(req), NewResponse(resp))
+		called := false
+		SecureFilter(c, []Filter{func(c *Controller, fc []Filter) { called = true }})
+		if c.Result != nil {
+			c.Result.Apply(c.Request, c.Response)
+		}
+		return resp, called
+	}
+	forwardedHTTPS := func(req *http.Request) { req.Header.Set("X-Forwarded-Proto", "https") }
+
+	// Off by default.
+	resp, called := run("GET", "http://example.com/hotels", nil)
+	if !called || len(resp.Header()) != 0 {
+		t.Errorf("Expected the filter to do nothing by default, got %v", resp.Header())
+	}
+
+	Config.SetOption("secure.redirect", "true")
+	Config.SetOption("secure.hsts.maxage", "31536000")
+	Config.SetOption("secure.hsts.subdomains", "true")
+	Config.SetOption("secure.nosniff", "true")
+	Config.SetOption("secure.frameoptions", "DENY")
+	Config.SetOption("secure.csp", "default-src 'self'")
+
+	resp, called = run("GET", "http://example.com/hotels?page=2", nil)
+	if called || resp.Code != http.StatusMovedPermanently || resp.Header().Get("Location") != "https://example.com/hotels?page=2" {
+		t.Errorf("Expected a redirect to HTTPS, got %d to %s", resp.Code, resp.Header().Get("Location"))
+	}
+	resp, _ = run("POST", "http://example.com/hotels", nil)
+	if resp.Code != http.StatusPermanentRedirect {
+		t.Errorf("Expected a 308 for POST, got %d", resp.Code)
+	}
+
+	// X-Forwarded-Proto is ignored unless the request comes from a proxy.
+	resp, called = run("GET", "http://example.com/hotels", forwardedHTTPS)
+	if called {
+		t.Error("Expected X-Forwarded-Proto from an untrusted client to be ignored")
+	}
+
+	Config.SetOption("app.behind.proxy", "true")
+	resp, called = run("GET", "http://example.com/hotels", forwardedHTTPS)
+	if !called {
+		t.Fatalf("Expected a request forwarded as HTTPS to pass, got %d", resp.Code)
+	}
+	for name, expected := range map[string]string{
+		"Strict-Transport-Security": "max-age=31536000; includeSubDomains",
+		"X-Content-Type-Options":    "nosniff",
+		"X-Frame-Options":           "DENY",
+		"Content-Security-Policy":   "default-src 'self'",
+	} {
+		eq(t, name, resp.Header().Get(name), expected)
+	}
+
+	resp, called = run("GET", "https://example.com/hotels", func(req *http.Request) { req.TLS = &tls.ConnectionState{} })
+	if !called || resp.Header().Get("Strict-Transport-Security") == "" {
+		t.Error("Expected TLS requests to pass with HSTS")
+	}
+}
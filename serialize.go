@@ -0,0 +1,169 @@
+package revel
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Serializer encodes a value as a response body of its content type.
+type Serializer func(obj interface{}) ([]byte, error)
+
+var (
+	serializers            = make(map[string]Serializer)
+	serializerContentTypes []string // In order of registration, for clients that accept any type.
+)
+
+// RegisterSerializer registers the serializer for a content type, which
+// Serialize and RenderNegotiated use when the client accepts it, e.g.
+//
+//	revel.RegisterSerializer("application/yaml", func(obj interface{}) ([]byte, error) {
+//	  return yaml.Marshal(obj)
+//	})
+//
+// JSON and XML are registered by Revel, in that order.  Clients that accept
+// any type are sent the first registered type.  Registering another
+// serializer for the same content type replaces the previous one.
+func RegisterSerializer(contentType string, serializer Serializer) {
+	contentType = strings.ToLower(contentType)
+	if _, found := serializers[contentType]; !found {
+		serializerContentTypes = append(serializerContentTypes, contentType)
+	}
+	serializers[contentType] = serializer
+}
+
+// Serialize renders the value with the serializer of the content type the
+// client prefers (see RegisterSerializer), or as JSON if it accepts none of
+// them.
+func (c *Controller) Serialize(obj interface{}) Result {
+	contentType, found := negotiateSerializer(c.Request.Header.Get("Accept"))
+	if !found {
+		contentType = "application/json"
+	}
+	c.setStatusIfNil(http.StatusOK)
+	return RenderSerializedResult{obj: obj, contentType: contentType}
+}
+
+// RenderNegotiated renders the value with the serializer of the content type
+// the client prefers (see RegisterSerializer).  If it accepts none of them,
+// the response is 406 Not Acceptable.
+func (c *Controller) RenderNegotiated(obj interface{}) Result {
+	contentType, found := negotiateSerializer(c.Request.Header.Get("Accept"))
+	if !found {
+		c.Response.Out.Header().Add("Vary", "Accept")
+		c.Response.Status = http.StatusNotAcceptable
+		return c.RenderText("Not Acceptable, available types: %s", strings.Join(serializerContentTypes, ", "))
+	}
+	c.setStatusIfNil(http.StatusOK)
+	return RenderSerializedResult{obj: obj, contentType: contentType}
+}
+
+// RenderSerializedResult renders a value with the serializer registered for
+// its content type.
+type RenderSerializedResult struct {
+	obj         interface{}
+	contentType string
+}
+
+func (r RenderSerializedResult) Apply(req *Request, resp *Response) {
+	serializer, found := serializers[r.contentType]
+	if !found {
+		ErrorResult{Error: fmt.Errorf("revel: no serializer for %s", r.contentType)}.Apply(req, resp)
+		return
+	}
+	b, err := serializer(r.obj)
+	if err != nil {
+		ErrorResult{Error: err}.Apply(req, resp)
+		return
+	}
+
+	resp.Out.Header().Add("Vary", "Accept")
+	resp.WriteHeader(http.StatusOK, serializedContentType(r.contentType))
+	resp.Out.Write(b)
+}
+
+// serializedContentType returns the Content-Type header of a serialized
+// response, with the configured charset for text types.
+func serializedContentType(contentType string) string {
+	switch {
+	case contentType == "application/json":
+		return jsonContentType()
+	case contentType == "application/xml", strings.HasPrefix(contentType, "text/"):
+		return textContentType(contentType)
+	}
+	return contentType
+}
+
+// negotiateSerializer returns the registered content type that is preferred
+// by the given Accept header.  An empty header accepts any type.
+func negotiateSerializer(accept string) (string, bool) {
+	if len(serializerContentTypes) == 0 {
+		return "", false
+	}
+	if strings.TrimSpace(accept) == "" {
+		return serializerContentTypes[0], true
+	}
+
+	type mediaRange struct {
+		name    string
+		quality float64
+	}
+	var ranges []mediaRange
+	refused := make(map[string]bool)
+	for _, part := range strings.Split(accept, ",") {
+		params := strings.Split(part, ";")
+		name := strings.ToLower(strings.TrimSpace(params[0]))
+		quality := 1.0
+		for _, param := range params[1:] {
+			if param = strings.TrimSpace(param); strings.HasPrefix(param, "q=") {
+				if q, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					quality = q
+				}
+			}
+		}
+		if quality <= 0 {
+			// An explicit refusal overrides the wildcards.
+			refused[name] = true
+		} else if name != "" {
+			ranges = append(ranges, mediaRange{name, quality})
+		}
+	}
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].quality > ranges[j].quality })
+
+	for _, r := range ranges {
+		for _, contentType := range serializerContentTypes {
+			if !refused[contentType] && mediaRangeMatches(r.name, contentType) {
+				return contentType, true
+			}
+		}
+	}
+	return "", false
+}
+
+// mediaRangeMatches returns true if the Accept media range, e.g. "*/*",
+// "application/*" or "application/json", matches the content type.
+func mediaRangeMatches(mediaRange, contentType string) bool {
+	if mediaRange == "*/*" || mediaRange == contentType {
+		return true
+	}
+	return strings.HasSuffix(mediaRange, "/*") &&
+		strings.HasPrefix(contentType, strings.TrimSuffix(mediaRange, "*"))
+}
+
+// encodeXml encodes o as XML, indented if "results.pretty" is set.
+func encodeXml(o interface{}) ([]byte, error) {
+	if CurrentConfig().BoolDefault("results.pretty", false) {
+		return xml.MarshalIndent(o, "", "  ")
+	}
+	return xml.Marshal(o)
+}
+
+func init() {
+	RegisterSerializer("application/json", func(obj interface{}) ([]byte, error) {
+		return encodeJson(obj, DefaultJsonOptions())
+	})
+	RegisterSerializer("application/xml", encodeXml)
+}
@@ -25,7 +25,7 @@ var (
 // This method handles all requests.  It dispatches to handleInternal after
 // handling / adapting websocket connections.
 func handle(w http.ResponseWriter, r *http.Request) {
-	if maxRequestSize := int64(Config.IntDefault("http.maxrequestsize", 0)); maxRequestSize > 0 {
+	if maxRequestSize := int64(CurrentConfig().IntDefault("http.maxrequestsize", 0)); maxRequestSize > 0 {
 		r.Body = http.MaxBytesReader(w, r.Body, maxRequestSize)
 	}
 
@@ -55,8 +55,10 @@ func handleInternal(w http.ResponseWriter, r *http.Request, ws *websocket.Conn)
 		c    = NewController(req, resp)
 	)
 	req.Websocket = ws
+	memo := withRequestMemo(req)
+	defer memo.clear()
 
-	Filters[0](c, Filters[1:])
+	runChain(c, Filters)
 	if c.Result != nil {
 		c.Result.Apply(req, resp)
 	} else if c.Response.Status != 0 {
@@ -66,19 +68,46 @@ func handleInternal(w http.ResponseWriter, r *http.Request, ws *websocket.Conn)
 	if w, ok := resp.Out.(io.Closer); ok {
 		w.Close()
 	}
+	c.startJobs()
 
 	// Revel request access log format
 	// RequestStartTime ClientIP ResponseStatus RequestLatency HTTPMethod URLPath
 	// Sample format:
 	// 2016/05/25 17:46:37.112 127.0.0.1 200  270.157µs GET /
-	requestLog.Printf("%v %v %v %10v %v %v",
-		start.Format(requestLogTimeFormat),
-		ClientIP(r),
-		c.Response.Status,
-		time.Since(start),
-		r.Method,
-		r.URL.Path,
-	)
+	//
+	// Requests marked verbose by the VerboseLogFilter add the action and the
+	// request ID:
+	// 2016/05/25 17:46:37.112 127.0.0.1 200  270.157µs GET / App.Index 7f3a9c
+	if IsVerbose(req.Context()) {
+		requestID := r.Header.Get("X-Request-Id")
+		if requestID == "" {
+			requestID = "-"
+		}
+		requestLog.Printf("%v %v %v %10v %v %v %v %v",
+			start.Format(requestLogTimeFormat),
+			ClientIP(r),
+			c.Response.Status,
+			time.Since(start),
+			r.Method,
+			r.URL.Path,
+			c.Action,
+			requestID,
+		)
+	} else {
+		requestLog.Printf("%v %v %v %10v %v %v",
+			start.Format(requestLogTimeFormat),
+			ClientIP(r),
+			c.Response.Status,
+			time.Since(start),
+			r.Method,
+			r.URL.Path,
+		)
+	}
+
+	if resp.aborted {
+		// Close the connection, so the client sees an incomplete response.
+		panic(http.ErrAbortHandler)
+	}
 }
 
 // InitServer intializes the server and returns the handler
@@ -87,24 +116,35 @@ func handleInternal(w http.ResponseWriter, r *http.Request, ws *websocket.Conn)
 // TLS options.
 func InitServer() http.HandlerFunc {
 	runStartupHooks()
+	applyRegisteredFilters()
 
 	// Load templates
 	MainTemplateLoader = NewTemplateLoader(TemplatePaths)
 	MainTemplateLoader.Refresh()
 
+	// Show the routes, filters and route schema on the dev console.
+	if DevMode {
+		Filters = append([]Filter{DevRoutesFilter, DevSchemaFilter}, Filters...)
+	}
+
 	// The "watch" config variable can turn on and off all watching.
 	// (As a convenient way to control it all together.)
-	if Config.BoolDefault("watch", true) {
+	if CurrentConfig().BoolDefault("watch", true) {
 		MainWatcher = NewWatcher()
 		Filters = append([]Filter{WatchFilter}, Filters...)
 	}
 
 	// If desired (or by default), create a watcher for templates and routes.
 	// The watcher calls Refresh() on things on the first request.
-	if MainWatcher != nil && Config.BoolDefault("watch.templates", true) {
+	if MainWatcher != nil && CurrentConfig().BoolDefault("watch.templates", true) {
 		MainWatcher.Listen(MainTemplateLoader, MainTemplateLoader.paths...)
 	}
 
+	// In dev mode, reload the messages when a message file changes.
+	if MainWatcher != nil && CurrentConfig().BoolDefault("watch.messages", true) {
+		MainWatcher.Listen(mainMessageLoader, mainMessageLoader.paths()...)
+	}
+
 	return http.HandlerFunc(handle)
 }
 
@@ -131,14 +171,12 @@ func Run(port int) {
 		localAddress = address + ":" + strconv.Itoa(port)
 	}
 
-	Server = &http.Server{
-		Addr:         localAddress,
-		Handler:      http.HandlerFunc(handle),
-		ReadTimeout:  time.Duration(Config.IntDefault("timeout.read", 0)) * time.Second,
-		WriteTimeout: time.Duration(Config.IntDefault("timeout.write", 0)) * time.Second,
-	}
-
 	InitServer()
+	handleReloadSignal()
+
+	// Build the server once the app has started, so that it is configured
+	// from the final config.
+	Server = newServer(localAddress)
 
 	// Crazy Harness needs this output for "revel run" to work.
 	go func() {
@@ -164,23 +202,71 @@ func Run(port int) {
 	// }
 	// *** ORIGINAL CODE END
 
-	Server = &http.Server{
-		Addr:    localAddress,
-		Handler: http.HandlerFunc(handle),
-	}
-
 	if err := gracehttp.Serve(Server); err != nil {
 		ERROR.Fatalln("Failed to serve:", err)
 	}
 
 	INFO.Println("Waiting for handlers to complete.")
 	wg.Wait()
+	INFO.Println("Waiting for background jobs to complete.")
+	if !waitForJobs(serverTimeout("server.draintimeout", "", 30*time.Second)) {
+		WARN.Println("Timed out waiting for background jobs.")
+	}
 	INFO.Println("Running Shutdown Hooks.")
 	runShutdownHooks()
 
 	INFO.Println("Exit.")
 }
 
+// newServer returns the server for the given address, with the timeouts
+// configured in app.conf (as durations, e.g. "30s"):
+//
+//	server.readtimeout       - reading the whole request (or timeout.read seconds)
+//	server.readheadertimeout - reading the request headers
+//	server.writetimeout      - writing the response (or timeout.write seconds)
+//	server.idletimeout       - keeping idle connections open
+//
+// A timeout of zero, the default, means no timeout.  The read and write
+// deadlines stay on the connection of a hijacked request, so websockets and
+// streaming responses must lift them, e.g. with Response.SetWriteDeadline.
+//
+// Keep-alive connections may be turned off with server.keepalive = false, and
+// server.maxconns caps the number of simultaneous connections (default 0, no
+// cap); connections past the cap are closed as soon as they are accepted.
+func newServer(address string) *http.Server {
+	server := &http.Server{
+		Addr:              address,
+		Handler:           http.HandlerFunc(handle),
+		ReadTimeout:       serverTimeout("server.readtimeout", "timeout.read", 0),
+		ReadHeaderTimeout: serverTimeout("server.readheadertimeout", "", 0),
+		WriteTimeout:      serverTimeout("server.writetimeout", "timeout.write", 0),
+		IdleTimeout:       serverTimeout("server.idletimeout", "", 0),
+	}
+	server.SetKeepAlivesEnabled(CurrentConfig().BoolDefault("server.keepalive", true))
+	if maxConns := CurrentConfig().IntDefault("server.maxconns", 0); maxConns > 0 {
+		server.ConnState = newConnLimiter(maxConns).connState
+	}
+	return server
+}
+
+// serverTimeout returns the duration configured under key, or else the
+// seconds configured under legacyKey, or else the default.
+func serverTimeout(key, legacyKey string, def time.Duration) time.Duration {
+	if value, found := CurrentConfig().String(key); found {
+		timeout, err := time.ParseDuration(value)
+		if err != nil {
+			ERROR.Fatalf("%s invalid: %s", key, err)
+		}
+		return timeout
+	}
+	if legacyKey != "" {
+		if _, found := CurrentConfig().String(legacyKey); found {
+			return time.Duration(CurrentConfig().IntDefault(legacyKey, 0)) * time.Second
+		}
+	}
+	return def
+}
+
 func runStartupHooks() {
 	sort.Sort(startupHooks)
 	for _, hook := range startupHooks {
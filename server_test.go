@@ -1,12 +1,15 @@
 package revel
 
 import (
+	"errors"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path"
 	"strings"
 	"testing"
+	"time"
 )
 
 // This tries to benchmark the usual request-serving pipeline to get an overall
@@ -118,3 +121,90 @@ var (
 	jsonRequest, _      = http.NewRequest("GET", "/hotels/3/booking", nil)
 	plaintextRequest, _ = http.NewRequest("GET", "/hotels", nil)
 )
+
+func TestServerTimeouts(t *testing.T) {
+	startFakeBookingApp()
+
+	server := newServer(":9000")
+	if server.ReadTimeout != 0 || server.ReadHeaderTimeout != 0 ||
+		server.WriteTimeout != 0 || server.IdleTimeout != 0 {
+		t.Errorf("Unexpected default timeouts: %+v", server)
+	}
+
+	Config.SetOption("timeout.read", "30")
+	Config.SetOption("server.writetimeout", "5m")
+	Config.SetOption("server.idletimeout", "2m")
+	server = newServer(":9000")
+	if server.ReadTimeout != 30*time.Second || server.WriteTimeout != 5*time.Minute || server.IdleTimeout != 2*time.Minute {
+		t.Errorf("Unexpected configured timeouts: %+v", server)
+	}
+}
+
+func TestSetWriteDeadline(t *testing.T) {
+	errs := make(chan error, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resp := NewResponse(&CompressResponseWriter{ResponseWriter: w})
+		errs <- resp.SetWriteDeadline(time.Time{})
+	}))
+	defer server.Close()
+
+	if _, err := http.Get(server.URL); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-errs; err != nil {
+		t.Errorf("Expected the deadline to be set through the compressing writer, got %v", err)
+	}
+
+	if err := NewResponse(httptest.NewRecorder()).SetWriteDeadline(time.Time{}); !errors.Is(err, http.ErrNotSupported) {
+		t.Errorf("Expected ErrNotSupported from a recorder, got %v", err)
+	}
+}
+
+func TestServerMaxConns(t *testing.T) {
+	startFakeBookingApp()
+
+	Config.SetOption("server.keepalive", "false")
+	Config.SetOption("server.maxconns", "1")
+	defer Config.SetOption("server.keepalive", "true")
+	defer Config.SetOption("server.maxconns", "0")
+	if server := newServer(":9000"); server.ConnState == nil {
+		t.Error("Expected a connection limit")
+	}
+
+	limiter := newConnLimiter(1)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.Config.ConnState = limiter.connState
+	server.Start()
+	defer server.Close()
+
+	first, err := net.Dial("tcp", server.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := net.Dial("tcp", server.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer second.Close()
+
+	// The second connection is closed by the server without a response.
+	second.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, err := second.Write([]byte("GET / HTTP/1.1\r\nHost: test\r\n\r\n")); err == nil {
+		if n, err := second.Read(make([]byte, 1)); err == nil || n > 0 {
+			t.Errorf("Expected the second connection to be closed, read %d bytes, err %v", n, err)
+		}
+	}
+
+	first.Close()
+	for i := 0; limiter.open() > 0 && i < 100; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if open := limiter.open(); open != 0 {
+		t.Fatalf("Expected no open connections, got %d", open)
+	}
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+}
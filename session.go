@@ -1,8 +1,6 @@
 package revel
 
 import (
-	"crypto/rand"
-	"encoding/hex"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -30,7 +28,7 @@ func init() {
 	// Set expireAfterDuration, default to 30 days if no value in config
 	OnAppStart(func() {
 		var err error
-		if expiresString, ok := Config.String("session.expires"); !ok {
+		if expiresString, ok := CurrentConfig().String("session.expires"); !ok {
 			expireAfterDuration = 30 * 24 * time.Hour
 		} else if expiresString == "session" {
 			expireAfterDuration = 0
@@ -40,19 +38,14 @@ func init() {
 	})
 }
 
-// Id retrieves from the cookie or creates a time-based UUID identifying this
-// session.
+// Id retrieves from the cookie or creates a random ID (see NewID) identifying
+// this session.
 func (s Session) Id() string {
 	if sessionIdStr, ok := s[SESSION_ID_KEY]; ok {
 		return sessionIdStr
 	}
 
-	buffer := make([]byte, 32)
-	if _, err := rand.Read(buffer); err != nil {
-		panic(err)
-	}
-
-	s[SESSION_ID_KEY] = hex.EncodeToString(buffer)
+	s[SESSION_ID_KEY] = NewID()
 	return s[SESSION_ID_KEY]
 }
 
@@ -1,6 +1,8 @@
 package revel
 
 import (
+	"bytes"
+	"crypto/rand"
 	"net/http"
 	"testing"
 	"time"
@@ -24,6 +26,29 @@ func TestSessionRestore(t *testing.T) {
 	}
 }
 
+func TestSessionSecretRotation(t *testing.T) {
+	defer func(keys [][]byte) { secretKeys = keys }(secretKeys)
+	expireAfterDuration = 0
+	session := Session{"foo": "foo"}
+
+	secretKeys = parseSecretKeys("old")
+	cookie := session.Cookie()
+
+	// Cookies signed with a previous secret are still accepted.
+	secretKeys = parseSecretKeys("new, old")
+	if GetSessionFromCookie(cookie)["foo"] != "foo" {
+		t.Error("Expected the session signed with the previous secret to be restored")
+	}
+	if sig := Sign("message"); sig != signWith([]byte("new"), "message") {
+		t.Errorf("Expected to sign with the first secret, got %s", sig)
+	}
+
+	secretKeys = parseSecretKeys("new")
+	if len(GetSessionFromCookie(cookie)) != 0 {
+		t.Error("Expected the session signed with a retired secret to be rejected")
+	}
+}
+
 func TestSessionExpire(t *testing.T) {
 	expireAfterDuration = time.Hour
 	session := make(Session)
@@ -62,3 +87,13 @@ func TestSessionExpire(t *testing.T) {
 		t.Error("expect expires", cookie.Expires, "before", expectExpire)
 	}
 }
+
+func TestSessionIdUsesIDSource(t *testing.T) {
+	defer func() { IDSource = rand.Reader }()
+
+	IDSource = bytes.NewReader(make([]byte, 32))
+	session := make(Session)
+	if id := session.Id(); id != string(bytes.Repeat([]byte("0"), 64)) {
+		t.Errorf("Expected the session ID to come from the IDSource, got %s", id)
+	}
+}
@@ -0,0 +1,144 @@
+package revel
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// SetCookieFilter cleans up the Set-Cookie headers of the response just
+// before they are sent: when the same cookie (the same name, domain and path)
+// is set more than once, e.g. by two filters, only the last one is kept.  It
+// warns when the Set-Cookie headers together are larger than
+// "cookie.maxheadersize" bytes (default 4096), as browsers and proxies may
+// drop them.
+//
+// It should run before the filters that set cookies (e.g. the SessionFilter),
+// so that it sees their cookies too.
+func SetCookieFilter(c *Controller, fc []Filter) {
+	fc[0](c, fc[1:])
+
+	if c.Result == nil {
+		finalizeSetCookies(c.Request, c.Response.Out.Header())
+		return
+	}
+	if len(c.Response.Out.Header()["Set-Cookie"]) > 0 {
+		c.Result = setCookieResult{c.Result}
+	}
+}
+
+// setCookieResult finalizes the Set-Cookie headers when the wrapped result
+// writes the response header.
+type setCookieResult struct {
+	Result
+}
+
+func (r setCookieResult) Apply(req *Request, resp *Response) {
+	out := resp.Out
+	resp.Out = &setCookieResponseWriter{ResponseWriter: out, req: req}
+	defer func() {
+		resp.Out = out
+	}()
+	r.Result.Apply(req, resp)
+}
+
+func (r setCookieResult) Unwrap() Result {
+	return r.Result
+}
+
+// setCookieResponseWriter finalizes the Set-Cookie headers before the
+// response header is written.
+type setCookieResponseWriter struct {
+	http.ResponseWriter
+	req         *Request
+	wroteHeader bool
+}
+
+func (w *setCookieResponseWriter) WriteHeader(status int) {
+	w.finalize()
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// finalize finalizes the Set-Cookie headers, once.
+func (w *setCookieResponseWriter) finalize() {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		finalizeSetCookies(w.req, w.Header())
+	}
+}
+
+func (w *setCookieResponseWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *setCookieResponseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *setCookieResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *setCookieResponseWriter) ReadFrom(r io.Reader) (int64, error) {
+	// The wrapped writer writes the header, e.g. with a sniffed content type.
+	w.finalize()
+	return readFromWriter(w.ResponseWriter, r)
+}
+
+func (w *setCookieResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return hijackWriter(w.ResponseWriter)
+}
+
+func (w *setCookieResponseWriter) CloseNotify() <-chan bool {
+	return closeNotifyWriter(w.ResponseWriter)
+}
+
+// finalizeSetCookies removes the Set-Cookie headers overridden by later ones
+// for the same cookie, and warns if the rest are too large.
+func finalizeSetCookies(req *Request, header http.Header) {
+	setCookies := header["Set-Cookie"]
+	if len(setCookies) == 0 {
+		return
+	}
+
+	last := make(map[string]int, len(setCookies))
+	for i, setCookie := range setCookies {
+		last[setCookieKey(setCookie)] = i
+	}
+	kept := setCookies[:0:0]
+	size := 0
+	for i, setCookie := range setCookies {
+		if last[setCookieKey(setCookie)] != i {
+			continue
+		}
+		kept = append(kept, setCookie)
+		size += len("Set-Cookie: ") + len(setCookie) + len("\r\n")
+	}
+	if len(kept) < len(setCookies) {
+		header["Set-Cookie"] = kept
+	}
+
+	if max := CurrentConfig().IntDefault("cookie.maxheadersize", 4096); max > 0 && size > max {
+		WARN.Printf("Set-Cookie headers of %s %s are %d bytes, more than cookie.maxheadersize (%d)",
+			req.Method, req.URL.Path, size, max)
+	}
+}
+
+// setCookieKey identifies the cookie set by a Set-Cookie header value: its
+// name, domain and path.  Cookies that can not be parsed are keyed by the
+// whole value, so they are only deduplicated if identical.
+func setCookieKey(setCookie string) string {
+	cookies := (&http.Response{Header: http.Header{"Set-Cookie": {setCookie}}}).Cookies()
+	if len(cookies) == 0 {
+		return setCookie
+	}
+	cookie := cookies[0]
+	return cookie.Name + "\x00" + strings.ToLower(strings.TrimPrefix(cookie.Domain, ".")) + "\x00" + cookie.Path
+}
@@ -0,0 +1,97 @@
+package revel
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSetCookieFilter(t *testing.T) {
+	startFakeBookingApp()
+
+	resp := httptest.NewRecorder()
+	c := NewController(NewRequest(showRequest), NewResponse(resp))
+	SetCookieFilter(c, []Filter{func(c *Controller, fc []Filter) {
+		http.SetCookie(c.Response.Out, &http.Cookie{Name: "REVEL_SESSION", Value: "first", Path: "/"})
+		http.SetCookie(c.Response.Out, &http.Cookie{Name: "REVEL_FLASH", Value: "", Path: "/"})
+		http.SetCookie(c.Response.Out, &http.Cookie{Name: "REVEL_SESSION", Value: "old", Path: "/admin"})
+		http.SetCookie(c.Response.Out, &http.Cookie{Name: "REVEL_SESSION", Value: "second", Path: "/"})
+		c.Result = c.RenderText("ok")
+	}})
+	c.Result.Apply(c.Request, c.Response)
+
+	expected := []string{"REVEL_FLASH=; Path=/", "REVEL_SESSION=old; Path=/admin", "REVEL_SESSION=second; Path=/"}
+	if actual := resp.Header()["Set-Cookie"]; !eq(t, "Set-Cookie count", len(actual), len(expected)) {
+		t.Errorf("Got %q", actual)
+	} else {
+		for i := range expected {
+			eq(t, "Set-Cookie", actual[i], expected[i])
+		}
+	}
+	eq(t, "body", resp.Body.String(), "ok")
+
+	// Without cookies, the result is left as it is.
+	c = NewController(NewRequest(showRequest), NewResponse(httptest.NewRecorder()))
+	SetCookieFilter(c, []Filter{func(c *Controller, fc []Filter) {
+		c.Result = c.RenderText("ok")
+	}})
+	if _, ok := c.Result.(*RenderTextResult); !ok {
+		t.Errorf("Expected the result not to be wrapped, got %T", c.Result)
+	}
+
+	// Without a result, the headers are cleaned up right away.
+	resp = httptest.NewRecorder()
+	c = NewController(NewRequest(showRequest), NewResponse(resp))
+	SetCookieFilter(c, []Filter{func(c *Controller, fc []Filter) {
+		http.SetCookie(c.Response.Out, &http.Cookie{Name: "a", Value: "1"})
+		http.SetCookie(c.Response.Out, &http.Cookie{Name: "a", Value: "2"})
+	}})
+	if actual := resp.Header()["Set-Cookie"]; len(actual) != 1 || actual[0] != "a=2" {
+		t.Errorf("Expected only the last cookie without a result, got %q", actual)
+	}
+}
+
+// readerFromRecorder is a ResponseRecorder with a ReadFrom method, as the
+// server's ResponseWriter has for sendfile.
+type readerFromRecorder struct {
+	*httptest.ResponseRecorder
+	readFrom int
+}
+
+func (r *readerFromRecorder) ReadFrom(src io.Reader) (int64, error) {
+	r.readFrom++
+	return io.Copy(r.ResponseRecorder, src)
+}
+
+func TestWrappedWritersReadFrom(t *testing.T) {
+	for name, wrap := range map[string]func(http.ResponseWriter) http.ResponseWriter{
+		"set-cookie": func(w http.ResponseWriter) http.ResponseWriter {
+			return &setCookieResponseWriter{ResponseWriter: w, req: NewRequest(showRequest)}
+		},
+		"budget": func(w http.ResponseWriter) http.ResponseWriter {
+			return &budgetResponseWriter{ResponseWriter: w, budget: 8, abort: true}
+		},
+		"body log": func(w http.ResponseWriter) http.ResponseWriter {
+			return &capturingResponseWriter{w, &bodyCapture{limit: 8}}
+		},
+	} {
+		rec := &readerFromRecorder{ResponseRecorder: httptest.NewRecorder()}
+		w := wrap(rec)
+		if _, ok := w.(http.Hijacker); !ok {
+			t.Errorf("%s: expected the writer to be a Hijacker", name)
+		}
+		n, err := io.Copy(w, struct{ io.Reader }{strings.NewReader("hello")})
+		if n != 5 || err != nil || rec.Body.String() != "hello" {
+			t.Errorf("%s: unexpected copy: %d %v %q", name, n, err, rec.Body)
+		}
+		eq(t, name+" ReadFrom calls", rec.readFrom, 1)
+	}
+
+	rec := &readerFromRecorder{ResponseRecorder: httptest.NewRecorder()}
+	w := &budgetResponseWriter{ResponseWriter: rec, budget: 8, abort: true}
+	if n, err := w.ReadFrom(strings.NewReader(strings.Repeat("x", 20))); n != 8 || err != ErrResponseBudgetExceeded || !w.exceeded {
+		t.Errorf("Expected the copy to stop at the budget, got %d %v", n, err)
+	}
+}
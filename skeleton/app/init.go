@@ -5,15 +5,33 @@ import "github.com/revel/revel"
 func init() {
 	// Filters is the default set of global filters.
 	revel.Filters = []revel.Filter{
+		revel.VerboseLogFilter,        // Mark a sample of the requests for verbose logging.
+		revel.SlowRequestFilter,       // Log the requests slower than log.slowrequest.threshold.
 		revel.PanicFilter,             // Recover from panics and display an error page instead.
+		revel.AppErrorFilter,          // Render AppErrors returned by the action.
+		revel.HostFilter,              // Reject requests for hosts other than hosts.allowed.
+		revel.SecureFilter,            // Enforce HTTPS and set security headers, if configured.
+		revel.CleanPathFilter,         // Resolve "//", "." and ".." in the request path.
+		revel.MaintenanceFilter,       // Reply 503 while in maintenance mode.
+		revel.OverloadFilter,          // Reply 503 while past the overload.* limits.
+		revel.AssetsFilter,            // Serve hashed asset URLs with long cache headers.
 		revel.RouterFilter,            // Use the routing table to select the right Action
+		revel.TracingFilter,           // Record a span for the request, if a Tracer is set.
 		revel.FilterConfiguringFilter, // A hook for adding or removing per-Action filters.
+		revel.RequiredHeadersFilter,   // Reject requests without the headers.required headers.
+		revel.ContentTypeFilter,       // Reject bodies not of the contenttypes.allowed types.
+		revel.BodyLimitFilter,         // Cap the size of the request body.
+		revel.ConcurrencyLimitFilter,  // Limit how many requests run an action at once.
+		revel.ResponseBudgetFilter,    // Count the response bytes and enforce response.budget.
+		revel.BodyLogFilter,           // Log the request and response bodies, if configured.
 		revel.ParamsFilter,            // Parse parameters into Controller.Params.
+		revel.SetCookieFilter,         // Drop duplicate Set-Cookie headers, warn if too large.
 		revel.SessionFilter,           // Restore and write the session cookie.
 		revel.FlashFilter,             // Restore and write the flash cookie.
 		revel.ValidationFilter,        // Restore kept validation errors and save new ones from cookie.
 		revel.I18nFilter,              // Resolve the requested language
-		HeaderFilter,                  // Add some security based headers
+		revel.DefaultHeadersFilter,    // Add the headers.default headers to the response.
+		revel.ResultHookFilter,        // Transform the result with the registered ResultHooks.
 		revel.InterceptorFilter,       // Run interceptors around the action.
 		revel.CompressFilter,          // Compress the result.
 		revel.ActionInvoker,           // Invoke the action.
@@ -24,15 +42,3 @@ func init() {
 	// revel.OnAppStart(InitDB)
 	// revel.OnAppStart(FillCache)
 }
-
-// TODO turn this into revel.HeaderFilter
-// should probably also have a filter for CSRF
-// not sure if it can go in the same filter or not
-var HeaderFilter = func(c *revel.Controller, fc []revel.Filter) {
-	// Add some common security headers
-	c.Response.Out.Header().Add("X-Frame-Options", "SAMEORIGIN")
-	c.Response.Out.Header().Add("X-XSS-Protection", "1; mode=block")
-	c.Response.Out.Header().Add("X-Content-Type-Options", "nosniff")
-
-	fc[0](c, fc[1:]) // Execute the next filter stage.
-}
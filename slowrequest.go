@@ -0,0 +1,104 @@
+package revel
+
+import (
+	"fmt"
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+// slowRequestThreshold is the "log.slowrequest.threshold" duration, parsed
+// when the app starts and when the config is reloaded.
+var slowRequestThreshold atomic.Int64
+
+func init() {
+	OnAppStart(func() {
+		if err := loadSlowRequestThreshold(); err != nil {
+			panic(err)
+		}
+	})
+	OnConfigReload(func(changed map[string]bool) error {
+		if changed["log.slowrequest.threshold"] {
+			return loadSlowRequestThreshold()
+		}
+		return nil
+	})
+}
+
+// loadSlowRequestThreshold parses the "log.slowrequest.threshold" duration.
+// The threshold is left unchanged if it is invalid.
+func loadSlowRequestThreshold() error {
+	threshold, err := time.ParseDuration(CurrentConfig().StringDefault("log.slowrequest.threshold", "0s"))
+	if err != nil {
+		return fmt.Errorf("log.slowrequest.threshold invalid: %s", err)
+	}
+	slowRequestThreshold.Store(int64(threshold))
+	return nil
+}
+
+// SlowRequestFilter logs a warning for each request that takes longer than
+// "log.slowrequest.threshold" (a duration, e.g. "500ms") to handle, including
+// rendering its result.  The warning gives the action and route, the
+// duration, the request ID (from the X-Request-Id header) and the names of
+// the params, but not their values.  Nothing is logged for faster requests,
+// or if no threshold is configured.
+//
+// It is in the default Filters, right after the VerboseLogFilter, so that
+// the whole chain is timed.
+func SlowRequestFilter(c *Controller, fc []Filter) {
+	threshold := time.Duration(slowRequestThreshold.Load())
+	if threshold <= 0 {
+		fc[0](c, fc[1:])
+		return
+	}
+
+	start := time.Now()
+	fc[0](c, fc[1:])
+	if c.Result == nil {
+		logSlowRequest(c, start, threshold)
+		return
+	}
+	c.Result = slowRequestResult{c.Result, c, start, threshold}
+}
+
+// slowRequestResult times the rendering of the wrapped result.
+type slowRequestResult struct {
+	Result
+	c         *Controller
+	start     time.Time
+	threshold time.Duration
+}
+
+func (r slowRequestResult) Apply(req *Request, resp *Response) {
+	r.Result.Apply(req, resp)
+	logSlowRequest(r.c, r.start, r.threshold)
+}
+
+func (r slowRequestResult) Unwrap() Result {
+	return r.Result
+}
+
+func logSlowRequest(c *Controller, start time.Time, threshold time.Duration) {
+	duration := time.Since(start)
+	if duration < threshold {
+		return
+	}
+
+	route := c.Request.URL.Path
+	if c.Route != nil {
+		route = c.Route.Path
+	}
+	var params []string
+	if c.Params != nil {
+		for name := range c.Params.Values {
+			params = append(params, name)
+		}
+		sort.Strings(params)
+	}
+	requestID := c.Request.Header.Get("X-Request-Id")
+	if requestID == "" {
+		requestID = "-"
+	}
+	WARN.Printf("Slow request: %s %s (%s) took %v, status %d, request id %s, params %v",
+		c.Request.Method, route, c.Action, duration, c.Response.Status, requestID, params)
+}
@@ -0,0 +1,65 @@
+package revel
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSlowRequestFilter(t *testing.T) {
+	startFakeBookingApp()
+	defer func(logger *log.Logger) { WARN = logger }(WARN)
+	var buf bytes.Buffer
+	WARN = log.New(&buf, "", 0)
+
+	run := func(renderTime time.Duration) string {
+		buf.Reset()
+		req, _ := http.NewRequest("GET", "/hotels/3?q=secret", nil)
+		req.Header.Set("X-Request-Id", "req-1")
+		c := NewController(NewRequest(req), NewResponse(httptest.NewRecorder()))
+		c.Action = "Hotels.Show"
+		SlowRequestFilter(c, []Filter{func(c *Controller, fc []Filter) {
+			c.Params = &Params{Values: url.Values{"q": {"secret"}, "id": {"3"}}}
+			c.Result = ResultFunc(func(req *Request, resp *Response) {
+				time.Sleep(renderTime)
+				resp.WriteHeader(http.StatusOK, "text/plain")
+			})
+		}})
+		c.Result.Apply(c.Request, c.Response)
+		return buf.String()
+	}
+
+	if output := run(20 * time.Millisecond); output != "" {
+		t.Errorf("Expected no log without a threshold, got %q", output)
+	}
+
+	Config.SetOption("log.slowrequest.threshold", "10ms")
+	if err := loadSlowRequestThreshold(); err != nil {
+		t.Fatal(err)
+	}
+	defer slowRequestThreshold.Store(0)
+	if output := run(0); output != "" {
+		t.Errorf("Expected no log for a fast request, got %q", output)
+	}
+	output := run(20 * time.Millisecond)
+	for _, expected := range []string{"GET /hotels/3 (Hotels.Show)", "status 200", "request id req-1", "params [id q]"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected the log to contain %q, got %q", expected, output)
+		}
+	}
+	if strings.Contains(output, "secret") {
+		t.Errorf("Expected the param values not to be logged, got %q", output)
+	}
+
+	// An invalid threshold is rejected, and the previous one kept.
+	Config.SetOption("log.slowrequest.threshold", "soon")
+	if err := loadSlowRequestThreshold(); err == nil {
+		t.Error("Expected an invalid threshold to be rejected")
+	}
+	eq(t, "threshold", time.Duration(slowRequestThreshold.Load()), 10*time.Millisecond)
+}
@@ -0,0 +1,72 @@
+package revel
+
+import (
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// StrictParamsFilter rejects requests with 400 Bad Request when a param is
+// given more than once, whether within the query or form or across the query,
+// form and route (e.g. ?role=user&role=admin).  This guards actions that read
+// a single value against HTTP parameter pollution.
+//
+// Params may legitimately have several values when they are bound to a slice
+// or array action argument, when their name ends with "[]", or when they are
+// listed in "params.strict.allow" (comma separated).
+//
+// It must run after the ParamsFilter.  It may be enabled for every request by
+// adding it to revel.Filters, or per controller or action:
+//
+//	revel.FilterAction(App.Transfer).Add(revel.StrictParamsFilter)
+func StrictParamsFilter(c *Controller, fc []Filter) {
+	if name := duplicateParam(c); name != "" {
+		c.Response.Status = http.StatusBadRequest
+		c.Result = c.RenderError(&Error{
+			Title:       "Bad Request",
+			Description: "The parameter " + name + " was given more than once",
+		})
+		return
+	}
+	fc[0](c, fc[1:])
+}
+
+// duplicateParam returns the first (by name) param with more than one value
+// that is not allowed to have several, or "" if there is none.
+func duplicateParam(c *Controller) string {
+	if c.Params == nil {
+		return ""
+	}
+	var names []string
+	for name, values := range c.Params.Values {
+		if len(values) > 1 && !multiValueParam(c, name) {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return ""
+	}
+	sort.Strings(names)
+	return names[0]
+}
+
+// multiValueParam returns true if the param may have several values.
+func multiValueParam(c *Controller, name string) bool {
+	if strings.HasSuffix(name, "[]") {
+		return true
+	}
+	for _, allowed := range strings.Split(CurrentConfig().StringDefault("params.strict.allow", ""), ",") {
+		if strings.TrimSpace(allowed) == name {
+			return true
+		}
+	}
+	if c.MethodType != nil {
+		for _, arg := range c.MethodType.Args {
+			if arg.Name == name && (arg.Type.Kind() == reflect.Slice || arg.Type.Kind() == reflect.Array) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,51 @@
+package revel
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestStrictParamsFilter(t *testing.T) {
+	startFakeBookingApp()
+
+	run := func(values url.Values) (*httptest.ResponseRecorder, bool) {
+		req, _ := http.NewRequest("GET", "/hotels", nil)
+		resp := httptest.NewRecorder()
+		c := NewController(NewRequest(req), NewResponse(resp))
+		c.MethodType = &MethodType{Args: []*MethodArg{
+			{Name: "role", Type: reflect.TypeOf("")},
+			{Name: "ids", Type: reflect.TypeOf([]int{})},
+		}}
+		c.Params = &Params{Values: values}
+		called := false
+		StrictParamsFilter(c, []Filter{func(c *Controller, fc []Filter) { called = true }})
+		if c.Result != nil {
+			c.Result.Apply(c.Request, c.Response)
+		}
+		return resp, called
+	}
+
+	if _, called := run(url.Values{"role": {"user"}, "ids": {"1", "2"}, "tags[]": {"a", "b"}}); !called {
+		t.Error("Expected single and slice params to be accepted")
+	}
+
+	resp, called := run(url.Values{"role": {"user", "admin"}})
+	if called || resp.Code != http.StatusBadRequest {
+		t.Errorf("Expected a duplicate param to be rejected, got %d (called: %v)", resp.Code, called)
+	}
+
+	// Duplicates across sources are merged into Values.
+	params := &Params{Query: url.Values{"role": {"user"}}, Form: url.Values{"role": {"admin"}}}
+	resp, called = run(params.calcValues())
+	if called || resp.Code != http.StatusBadRequest {
+		t.Errorf("Expected a param given in the query and form to be rejected, got %d (called: %v)", resp.Code, called)
+	}
+
+	Config.SetOption("params.strict.allow", "sort, role")
+	if _, called := run(url.Values{"role": {"user", "admin"}}); !called {
+		t.Error("Expected an allowed param to be accepted")
+	}
+}
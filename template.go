@@ -45,7 +45,8 @@ var whiteSpacePattern = regexp.MustCompile(`\s+`)
 var (
 	// The functions available for use in the templates.
 	TemplateFuncs = map[string]interface{}{
-		"url": ReverseUrl,
+		"url":    ReverseUrl,
+		"absurl": ReverseAbsoluteUrl,
 		"set": func(renderArgs map[string]interface{}, key string, value interface{}) template.JS {
 			renderArgs[key] = value
 			return template.JS("")
@@ -121,6 +122,12 @@ var (
 			return template.HTML(ERROR_CLASS)
 		},
 
+		// Returns the validation error message of the given field, if any.
+		"fieldError": func(name string, renderArgs map[string]interface{}) string {
+			errorMap, _ := renderArgs["errors"].(map[string]*ValidationError)
+			return errorMap[name].String()
+		},
+
 		"msg": func(renderArgs map[string]interface{}, message string, args ...interface{}) template.HTML {
 			str, ok := renderArgs[CurrentLocaleRenderArg].(string)
 			if !ok {
@@ -427,6 +434,27 @@ func (gotmpl GoTemplate) Content() []string {
 	return content
 }
 
+// Part returns the named {{define}} (or {{block}}) template of the template
+// set, to be rendered on its own.  It keeps the name and content of this
+// template, for error pages.
+func (gotmpl GoTemplate) Part(name string) (Template, error) {
+	part := gotmpl.Lookup(name)
+	if part == nil {
+		return nil, fmt.Errorf("Template %s has no block %s.", gotmpl.Name(), name)
+	}
+	return goTemplatePart{gotmpl, part}, nil
+}
+
+// goTemplatePart is a block of a GoTemplate.
+type goTemplatePart struct {
+	GoTemplate
+	part *template.Template
+}
+
+func (p goTemplatePart) Render(wr io.Writer, arg interface{}) error {
+	return p.part.Execute(wr, arg)
+}
+
 /////////////////////
 // Template functions
 /////////////////////
@@ -0,0 +1,157 @@
+package revel
+
+import (
+	"context"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Tracer starts the spans recorded by the TracingFilter.  It is the
+// integration point for a tracing library such as OpenTelemetry: an adapter
+// starts a span as a child of the span found in ctx or, for the request span,
+// of the remote parent returned by RemoteTraceParent(ctx).
+type Tracer interface {
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span is a unit of traced work.
+type Span interface {
+	SetAttribute(key string, value interface{})
+
+	// TraceParent returns the W3C traceparent header value identifying this
+	// span, used to propagate the trace to downstream services.
+	TraceParent() string
+
+	End()
+}
+
+// DefaultTracer is used by the TracingFilter.  Tracing is disabled while it
+// is nil.
+var DefaultTracer Tracer
+
+type traceContextKey int
+
+const (
+	spanKey traceContextKey = iota
+	remoteTraceParentKey
+)
+
+// TraceParent is a W3C Trace Context, as carried by the traceparent and
+// tracestate headers.
+type TraceParent struct {
+	TraceID  string // 32 lowercase hex digits
+	ParentID string // 16 lowercase hex digits
+	Flags    byte   // e.g. 0x01 (sampled)
+	State    string // The tracestate header, passed on as-is.
+}
+
+// ParseTraceParent parses a traceparent header value, e.g.
+// "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01".
+func ParseTraceParent(header string) (TraceParent, bool) {
+	parts := strings.Split(strings.TrimSpace(header), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || (parts[0] == "00" && len(parts) != 4) {
+		return TraceParent{}, false
+	}
+	if !isTraceHex(parts[1], 32) || !isTraceHex(parts[2], 16) || !isTraceHex(parts[3], 2) {
+		return TraceParent{}, false
+	}
+	flags, _ := hex.DecodeString(parts[3])
+	return TraceParent{TraceID: parts[1], ParentID: parts[2], Flags: flags[0]}, true
+}
+
+// isTraceHex returns true if s is a non-zero lowercase hex string of the
+// given length.
+func isTraceHex(s string, length int) bool {
+	if len(s) != length {
+		return false
+	}
+	zero := true
+	for _, r := range s {
+		if (r < '0' || r > '9') && (r < 'a' || r > 'f') {
+			return false
+		}
+		zero = zero && r == '0'
+	}
+	return !zero || length == 2
+}
+
+func (tp TraceParent) String() string {
+	return "00-" + tp.TraceID + "-" + tp.ParentID + "-" + hex.EncodeToString([]byte{tp.Flags})
+}
+
+// RemoteTraceParent returns the trace context the request was sent with, if
+// any.
+func RemoteTraceParent(ctx context.Context) (TraceParent, bool) {
+	tp, ok := ctx.Value(remoteTraceParentKey).(TraceParent)
+	return tp, ok
+}
+
+// SpanFromContext returns the span of the request, or nil if the request is
+// not traced.  Actions get it with SpanFromContext(c.Request.Context()).
+func SpanFromContext(ctx context.Context) Span {
+	span, _ := ctx.Value(spanKey).(Span)
+	return span
+}
+
+// InjectTraceParent sets the traceparent header of an outgoing request, so
+// that the downstream service continues the trace of the span in ctx.
+func InjectTraceParent(ctx context.Context, header http.Header) {
+	if span := SpanFromContext(ctx); span != nil {
+		header.Set("traceparent", span.TraceParent())
+		if tp, ok := RemoteTraceParent(ctx); ok && tp.State != "" {
+			header.Set("tracestate", tp.State)
+		}
+	}
+}
+
+// TracingFilter records a span for each request, named by its method and
+// matched route (e.g. "GET /hotels/:id").  The trace is continued from the
+// traceparent header, if valid.  The span is stored in the request context,
+// where SpanFromContext finds it.
+//
+// It does nothing unless DefaultTracer is set, and must run after the
+// RouterFilter.
+func TracingFilter(c *Controller, fc []Filter) {
+	if DefaultTracer == nil {
+		fc[0](c, fc[1:])
+		return
+	}
+
+	ctx := c.Request.Context()
+	if tp, ok := ParseTraceParent(c.Request.Header.Get("traceparent")); ok {
+		tp.State = c.Request.Header.Get("tracestate")
+		ctx = context.WithValue(ctx, remoteTraceParentKey, tp)
+	}
+
+	route := c.Request.URL.Path
+	if c.Route != nil {
+		route = c.Route.Path
+	}
+	ctx, span := DefaultTracer.Start(ctx, c.Request.Method+" "+route)
+	ctx = context.WithValue(ctx, spanKey, span)
+	c.Request.Request = c.Request.WithContext(ctx)
+
+	start := time.Now()
+	defer func() {
+		status := c.Response.Status
+		err := recover()
+		if err != nil {
+			status = http.StatusInternalServerError
+		} else if status == 0 {
+			status = http.StatusOK
+		}
+		span.SetAttribute("http.method", c.Request.Method)
+		span.SetAttribute("http.route", route)
+		span.SetAttribute("http.status_code", status)
+		span.SetAttribute("revel.action", c.Action)
+		span.SetAttribute("revel.duration_ms", float64(time.Since(start))/float64(time.Millisecond))
+		span.End()
+		if err != nil {
+			panic(err)
+		}
+	}()
+
+	fc[0](c, fc[1:])
+}
@@ -0,0 +1,96 @@
+package revel
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type testSpan struct {
+	name       string
+	parent     TraceParent
+	attributes map[string]interface{}
+	ended      bool
+}
+
+func (s *testSpan) SetAttribute(key string, value interface{}) { s.attributes[key] = value }
+func (s *testSpan) TraceParent() string {
+	return TraceParent{TraceID: s.parent.TraceID, ParentID: "00f067aa0ba902b8", Flags: 1}.String()
+}
+func (s *testSpan) End() { s.ended = true }
+
+type testTracer struct{ spans []*testSpan }
+
+func (t *testTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	span := &testSpan{name: name, attributes: make(map[string]interface{})}
+	span.parent, _ = RemoteTraceParent(ctx)
+	t.spans = append(t.spans, span)
+	return ctx, span
+}
+
+func TestParseTraceParent(t *testing.T) {
+	tp, ok := ParseTraceParent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	eq(t, "valid", ok, true)
+	eq(t, "trace id", tp.TraceID, "4bf92f3577b34da6a3ce929d0e0e4736")
+	eq(t, "parent id", tp.ParentID, "00f067aa0ba902b7")
+	eq(t, "flags", tp.Flags, byte(1))
+	eq(t, "string", tp.String(), "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	for _, header := range []string{
+		"",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+	} {
+		if _, ok := ParseTraceParent(header); ok {
+			t.Errorf("Expected %q to be invalid", header)
+		}
+	}
+}
+
+func TestTracingFilter(t *testing.T) {
+	startFakeBookingApp()
+
+	// Without a tracer the filter does nothing.
+	c := NewController(NewRequest(showRequest), NewResponse(httptest.NewRecorder()))
+	TracingFilter(c, []Filter{func(c *Controller, fc []Filter) {
+		if SpanFromContext(c.Request.Context()) != nil {
+			t.Error("Expected no span without a tracer")
+		}
+	}})
+
+	tracer := &testTracer{}
+	DefaultTracer = tracer
+	defer func() { DefaultTracer = nil }()
+
+	req, _ := http.NewRequest("GET", "/hotels/3", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	req.Header.Set("tracestate", "vendor=1")
+	c = NewController(NewRequest(req), NewResponse(httptest.NewRecorder()))
+	c.Action = "Hotels.Show"
+	c.Route = &RouteMatch{Path: "/hotels/:id"}
+
+	outgoing := make(http.Header)
+	TracingFilter(c, []Filter{func(c *Controller, fc []Filter) {
+		InjectTraceParent(c.Request.Context(), outgoing)
+		c.Response.Status = http.StatusNotFound
+	}})
+
+	if len(tracer.spans) != 1 {
+		t.Fatalf("Expected one span, got %d", len(tracer.spans))
+	}
+	span := tracer.spans[0]
+	eq(t, "name", span.name, "GET /hotels/:id")
+	eq(t, "parent", span.parent.ParentID, "00f067aa0ba902b7")
+	eq(t, "ended", span.ended, true)
+	eq(t, "status", span.attributes["http.status_code"], http.StatusNotFound)
+	eq(t, "action", span.attributes["revel.action"], "Hotels.Show")
+	if _, ok := span.attributes["revel.duration_ms"].(float64); !ok {
+		t.Error("Expected the duration to be recorded")
+	}
+	eq(t, "traceparent", outgoing.Get("traceparent"), "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b8-01")
+	eq(t, "tracestate", outgoing.Get("tracestate"), "vendor=1")
+}
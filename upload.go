@@ -0,0 +1,99 @@
+package revel
+
+import (
+	"fmt"
+	"io"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// maxUniqueFileNames is how many numbered names (e.g. photo-1.jpg) are tried
+// before giving up on saving an upload.
+const maxUniqueFileNames = 1000
+
+// SaveAllFiles saves every file uploaded under the given name (e.g. from an
+// <input type="file" name="photos" multiple>, also accepted as "photos[]")
+// into dir, and returns the paths of the saved files.
+//
+// Each file is saved under its sanitized name (see SanitizeFileName).  Names
+// are never overwritten: a number is appended when the name is taken, e.g.
+// photo-1.jpg.  A file that fails to save does not stop the others; an error
+// is returned for each of them.
+func (p *Params) SaveAllFiles(name, dir string) (saved []string, errs []error) {
+	var headers []*multipart.FileHeader
+	headers = append(headers, p.Files[name]...)
+	headers = append(headers, p.Files[name+"[]"]...)
+	for _, header := range headers {
+		path, err := saveUploadedFile(header, dir)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("revel/params: failed to save %q: %s", header.Filename, err))
+			continue
+		}
+		saved = append(saved, path)
+	}
+	return saved, errs
+}
+
+func saveUploadedFile(header *multipart.FileHeader, dir string) (string, error) {
+	src, err := header.Open()
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+
+	dst, err := createUniqueFile(dir, SanitizeFileName(header.Filename))
+	if err != nil {
+		return "", err
+	}
+	if _, err = io.Copy(dst, src); err == nil {
+		err = dst.Close()
+	} else {
+		dst.Close()
+	}
+	if err != nil {
+		os.Remove(dst.Name())
+		return "", err
+	}
+	return dst.Name(), nil
+}
+
+// createUniqueFile creates a new file of the given name in dir, or of the
+// first free numbered name (name-1.ext, name-2.ext, ...).
+func createUniqueFile(dir, name string) (*os.File, error) {
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	for i := 0; i < maxUniqueFileNames; i++ {
+		candidate := name
+		if i > 0 {
+			candidate = fmt.Sprintf("%s-%d%s", base, i, ext)
+		}
+		file, err := os.OpenFile(filepath.Join(dir, candidate), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if !os.IsExist(err) {
+			return file, err
+		}
+	}
+	return nil, fmt.Errorf("no free file name for %s in %s", name, dir)
+}
+
+// SanitizeFileName returns a client supplied file name made safe to save:
+// any directory is dropped, characters other than letters, digits, ".", "-"
+// and "_" are replaced with "_", and leading dots are removed (so that the
+// file is neither hidden nor "..").  Names left empty become "upload".
+func SanitizeFileName(name string) string {
+	// Browsers on Windows may send the full path.
+	name = filepath.Base(strings.Replace(name, "\\", "/", -1))
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '.' || r == '-' || r == '_' {
+			return r
+		}
+		return '_'
+	}, name)
+	name = strings.TrimLeft(name, ".")
+	if name == "" {
+		return "upload"
+	}
+	return name
+}
@@ -0,0 +1,65 @@
+package revel
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSaveAllFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "revel-uploads")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	c := Controller{
+		Request: NewRequest(getMultipartRequest()),
+		Params:  &Params{},
+	}
+	ParamsFilter(&c, NilChain)
+
+	// file1 and file2[] both have a test.txt, which must not be overwritten.
+	saved, errs := c.Params.SaveAllFiles("file1", dir)
+	if len(saved) != 1 || len(errs) != 0 {
+		t.Fatalf("Unexpected result saving file1: %v %v", saved, errs)
+	}
+	saved, errs = c.Params.SaveAllFiles("file2", dir)
+	if len(errs) != 0 {
+		t.Fatalf("Unexpected errors saving file2[]: %v", errs)
+	}
+	expected := []string{filepath.Join(dir, "test-1.txt"), filepath.Join(dir, "favicon.ico")}
+	if strings.Join(saved, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected the files to be saved as %v, got %v", expected, saved)
+	}
+	for path, content := range map[string]string{"test.txt": "content1", "test-1.txt": "content2", "favicon.ico": "xyz"} {
+		if actual, err := ioutil.ReadFile(filepath.Join(dir, path)); err != nil || string(actual) != content {
+			t.Errorf("Expected %s to hold %q, got %q (%v)", path, content, actual, err)
+		}
+	}
+
+	// A failing file is reported without stopping the others.
+	saved, errs = c.Params.SaveAllFiles("file2", filepath.Join(dir, "missing"))
+	if len(saved) != 0 || len(errs) != 2 {
+		t.Errorf("Expected an error per file, got %v %v", saved, errs)
+	}
+}
+
+func TestSanitizeFileName(t *testing.T) {
+	for name, expected := range map[string]string{
+		"photo.jpg":                    "photo.jpg",
+		"C:\\Users\\rob\\my photo.jpg": "my_photo.jpg",
+		"../../etc/passwd":             "passwd",
+		".htaccess":                    "htaccess",
+		"..":                           "upload",
+		"":                             "upload",
+		"résumé (1).pdf":               "résumé__1_.pdf",
+		"a\x00b;rm -rf.sh":             "a_b_rm_-rf.sh",
+	} {
+		if actual := SanitizeFileName(name); actual != expected {
+			t.Errorf("Expected %q to be sanitized to %q, got %q", name, expected, actual)
+		}
+	}
+}
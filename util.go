@@ -27,6 +27,9 @@ var (
 	hdrRealIP            = http.CanonicalHeaderKey("X-Real-Ip")
 
 	mimeConfig *config.Context
+
+	// Networks whose forwarding headers are trusted by ClientIP.
+	trustedProxies []*net.IPNet
 )
 
 // Add some more methods to the default Template.
@@ -184,9 +187,23 @@ func Equal(a, b interface{}) bool {
 // you may get inaccurate Client IP address. Revel parses the
 // IP address in the order of X-Forwarded-For, X-Real-IP.
 //
+// If "server.trustedproxies" is set (a comma separated list of CIDRs or IPs),
+// the headers are only believed when the request comes from a trusted proxy.
+// X-Forwarded-For is then walked from right to left, skipping trusted
+// proxies, and the first untrusted address is returned as the client IP.
+//
 // By default revel will get http.Request's RemoteAddr
 func ClientIP(r *http.Request) string {
-	if Config.BoolDefault("app.behind.proxy", false) {
+	remoteAddr := ""
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		remoteAddr = host
+	}
+
+	if len(trustedProxies) > 0 {
+		return trustedClientIP(r, remoteAddr)
+	}
+
+	if CurrentConfig().BoolDefault("app.behind.proxy", false) {
 		// Header X-Forwarded-For
 		if fwdFor := strings.TrimSpace(r.Header.Get(hdrForwardedFor)); fwdFor != "" {
 			index := strings.Index(fwdFor, ",")
@@ -202,11 +219,74 @@ func ClientIP(r *http.Request) string {
 		}
 	}
 
-	if remoteAddr, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+	return remoteAddr
+}
+
+// trustedClientIP resolves the client IP, only trusting the forwarding
+// headers as far as they were added by trusted proxies.
+func trustedClientIP(r *http.Request, remoteAddr string) string {
+	if !isTrustedProxy(remoteAddr) {
 		return remoteAddr
 	}
 
-	return ""
+	// Header X-Forwarded-For, which may be repeated.
+	var hops []string
+	for _, fwdFor := range r.Header[hdrForwardedFor] {
+		for _, hop := range strings.Split(fwdFor, ",") {
+			if hop = strings.TrimSpace(hop); hop != "" {
+				hops = append(hops, hop)
+			}
+		}
+	}
+	for i := len(hops) - 1; i >= 0; i-- {
+		if !isTrustedProxy(hops[i]) || i == 0 {
+			return hops[i]
+		}
+	}
+
+	// Header X-Real-Ip
+	if realIP := strings.TrimSpace(r.Header.Get(hdrRealIP)); realIP != "" {
+		return realIP
+	}
+
+	return remoteAddr
+}
+
+// isTrustedProxy returns true if the given address is within one of the
+// networks configured in "server.trustedproxies".
+func isTrustedProxy(addr string) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+	for _, network := range trustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// loadTrustedProxies parses the "server.trustedproxies" configuration.
+func loadTrustedProxies() {
+	trustedProxies = nil
+	for _, entry := range strings.Split(CurrentConfig().StringDefault("server.trustedproxies", ""), ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			if ip := net.ParseIP(entry); ip != nil && ip.To4() != nil {
+				entry += "/32"
+			} else {
+				entry += "/128"
+			}
+		}
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			ERROR.Fatalln("Invalid entry in server.trustedproxies:", entry, err)
+		}
+		trustedProxies = append(trustedProxies, network)
+	}
 }
 
 // Walk method extends filepath.Walk to also follow symlinks.
@@ -269,4 +349,5 @@ func fsWalk(fname string, linkName string, walkFn filepath.WalkFunc) error {
 
 func init() {
 	OnAppStart(LoadMimeConfig)
+	OnAppStart(loadTrustedProxies)
 }
@@ -1,6 +1,7 @@
 package revel
 
 import (
+	"net/http"
 	"path"
 	"path/filepath"
 	"reflect"
@@ -90,3 +91,34 @@ func TestEqual(t *testing.T) {
 	testRow("strings2", "strings", false)
 	testRow("strings", "strings2", false)
 }
+
+func TestClientIPTrustedProxies(t *testing.T) {
+	startFakeBookingApp()
+	Config.SetOption("server.trustedproxies", "10.0.0.0/8, 192.168.1.1")
+	loadTrustedProxies()
+	defer func() { trustedProxies = nil }()
+
+	testCases := []struct {
+		remoteAddr, forwardedFor, expected string
+	}{
+		// Untrusted peers can not spoof their address.
+		{"1.2.3.4:1000", "5.6.7.8", "1.2.3.4"},
+		// The last untrusted hop is the client.
+		{"10.0.0.1:1000", "5.6.7.8, 1.2.3.4, 10.1.1.1", "1.2.3.4"},
+		{"192.168.1.1:1000", "1.2.3.4", "1.2.3.4"},
+		// Only trusted hops: the first is the client.
+		{"10.0.0.1:1000", "10.2.2.2, 10.1.1.1", "10.2.2.2"},
+		{"10.0.0.1:1000", "", "10.0.0.1"},
+	}
+	for _, tc := range testCases {
+		req, _ := http.NewRequest("GET", "/", nil)
+		req.RemoteAddr = tc.remoteAddr
+		if tc.forwardedFor != "" {
+			req.Header.Set("X-Forwarded-For", tc.forwardedFor)
+		}
+		if actual := ClientIP(req); actual != tc.expected {
+			t.Errorf("ClientIP(%s, %q): (expected) %s != %s (actual)",
+				tc.remoteAddr, tc.forwardedFor, tc.expected, actual)
+		}
+	}
+}
@@ -3,6 +3,10 @@ package revel
 import (
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strings"
 	"testing"
 )
 
@@ -74,3 +78,123 @@ func TestValidationNoKeepCookiePreviouslySet(t *testing.T) {
 		t.Fatalf("cookie should be deleted")
 	}
 }
+
+func TestValidationFailed(t *testing.T) {
+	var c *Controller
+	recorder := validationTester(buildEmptyRequest(), func(vc *Controller) {
+		c = vc
+		c.Flash = Flash{Data: map[string]string{}, Out: map[string]string{}}
+		c.Params = &Params{Values: url.Values{"user.Name": {""}, "user.Email": {"rob@example.com"}}}
+		c.Validation.Required("").Key("user.Name")
+		c.Result = c.ValidationFailed("/users/new")
+	})
+	c.Result.Apply(c.Request, c.Response)
+
+	eq(t, "status", recorder.Code, http.StatusSeeOther)
+	eq(t, "Location", recorder.Header().Get("Location"), "/users/new")
+	eq(t, "flashed param", c.Flash.Out["user.Email"], "rob@example.com")
+	if _, err := getRecordedCookie(recorder, "REVEL_ERRORS"); err != nil {
+		t.Errorf("Expected the validation errors to be kept: %s", err)
+	}
+
+	fieldError := TemplateFuncs["fieldError"].(func(string, map[string]interface{}) string)
+	renderArgs := map[string]interface{}{"errors": c.Validation.ErrorMap()}
+	eq(t, "fieldError", fieldError("user.Name", renderArgs), Required{}.DefaultMessage())
+	eq(t, "fieldError without an error", fieldError("user.Email", renderArgs), "")
+	eq(t, "fieldError without errors", fieldError("user.Name", map[string]interface{}{}), "")
+}
+
+func TestBindValid(t *testing.T) {
+	startFakeBookingApp()
+
+	type address struct {
+		City string `valid:"required"`
+		Zip  string `valid:"match=^[0-9]{4,5}$"`
+	}
+	type signup struct {
+		Email    string `param:"email" valid:"required,email"`
+		Age      int    `valid:"range=18:120"`
+		Nickname string `valid:"minsize=3,match=^[a-z,]+$"`
+		Stars    int
+		Address  address
+	}
+
+	bind := func(values url.Values) (*Controller, signup, bool) {
+		resp := httptest.NewRecorder()
+		c := NewController(NewRequest(showRequest), NewResponse(resp))
+		c.Params = &Params{Values: values}
+		var form signup
+		ok := c.BindValid(&form)
+		return c, form, ok
+	}
+
+	c, form, ok := bind(url.Values{"email": {"rob@example.com"}, "Age": {"30"}, "Nickname": {"rob,ert"}, "Address.City": {"Paris"}})
+	if !ok || c.Validation.HasErrors() || form.Email != "rob@example.com" || form.Age != 30 || form.Address.City != "Paris" {
+		t.Errorf("Expected a valid form, got %+v (%v)", form, c.Validation.Errors)
+	}
+
+	c, _, ok = bind(url.Values{"email": {"rob"}, "Age": {"12"}, "Nickname": {"Ro"}, "Stars": {"many"}, "Address.Zip": {"12"}})
+	errors := make(map[string]string)
+	for _, err := range c.Validation.Errors {
+		errors[err.Key] = err.Message
+	}
+	expected := map[string]string{
+		"email":        ValidEmail().DefaultMessage(),
+		"Age":          Range{Min{18}, Max{120}}.DefaultMessage(),
+		"Nickname":     MinSize{3}.DefaultMessage(),
+		"Stars":        "Invalid value",
+		"Address.City": "Required",
+		"Address.Zip":  Match{regexp.MustCompile("^[0-9]{4,5}$")}.DefaultMessage(),
+	}
+	if ok || !reflect.DeepEqual(errors, expected) {
+		t.Errorf("Unexpected validation errors: %v", errors)
+	}
+
+	resp := httptest.NewRecorder()
+	c.Response = NewResponse(resp)
+	c.Validation.Errors = []*ValidationError{{Key: "email", Message: "Required"}}
+	c.RenderValidationErrors(422).Apply(c.Request, c.Response)
+	if resp.Code != 422 || resp.Body.String() != `{"errors":[{"key":"email","message":"Required"}]}` {
+		t.Errorf("Unexpected validation errors response: %d %s", resp.Code, resp.Body.String())
+	}
+}
+
+func TestValidationRules(t *testing.T) {
+	startFakeBookingApp()
+
+	type nested struct {
+		Code string `valid:"length=x"`
+	}
+	type invalid struct {
+		Name   string `valid:"required,shiny"`
+		Nested nested
+	}
+
+	// The rules are parsed once per type, regexes included.
+	type valid struct {
+		Zip string `valid:"match=^[0-9]+$"`
+	}
+	rules := validationRulesOf(reflect.TypeOf(valid{}))
+	if rules[0].err != nil || validationRulesOf(reflect.TypeOf(valid{}))[0] != rules[0] {
+		t.Errorf("Expected the rules to be cached, got %+v", rules[0])
+	}
+	if err := checkValidationRules(reflect.TypeOf(&valid{})); err != nil {
+		t.Error(err)
+	}
+
+	// Invalid rules are reported by type, including those of nested structs.
+	if err := checkValidationRules(reflect.TypeOf(invalid{})); err == nil || !strings.Contains(err.Error(), "Name") {
+		t.Errorf("Expected the unknown rule to be reported, got %v", err)
+	}
+	if err := checkValidationRules(reflect.TypeOf(struct{ Nested nested }{})); err == nil || !strings.Contains(err.Error(), "Code") {
+		t.Errorf("Expected the invalid nested rule to be reported, got %v", err)
+	}
+
+	// At request time, the fields with invalid rules fail instead of panicking.
+	c := NewController(NewRequest(showRequest), NewResponse(httptest.NewRecorder()))
+	c.Params = &Params{Values: url.Values{"Name": {"rob"}, "Nested.Code": {"abc"}}}
+	var form invalid
+	if c.BindValid(&form) || len(c.Validation.Errors) != 2 || c.Validation.Errors[0].Key != "Name" || c.Validation.Errors[1].Key != "Nested.Code" {
+		t.Errorf("Expected the fields with invalid rules to fail, got %v", c.Validation.Errors)
+	}
+}
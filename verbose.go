@@ -0,0 +1,93 @@
+package revel
+
+import (
+	"context"
+	"hash/fnv"
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+type verboseKey struct{}
+
+// VerboseLogFilter marks a sample of the requests as verbose, for the filters
+// that log more about them (see IsVerbose): the access log adds the action
+// and request ID of verbose requests, and the BodyLogFilter logs their
+// bodies.  It is configured with:
+//
+//	log.verbose.rate      - the fraction of requests sampled, e.g. 0.01 (default 0)
+//	log.verbose.header    - a request header that marks the request verbose when
+//	                        set to "1" or "true", e.g. X-Debug (default none)
+//	log.verbose.traceflag - whether requests with a sampled traceparent are
+//	                        verbose (default false)
+//
+// The sample is taken by hashing the trace ID of the traceparent header, or
+// else the X-Request-Id header, so that all the services handling a request
+// make the same decision.  Requests with neither are sampled at random.
+//
+// It should run before the filters that check it.
+func VerboseLogFilter(c *Controller, fc []Filter) {
+	if verboseSampled(c.Request) {
+		c.Request.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), verboseKey{}, true))
+	}
+	fc[0](c, fc[1:])
+}
+
+// IsVerbose returns true if the request of the given context was marked
+// verbose by the VerboseLogFilter.
+func IsVerbose(ctx context.Context) bool {
+	verbose, _ := ctx.Value(verboseKey{}).(bool)
+	return verbose
+}
+
+// Verbose returns true if the request was marked verbose by the
+// VerboseLogFilter.
+func (c *Controller) Verbose() bool {
+	return IsVerbose(c.Request.Context())
+}
+
+// verboseSampled returns true if the request is to be logged verbosely.
+func verboseSampled(req *Request) bool {
+	if header := CurrentConfig().StringDefault("log.verbose.header", ""); header != "" {
+		if value := strings.TrimSpace(req.Header.Get(header)); value == "1" || strings.EqualFold(value, "true") {
+			return true
+		}
+	}
+
+	traceParent, traced := ParseTraceParent(req.Header.Get("traceparent"))
+	if traced && traceParent.Flags&0x01 != 0 && CurrentConfig().BoolDefault("log.verbose.traceflag", false) {
+		return true
+	}
+
+	rate, err := strconv.ParseFloat(CurrentConfig().StringDefault("log.verbose.rate", "0"), 64)
+	if err != nil {
+		WARN.Println("log.verbose.rate invalid:", err)
+		return false
+	}
+	if rate <= 0 {
+		return false
+	}
+
+	id := req.Header.Get("X-Request-Id")
+	if traced {
+		id = traceParent.TraceID
+	}
+	if id == "" {
+		return rand.Float64() < rate
+	}
+	hash := fnv.New64a()
+	hash.Write([]byte(id))
+	// The top 53 bits as a fraction in [0, 1).
+	return float64(mixHash(hash.Sum64())>>11)/(1<<53) < rate
+}
+
+// mixHash spreads the bits of an FNV hash over its top bits, which vary
+// little between IDs that only differ at the end (the MurmurHash3 finalizer).
+func mixHash(h uint64) uint64 {
+	h ^= h >> 33
+	h *= 0xff51afd7ed558ccd
+	h ^= h >> 33
+	h *= 0xc4ceb9fe1a85ec53
+	h ^= h >> 33
+	return h
+}
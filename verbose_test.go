@@ -0,0 +1,70 @@
+package revel
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestVerboseLogFilter(t *testing.T) {
+	startFakeBookingApp()
+	defer func() {
+		Config.SetOption("log.verbose.rate", "0")
+		Config.SetOption("log.verbose.header", "")
+		Config.SetOption("log.verbose.traceflag", "false")
+	}()
+
+	verbose := func(headers map[string]string) bool {
+		req, _ := http.NewRequest("GET", "/hotels", nil)
+		for name, value := range headers {
+			req.Header.Set(name, value)
+		}
+		c := NewController(NewRequest(req), NewResponse(httptest.NewRecorder()))
+		var marked bool
+		VerboseLogFilter(c, []Filter{func(c *Controller, fc []Filter) {
+			marked = c.Verbose()
+		}})
+		return marked
+	}
+
+	debug := map[string]string{"X-Debug": "1"}
+	traced := map[string]string{"traceparent": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"}
+	if verbose(nil) || verbose(debug) || verbose(traced) {
+		t.Error("Expected no verbose requests by default")
+	}
+
+	Config.SetOption("log.verbose.header", "X-Debug")
+	Config.SetOption("log.verbose.traceflag", "true")
+	if !verbose(debug) || !verbose(map[string]string{"X-Debug": "true"}) {
+		t.Error("Expected the debug header to mark the request verbose")
+	}
+	if verbose(map[string]string{"X-Debug": "0"}) {
+		t.Error("Expected X-Debug: 0 not to mark the request verbose")
+	}
+	if !verbose(traced) {
+		t.Error("Expected a sampled traceparent to mark the request verbose")
+	}
+
+	// The same IDs are always sampled the same way, about 1 in 4 of them.
+	Config.SetOption("log.verbose.rate", "0.25")
+	sampled := 0
+	for i := 0; i < 1000; i++ {
+		id := map[string]string{"X-Request-Id": fmt.Sprint("request-", i)}
+		first := verbose(id)
+		if verbose(id) != first {
+			t.Fatalf("Expected the same decision for request %d", i)
+		}
+		if first {
+			sampled++
+		}
+	}
+	if sampled < 150 || sampled > 350 {
+		t.Errorf("Expected about 250 of 1000 requests sampled, got %d", sampled)
+	}
+
+	Config.SetOption("log.verbose.rate", "1")
+	if !verbose(nil) {
+		t.Error("Expected every request to be verbose at rate 1")
+	}
+}
@@ -8,5 +8,5 @@ const (
 	BuildDate = "2016-06-06"
 
 	// Minimum required Go version
-	MinimumGoVersion = ">= go1.4"
+	MinimumGoVersion = ">= go1.20"
 )
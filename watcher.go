@@ -184,9 +184,9 @@ func (w *Watcher) Notify() *Error {
 // when a source file is changed.
 // This feature is available only in dev mode.
 func (w *Watcher) eagerRebuildEnabled() bool {
-	return Config.BoolDefault("mode.dev", true) &&
-		Config.BoolDefault("watch", true) &&
-		Config.StringDefault("watch.mode", "normal") == "eager"
+	return CurrentConfig().BoolDefault("mode.dev", true) &&
+		CurrentConfig().BoolDefault("watch", true) &&
+		CurrentConfig().StringDefault("watch.mode", "normal") == "eager"
 }
 
 func (w *Watcher) rebuildRequired(ev fsnotify.Event, listener Listener) bool {